@@ -0,0 +1,181 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ErrWriterClosed reports a message sent to an AsyncWriter after Close.
+var ErrWriterClosed = fmt.Errorf("writer is closed")
+
+// Priority selects the send queue lane of a message given to an AsyncWriter.
+type Priority int
+
+const (
+	// PriorityLow is used for bulk traffic such as advices
+	PriorityLow Priority = iota
+
+	// PriorityHigh is used for time critical traffic such as reversals and network management messages
+	PriorityHigh
+)
+
+// starvationLimit is the number of consecutive high priority messages an AsyncWriter sends while low priority messages
+// are waiting before it sends one low priority message
+const starvationLimit = 8
+
+// AsyncWriter queues messages and writes them to a Writer from a background goroutine so callers never wait on the
+// network. Messages are queued in two lanes; high priority messages are written ahead of queued low priority
+// messages, but after every 8 consecutive high priority messages a waiting low priority message is written so the low
+// lane is never starved. Messages within a lane are written in the order they were sent. An AsyncWriter is safe for
+// concurrent use.
+//
+//	aw := simplemli.NewAsyncWriter(simplemli.NewWriter(conn, simplemli.MLI2I), 1024)
+//	defer aw.Close()
+//
+//	err := aw.Send(reversal, simplemli.PriorityHigh)
+type AsyncWriter struct {
+	w    *Writer
+	high chan []byte
+	low  chan []byte
+	done chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	errMu sync.Mutex
+	err   error
+}
+
+// NewAsyncWriter starts an AsyncWriter writing to w with up to queue messages waiting in each lane.
+func NewAsyncWriter(w *Writer, queue int) *AsyncWriter {
+	a := &AsyncWriter{
+		w:    w,
+		high: make(chan []byte, queue),
+		low:  make(chan []byte, queue),
+		done: make(chan struct{}),
+	}
+	go a.loop()
+	return a
+}
+
+// Send queues msg in the lane for p, blocking while that lane is full. The AsyncWriter takes ownership of msg, which
+// must not be modified after Send returns. If a previous write failed, Send returns that error without queueing msg.
+func (a *AsyncWriter) Send(msg []byte, p Priority) error {
+	if err := a.Err(); err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrWriterClosed
+	}
+	if p == PriorityHigh {
+		a.high <- msg
+	} else {
+		a.low <- msg
+	}
+	return nil
+}
+
+// loop writes queued messages until both lanes are closed and drained
+func (a *AsyncWriter) loop() {
+	defer close(a.done)
+	high, low := a.high, a.low
+	run := 0
+	for high != nil || low != nil {
+		// Give a waiting low priority message a turn after a run of high priority messages
+		if run >= starvationLimit && low != nil {
+			select {
+			case msg, ok := <-low:
+				if !ok {
+					low = nil
+					continue
+				}
+				a.write(msg)
+				run = 0
+				continue
+			default:
+			}
+		}
+
+		select {
+		case msg, ok := <-high:
+			if !ok {
+				high = nil
+				continue
+			}
+			a.write(msg)
+			run++
+			continue
+		default:
+		}
+
+		select {
+		case msg, ok := <-high:
+			if !ok {
+				high = nil
+				continue
+			}
+			a.write(msg)
+			run++
+		case msg, ok := <-low:
+			if !ok {
+				low = nil
+				continue
+			}
+			a.write(msg)
+			run = 0
+		}
+	}
+}
+
+// write writes msg unless a previous write failed
+func (a *AsyncWriter) write(msg []byte) {
+	if a.Err() != nil {
+		return
+	}
+	err := a.w.WriteMessage(msg)
+	if err != nil {
+		a.errMu.Lock()
+		a.err = err
+		a.errMu.Unlock()
+	}
+}
+
+// Err returns the first error encountered while writing. Messages queued after a failed write are discarded.
+func (a *AsyncWriter) Err() error {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	return a.err
+}
+
+// Close stops accepting messages, waits for queued messages to be written and returns the first write error, if any.
+// Close does not close the underlying connection.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.high)
+		close(a.low)
+	}
+	a.mu.Unlock()
+	<-a.done
+	return a.Err()
+}
@@ -0,0 +1,96 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks writes until release is closed, collecting the messages written
+type gatedWriter struct {
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.release
+	return g.buf.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("Priority", func(t *testing.T) {
+		gw := &gatedWriter{release: make(chan struct{})}
+		aw := NewAsyncWriter(NewWriter(gw, MLI2E), 32)
+
+		// The first message occupies the writer while the rest are queued
+		_ = aw.Send([]byte("0"), PriorityLow)
+		for len(aw.low) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for i := 0; i < 3; i++ {
+			_ = aw.Send([]byte("L"), PriorityLow)
+		}
+		for i := 0; i < 10; i++ {
+			_ = aw.Send([]byte("H"), PriorityHigh)
+		}
+		close(gw.release)
+		err := aw.Close()
+		if err != nil {
+			t.Fatalf("Unexpected error closing - %s", err)
+		}
+
+		var order strings.Builder
+		r := NewReader(&gw.buf, MLI2E)
+		for {
+			msg, err := r.ReadMessage()
+			if err != nil {
+				break
+			}
+			order.Write(msg)
+		}
+		if expected := "0HHHHHHHHLHHLL"; order.String() != expected {
+			t.Errorf("Unexpected send order, got %s expected %s", order.String(), expected)
+		}
+	})
+
+	t.Run("Write Error", func(t *testing.T) {
+		aw := NewAsyncWriter(NewWriter(&lockedBuffer{err: fmt.Errorf("broken pipe")}, MLI2E), 4)
+		_ = aw.Send([]byte("a"), PriorityHigh)
+		err := aw.Close()
+		if err == nil {
+			t.Errorf("Expected write error from Close - got nil")
+		}
+		err = aw.Send([]byte("b"), PriorityHigh)
+		if err == nil {
+			t.Errorf("Expected Send to fail after a write error - got nil")
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		aw := NewAsyncWriter(NewWriter(&bytes.Buffer{}, MLI2E), 4)
+		_ = aw.Close()
+		_ = aw.Close()
+		err := aw.Send([]byte("a"), PriorityLow)
+		if !errors.Is(err, ErrWriterClosed) {
+			t.Errorf("Expected ErrWriterClosed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,306 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Direction identifies whether a frame was received or sent.
+type Direction byte
+
+const (
+	// Inbound frames were read from the connection
+	Inbound Direction = 'I'
+
+	// Outbound frames were written to the connection
+	Outbound Direction = 'O'
+)
+
+// String returns "in" or "out".
+func (d Direction) String() string {
+	if d == Outbound {
+		return "out"
+	}
+	return "in"
+}
+
+// SyncPolicy selects when an AuditLog syncs its file to disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs after every record, so a frame is never sent or returned before it is on disk
+	SyncAlways SyncPolicy = iota
+
+	// SyncPeriodic syncs at the configured interval, bounding the records lost on a crash
+	SyncPeriodic
+
+	// SyncNever leaves syncing to the operating system
+	SyncNever
+)
+
+// auditRecordHeader is the size of the audit record header, an 8-byte timestamp, a 1-byte direction and a 4-byte
+// frame length, so frames over 4GB cannot be recorded
+const auditRecordHeader = 13
+
+// AuditConfig configures an AuditLog.
+type AuditConfig struct {
+	// Dir is the directory audit files are written to, it is created if it does not exist
+	Dir string
+
+	// Prefix begins the name of each audit file, defaulting to "audit"
+	Prefix string
+
+	// MaxSize rotates to a new file before a record would take the current file beyond MaxSize bytes, zero disables
+	// size based rotation
+	MaxSize int64
+
+	// MaxAge rotates to a new file once the current file has been open for MaxAge, zero disables time based rotation
+	MaxAge time.Duration
+
+	// Sync selects when records are synced to disk
+	Sync SyncPolicy
+
+	// SyncInterval is the interval used by SyncPeriodic, defaulting to one second
+	SyncInterval time.Duration
+}
+
+// AuditRecord is a single frame read from an audit file.
+type AuditRecord struct {
+	// Time the frame was received or sent
+	Time time.Time
+
+	// Direction of the frame
+	Direction Direction
+
+	// Frame is the raw frame including its MLI
+	Frame []byte
+}
+
+// AuditLog is a write-ahead log retaining every frame sent and received, for compliance environments which require
+// raw message retention at the transport layer. Outbound frames are recorded before they are written to the
+// connection and inbound frames before they are returned; if a record cannot be written the read or write fails.
+// Records are appended to files which are rotated by size and age. An AuditLog may be shared by many connections and
+// is safe for concurrent use.
+//
+//	audit, err := simplemli.NewAuditLog(simplemli.AuditConfig{Dir: "/var/audit", MaxSize: 64 << 20})
+//	if err != nil {
+//		// Do something
+//	}
+//	defer audit.Close()
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithAuditLog(audit))
+type AuditLog struct {
+	mu     sync.Mutex
+	cfg    AuditConfig
+	f      *os.File
+	size   int64
+	opened time.Time
+	seq    int
+	dirty  bool
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewAuditLog creates cfg.Dir if required and opens the first audit file.
+func NewAuditLog(cfg AuditConfig) (*AuditLog, error) {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "audit"
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = time.Second
+	}
+
+	err := os.MkdirAll(cfg.Dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create audit directory - %w", err)
+	}
+
+	a := &AuditLog{cfg: cfg}
+	err = a.rotate(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Sync == SyncPeriodic {
+		a.stop = make(chan struct{})
+		a.done = make(chan struct{})
+		go a.syncLoop()
+	}
+	return a, nil
+}
+
+// rotate closes the current file and opens a new one, the caller must hold the lock
+func (a *AuditLog) rotate(now time.Time) error {
+	if a.f != nil {
+		err := a.f.Sync()
+		if cerr := a.f.Close(); err == nil {
+			err = cerr
+		}
+		a.f = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		a.seq++
+		name := fmt.Sprintf("%s-%s-%04d.log", a.cfg.Prefix, now.UTC().Format("20060102T150405"), a.seq)
+		f, err := os.OpenFile(filepath.Join(a.cfg.Dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0600)
+		if os.IsExist(err) {
+			// A restart within the same second, or another AuditLog sharing the directory, took this name
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to open audit file - %w", err)
+		}
+		a.f, a.size, a.opened, a.dirty = f, 0, now, false
+		return nil
+	}
+}
+
+// Path returns the path of the file currently being written.
+func (a *AuditLog) Path() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return ""
+	}
+	return a.f.Name()
+}
+
+// record appends the frame made up of mli and body
+func (a *AuditLog) record(d Direction, t time.Time, mli, body []byte) error {
+	n := len(mli) + len(body)
+	// int may be 64 bits, compare as uint64 so the check compiles on 32-bit platforms
+	if uint64(n) > math.MaxUint32 {
+		return fmt.Errorf("%w - %d byte frame exceeds the audit record limit of %d", ErrTooLarge, n,
+			uint64(math.MaxUint32))
+	}
+	b := make([]byte, auditRecordHeader, auditRecordHeader+n)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	b[8] = byte(d)
+	binary.BigEndian.PutUint32(b[9:], uint32(n))
+	b = append(append(b, mli...), body...)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	now := time.Now()
+	if (a.cfg.MaxSize > 0 && a.size > 0 && a.size+int64(len(b)) > a.cfg.MaxSize) ||
+		(a.cfg.MaxAge > 0 && now.Sub(a.opened) >= a.cfg.MaxAge) {
+		err := a.rotate(now)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := a.f.Write(b)
+	if err != nil {
+		return err
+	}
+	a.size += int64(len(b))
+	a.dirty = true
+
+	if a.cfg.Sync == SyncAlways {
+		a.dirty = false
+		return a.f.Sync()
+	}
+	return nil
+}
+
+// syncLoop syncs written records at the configured interval until the log is closed
+func (a *AuditLog) syncLoop() {
+	defer close(a.done)
+	t := time.NewTicker(a.cfg.SyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			a.mu.Lock()
+			if a.dirty && a.f != nil {
+				a.dirty = false
+				_ = a.f.Sync()
+			}
+			a.mu.Unlock()
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// Close syncs and closes the current audit file.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.mu.Unlock()
+
+	if a.stop != nil {
+		close(a.stop)
+		<-a.done
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err := a.f.Sync()
+	if cerr := a.f.Close(); err == nil {
+		err = cerr
+	}
+	a.f = nil
+	return err
+}
+
+// ReadAuditFile reads every record from the audit file at path, oldest first.
+func ReadAuditFile(path string) ([]AuditRecord, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []AuditRecord
+	for len(b) > 0 {
+		if len(b) < auditRecordHeader {
+			return records, io.ErrUnexpectedEOF
+		}
+		n := int(binary.BigEndian.Uint32(b[9:]))
+		if len(b)-auditRecordHeader < n {
+			return records, io.ErrUnexpectedEOF
+		}
+		records = append(records, AuditRecord{
+			Time:      time.Unix(0, int64(binary.BigEndian.Uint64(b))),
+			Direction: Direction(b[8]),
+			Frame:     b[auditRecordHeader : auditRecordHeader+n : auditRecordHeader+n],
+		})
+		b = b[auditRecordHeader+n:]
+	}
+	return records, nil
+}
@@ -0,0 +1,143 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Run("Record", func(t *testing.T) {
+		dir := t.TempDir()
+		audit, err := NewAuditLog(AuditConfig{Dir: filepath.Join(dir, "audit")})
+		if err != nil {
+			t.Fatalf("Unable to create audit log - %s", err)
+		}
+
+		r := NewReader(bytes.NewReader([]byte{0x00, 0x05, 'a', 'b', 'c'}), MLI2I, WithAuditLog(audit))
+		_, err = r.ReadMessage()
+		if err != nil {
+			t.Fatalf("Unexpected error reading - %s", err)
+		}
+		w := NewWriter(&bytes.Buffer{}, MLI2E, WithAuditLog(audit))
+		err = w.WriteMessage([]byte("de"))
+		if err != nil {
+			t.Fatalf("Unexpected error writing - %s", err)
+		}
+
+		path := audit.Path()
+		err = audit.Close()
+		if err != nil {
+			t.Fatalf("Unexpected error closing audit log - %s", err)
+		}
+
+		records, err := ReadAuditFile(path)
+		if err != nil || len(records) != 2 {
+			t.Fatalf("Unexpected records, got %+v, %v", records, err)
+		}
+		if records[0].Direction != Inbound || !bytes.Equal(records[0].Frame, []byte{0x00, 0x05, 'a', 'b', 'c'}) {
+			t.Errorf("Unexpected inbound record, got %s %x", records[0].Direction, records[0].Frame)
+		}
+		if records[1].Direction != Outbound || !bytes.Equal(records[1].Frame, []byte{0x00, 0x02, 'd', 'e'}) {
+			t.Errorf("Unexpected outbound record, got %s %x", records[1].Direction, records[1].Frame)
+		}
+		if time.Since(records[0].Time) > time.Minute {
+			t.Errorf("Unexpected record time, got %s", records[0].Time)
+		}
+
+		err = w.WriteMessage([]byte("f"))
+		if err == nil {
+			t.Errorf("Expected write to fail once the audit log is closed - got nil")
+		}
+	})
+
+	t.Run("Rotate by Size", func(t *testing.T) {
+		dir := t.TempDir()
+		audit, err := NewAuditLog(AuditConfig{Dir: dir, Prefix: "host", MaxSize: 50, Sync: SyncNever})
+		if err != nil {
+			t.Fatalf("Unable to create audit log - %s", err)
+		}
+		w := NewWriter(&bytes.Buffer{}, MLI2E, WithAuditLog(audit))
+		for i := 0; i < 5; i++ {
+			_ = w.WriteMessage([]byte("0800000000"))
+		}
+		_ = audit.Close()
+
+		files, _ := filepath.Glob(filepath.Join(dir, "host-*.log"))
+		if len(files) != 3 {
+			t.Fatalf("Expected 3 audit files, got %v", files)
+		}
+		total := 0
+		for _, f := range files {
+			records, err := ReadAuditFile(f)
+			if err != nil {
+				t.Errorf("Unable to read %s - %s", f, err)
+			}
+			total += len(records)
+		}
+		if total != 5 {
+			t.Errorf("Expected 5 records across files, got %d", total)
+		}
+	})
+
+	t.Run("Rotate by Age", func(t *testing.T) {
+		dir := t.TempDir()
+		audit, err := NewAuditLog(AuditConfig{Dir: dir, MaxAge: 10 * time.Millisecond, Sync: SyncPeriodic,
+			SyncInterval: 5 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("Unable to create audit log - %s", err)
+		}
+		w := NewWriter(&bytes.Buffer{}, MLI2E, WithAuditLog(audit))
+		_ = w.WriteMessage([]byte("a"))
+		time.Sleep(20 * time.Millisecond)
+		_ = w.WriteMessage([]byte("b"))
+		_ = audit.Close()
+
+		files, _ := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+		if len(files) != 2 {
+			t.Errorf("Expected 2 audit files, got %v", files)
+		}
+	})
+
+	t.Run("Shared Directory", func(t *testing.T) {
+		dir := t.TempDir()
+		for i := 0; i < 3; i++ {
+			audit, err := NewAuditLog(AuditConfig{Dir: dir})
+			if err != nil {
+				t.Fatalf("Unable to create audit log %d sharing a directory - %s", i+1, err)
+			}
+			defer audit.Close()
+		}
+
+		files, _ := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+		if len(files) != 3 {
+			t.Errorf("Expected 3 audit files, got %v", files)
+		}
+	})
+
+	t.Run("Truncated File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bad.log")
+		_ = os.WriteFile(path, []byte{0x00, 0x01}, 0600)
+		_, err := ReadAuditFile(path)
+		if err == nil {
+			t.Errorf("Expected error reading truncated audit file - got nil")
+		}
+	})
+}
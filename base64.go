@@ -0,0 +1,62 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// ErrFrameLength reports a complete frame whose MLI does not match the length of the message it carries.
+var ErrFrameLength = fmt.Errorf("mli does not match message length")
+
+// EncodeFrameBase64 frames msg with an MLI and returns the complete frame as standard base64, for embedding frames
+// in JSON transports and log pipelines.
+//
+//	s, err := simplemli.EncodeFrameBase64(simplemli.MLI2I, msg)
+func EncodeFrameBase64(key string, msg []byte) (string, error) {
+	frame, err := EncodeFrame(key, msg)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(frame), nil
+}
+
+// DecodeFrameBase64 decodes a complete frame from standard base64 and validates that the embedded MLI matches the
+// length of the message which follows it. A mismatch returns ErrFrameLength.
+func DecodeFrameBase64(key, s string) (Frame, error) {
+	size, err := Size(key)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return Frame{}, fmt.Errorf("invalid base64 frame - %w", err)
+	}
+	if len(b) < size {
+		return Frame{}, &SizeError{Key: key, Expected: size, Got: len(b)}
+	}
+
+	mli := b[:size:size]
+	n, err := DecodeBytes(key, mli)
+	if err != nil {
+		return Frame{}, err
+	}
+	if n != len(b)-size {
+		return Frame{}, fmt.Errorf("%w - mli %d, message %d bytes", ErrFrameLength, n, len(b)-size)
+	}
+	return Frame{Key: key, MLI: mli, Body: b[size:], Length: n}, nil
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestFrameBase64(t *testing.T) {
+	t.Run("Round Trip", func(t *testing.T) {
+		s, err := EncodeFrameBase64(MLI2I, []byte("0800"))
+		if err != nil || s != "AAYwODAw" {
+			t.Fatalf("Unexpected result from EncodeFrameBase64, got %q, %v", s, err)
+		}
+
+		f, err := DecodeFrameBase64(MLI2I, s)
+		if err != nil {
+			t.Fatalf("Unexpected error from DecodeFrameBase64 - %s", err)
+		}
+		if string(f.Body) != "0800" || f.Length != 4 || f.Key != MLI2I {
+			t.Errorf("Unexpected frame, got %+v", f)
+		}
+	})
+
+	t.Run("Length Mismatch", func(t *testing.T) {
+		s := base64.StdEncoding.EncodeToString([]byte{0x00, 0x07, '0', '8', '0', '0'})
+		_, err := DecodeFrameBase64(MLI2I, s)
+		if !errors.Is(err, ErrFrameLength) {
+			t.Errorf("Expected ErrFrameLength, got %v", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := DecodeFrameBase64(MLI2I, "not base64!")
+		if err == nil {
+			t.Errorf("Expected error for invalid base64 - got nil")
+		}
+		_, err = DecodeFrameBase64(MLI4E, "AAY=")
+		if !errors.Is(err, ErrByteSize) {
+			t.Errorf("Expected ErrByteSize for short frame, got %v", err)
+		}
+		_, err = DecodeFrameBase64("3Q", "AAY=")
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+		_, err = EncodeFrameBase64("3Q", nil)
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+	})
+}
@@ -0,0 +1,192 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen reports an attempt to connect to an endpoint whose circuit breaker is open.
+var ErrCircuitOpen = fmt.Errorf("circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed allows all attempts
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen rejects all attempts until the cool-down period has passed
+	BreakerOpen
+
+	// BreakerHalfOpen allows a single trial attempt which decides whether the breaker closes or re-opens
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops connection attempts to an endpoint after a number of consecutive failures, preventing connect
+// storms against a flapping host. Once the cool-down period has passed a single trial attempt is allowed, success
+// closes the breaker and failure re-opens it for another cool-down period.
+//
+// A CircuitBreaker tracks one endpoint, use one breaker per endpoint. Failures of application level checks such as
+// echo tests should be reported with Failure so they count towards opening the breaker.
+//
+//	b := simplemli.NewCircuitBreaker(5, 30*time.Second, func(from, to simplemli.BreakerState) {
+//		log.Printf("breaker for %s changed from %s to %s", addr, from, to)
+//	})
+//
+//	conn, err := b.Dial(func() (net.Conn, error) {
+//		return net.DialTimeout("tcp", addr, 5*time.Second)
+//	})
+//
+// A CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker which opens after threshold consecutive failures and stays open
+// for cooldown. If onChange is not nil it is called on every state change.
+func NewCircuitBreaker(threshold int, cooldown time.Duration, onChange func(from, to BreakerState)) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		onChange:  onChange,
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns ErrCircuitOpen if an attempt should not be made now. Every nil return must be followed by a call to
+// Success or Failure reporting the outcome of the attempt.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	var from, to BreakerState
+	changed := false
+	defer func() {
+		b.mu.Unlock()
+		if changed && b.onChange != nil {
+			b.onChange(from, to)
+		}
+	}()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		from, to, changed = b.state, BreakerHalfOpen, true
+		b.state = BreakerHalfOpen
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		// Only one trial attempt at a time
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success reports a successful attempt, closing the breaker.
+func (b *CircuitBreaker) Success() {
+	b.setState(func() BreakerState {
+		b.failures = 0
+		b.trial = false
+		return BreakerClosed
+	})
+}
+
+// Failure reports a failed attempt, opening the breaker once the failure threshold is reached or if the failed
+// attempt was a half-open trial.
+func (b *CircuitBreaker) Failure() {
+	b.setState(func() BreakerState {
+		b.failures++
+		b.trial = false
+		if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+			b.openedAt = time.Now()
+			return BreakerOpen
+		}
+		return b.state
+	})
+}
+
+// setState applies fn under the lock and notifies onChange if the state changed
+func (b *CircuitBreaker) setState(fn func() BreakerState) {
+	b.mu.Lock()
+	from := b.state
+	to := fn()
+	b.state = to
+	b.mu.Unlock()
+
+	if from != to && b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
+
+// Dial calls dial if the breaker allows an attempt and records the outcome.
+func (b *CircuitBreaker) Dial(dial func() (net.Conn, error)) (net.Conn, error) {
+	err := b.Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dial()
+	if err != nil {
+		b.Failure()
+		return nil, err
+	}
+	b.Success()
+	return conn, nil
+}
@@ -0,0 +1,104 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var changes []string
+	b := NewCircuitBreaker(2, 20*time.Millisecond, func(from, to BreakerState) {
+		changes = append(changes, from.String()+"->"+to.String())
+	})
+
+	calls := 0
+	fail := func() (net.Conn, error) {
+		calls++
+		return nil, fmt.Errorf("connection refused")
+	}
+	succeed := func() (net.Conn, error) {
+		calls++
+		c, _ := net.Pipe()
+		return c, nil
+	}
+
+	t.Run("Opens after threshold", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := b.Dial(fail)
+			if err == nil || err == ErrCircuitOpen {
+				t.Errorf("Expected dial error on attempt %d, got %v", i, err)
+			}
+		}
+		if b.State() != BreakerOpen {
+			t.Errorf("Expected breaker to be open, got %s", b.State())
+		}
+
+		_, err := b.Dial(fail)
+		if err != ErrCircuitOpen {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("Dial attempted while breaker open, got %d calls", calls)
+		}
+	})
+
+	t.Run("Half-open trial failure re-opens", func(t *testing.T) {
+		time.Sleep(25 * time.Millisecond)
+		if b.State() != BreakerHalfOpen {
+			t.Errorf("Expected breaker to be half-open after cool-down, got %s", b.State())
+		}
+		_, _ = b.Dial(fail)
+		if b.State() != BreakerOpen {
+			t.Errorf("Expected failed trial to re-open breaker, got %s", b.State())
+		}
+	})
+
+	t.Run("Half-open trial success closes", func(t *testing.T) {
+		time.Sleep(25 * time.Millisecond)
+		c, err := b.Dial(succeed)
+		if err != nil {
+			t.Errorf("Unexpected error from trial dial - %s", err)
+		}
+		if c != nil {
+			c.Close()
+		}
+		if b.State() != BreakerClosed {
+			t.Errorf("Expected successful trial to close breaker, got %s", b.State())
+		}
+	})
+
+	t.Run("State change callback", func(t *testing.T) {
+		expected := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+		if fmt.Sprint(changes) != fmt.Sprint(expected) {
+			t.Errorf("Unexpected state changes, got %v expected %v", changes, expected)
+		}
+	})
+
+	t.Run("Single trial while half-open", func(t *testing.T) {
+		b := NewCircuitBreaker(1, 0, nil)
+		b.Failure()
+		if err := b.Allow(); err != nil {
+			t.Errorf("Expected trial attempt to be allowed, got %v", err)
+		}
+		if err := b.Allow(); err != ErrCircuitOpen {
+			t.Errorf("Expected concurrent trial to be rejected, got %v", err)
+		}
+	})
+}
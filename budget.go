@@ -0,0 +1,127 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded reports a message which could not be read without exceeding a shared MemoryBudget.
+var ErrBudgetExceeded = fmt.Errorf("message exceeds memory budget")
+
+// BudgetPolicy selects how a Reader handles messages which would exceed its MemoryBudget.
+type BudgetPolicy int
+
+const (
+	// BudgetBlock delays reading the message until enough of the budget has been released
+	BudgetBlock BudgetPolicy = iota
+
+	// BudgetShed discards the message and returns ErrBudgetExceeded, leaving the stream positioned at the next frame
+	BudgetShed
+)
+
+// MemoryBudget caps the total size of message buffers being filled across every Reader sharing it, so one partner
+// sending maximum size frames cannot exhaust the memory of a process hosting thousands of connections. A MemoryBudget
+// is safe for concurrent use.
+//
+//	budget := simplemli.NewMemoryBudget(256<<20, simplemli.BudgetShed)
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI4E, simplemli.WithMemoryBudget(budget))
+type MemoryBudget struct {
+	mu     sync.Mutex
+	limit  int64
+	used   int64
+	policy BudgetPolicy
+
+	// released is closed by Release to wake blocked acquires, it is nil while none are waiting
+	released chan struct{}
+}
+
+// errStopped reports an acquire abandoned because its stop channel was closed
+var errStopped = fmt.Errorf("budget wait stopped")
+
+// NewMemoryBudget returns a MemoryBudget allowing at most limit bytes in flight, handling messages beyond the limit
+// according to policy.
+func NewMemoryBudget(limit int64, policy BudgetPolicy) *MemoryBudget {
+	return &MemoryBudget{limit: limit, policy: policy}
+}
+
+// Acquire reserves n bytes of the budget. With the BudgetBlock policy Acquire waits until n bytes are available, with
+// BudgetShed it returns ErrBudgetExceeded immediately. Requests larger than the whole budget always return
+// ErrBudgetExceeded.
+func (b *MemoryBudget) Acquire(n int) error {
+	return b.acquire(context.Background(), nil, n)
+}
+
+// AcquireContext is Acquire, abandoning a wait with the BudgetBlock policy and returning ctx.Err() if ctx is done
+// before n bytes are available.
+func (b *MemoryBudget) AcquireContext(ctx context.Context, n int) error {
+	return b.acquire(ctx, nil, n)
+}
+
+// acquire reserves n bytes, a blocked wait is abandoned when ctx is done or stop is closed, returning errStopped for
+// the latter
+func (b *MemoryBudget) acquire(ctx context.Context, stop <-chan struct{}, n int) error {
+	b.mu.Lock()
+	if int64(n) > b.limit {
+		b.mu.Unlock()
+		return fmt.Errorf("%w - %d bytes, limit %d", ErrBudgetExceeded, n, b.limit)
+	}
+	for b.used+int64(n) > b.limit {
+		if b.policy == BudgetShed {
+			used := b.used
+			b.mu.Unlock()
+			return fmt.Errorf("%w - %d bytes, %d of %d in use", ErrBudgetExceeded, n, used, b.limit)
+		}
+		if b.released == nil {
+			b.released = make(chan struct{})
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return errStopped
+		}
+		b.mu.Lock()
+	}
+	b.used += int64(n)
+	b.mu.Unlock()
+	return nil
+}
+
+// Release returns n bytes reserved by Acquire to the budget.
+func (b *MemoryBudget) Release(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= int64(n)
+	if b.released != nil {
+		close(b.released)
+		b.released = nil
+	}
+}
+
+// InUse returns the number of bytes currently reserved.
+func (b *MemoryBudget) InUse() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
@@ -0,0 +1,152 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	t.Run("Shed", func(t *testing.T) {
+		b := NewMemoryBudget(8, BudgetShed)
+		err := b.Acquire(6)
+		if err != nil {
+			t.Fatalf("Unexpected error acquiring - %s", err)
+		}
+
+		stream := []byte{0x00, 0x05, 'a', 'b', 'c', 'd', 'e', 0x00, 0x01, 'f'}
+		r := NewReader(bytes.NewReader(stream), MLI2E, WithMemoryBudget(b))
+		_, err = r.ReadMessage()
+		if !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+		}
+		msg, err := r.ReadMessage()
+		if err != nil || string(msg) != "f" {
+			t.Errorf("Expected stream to continue at the next frame, got %q, %v", msg, err)
+		}
+		if b.InUse() != 6 {
+			t.Errorf("Expected reservation to be released after read, got %d in use", b.InUse())
+		}
+	})
+
+	t.Run("Shed Truncated", func(t *testing.T) {
+		r := NewReader(bytes.NewReader([]byte{0x00, 0x05, 'a'}), MLI2E, WithMemoryBudget(NewMemoryBudget(2, BudgetShed)))
+		_, err := r.ReadMessage()
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		b := NewMemoryBudget(8, BudgetBlock)
+		_ = b.Acquire(6)
+
+		done := make(chan error, 1)
+		go func() {
+			r := NewReader(bytes.NewReader([]byte{0x00, 0x05, 'a', 'b', 'c', 'd', 'e'}), MLI2E, WithMemoryBudget(b))
+			_, err := r.ReadMessage()
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			t.Fatalf("Expected read to block, got %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		b.Release(6)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Unexpected error reading - %s", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Read did not resume after budget was released")
+		}
+		if b.InUse() != 0 {
+			t.Errorf("Expected budget to be fully released, got %d in use", b.InUse())
+		}
+	})
+
+	t.Run("Abandon Blocked Read", func(t *testing.T) {
+		for name, read := range map[string]func(conn *MessageConn) error{
+			"Close": func(conn *MessageConn) error {
+				time.AfterFunc(20*time.Millisecond, func() { _ = conn.Close() })
+				_, err := conn.ReadMessage()
+				if !errors.Is(err, net.ErrClosed) {
+					return fmt.Errorf("expected net.ErrClosed, got %v", err)
+				}
+				return nil
+			},
+			"Context": func(conn *MessageConn) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(20*time.Millisecond, cancel)
+				_, err := conn.ReadMessageContext(ctx)
+				if err != context.Canceled {
+					return fmt.Errorf("expected context.Canceled, got %v", err)
+				}
+				return nil
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				b := NewMemoryBudget(8, BudgetBlock)
+				_ = b.Acquire(6)
+				a, peer := net.Pipe()
+				conn := NewMessageConn(a, MLI2E, WithMemoryBudget(b))
+				defer conn.Close()
+				defer peer.Close()
+				go func() { _, _ = peer.Write([]byte{0x00, 0x05, 'a', 'b', 'c', 'd', 'e'}) }()
+
+				done := make(chan error, 1)
+				go func() { done <- read(conn) }()
+				select {
+				case err := <-done:
+					if err != nil {
+						t.Errorf("Unexpected result abandoning read - %s", err)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Read blocked on the budget was not released")
+				}
+				if b.InUse() != 6 {
+					t.Errorf("Expected abandoned read to leave the budget unchanged, got %d in use", b.InUse())
+				}
+			})
+		}
+	})
+
+	t.Run("Acquire Context", func(t *testing.T) {
+		b := NewMemoryBudget(8, BudgetBlock)
+		_ = b.Acquire(6)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := b.AcquireContext(ctx, 4); err != context.DeadlineExceeded {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Larger Than Budget", func(t *testing.T) {
+		err := NewMemoryBudget(4, BudgetBlock).Acquire(5)
+		if !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+		}
+	})
+}
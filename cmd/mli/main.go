@@ -0,0 +1,249 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mli encodes and decodes Message Length Indicators from the command line.
+
+Usage:
+
+	mli encode -type 2I 1500
+	05de
+
+	mli decode -type 2BCD2 00000288
+	284
+
+	mli validate -type 2I fixtures.bin
+
+	mli convert -from 2I -to 4E -o replay.bin archive.bin
+
+Encode prints the hex MLI for a message length and decode prints the message length for a hex MLI. Lengths follow
+the same inclusive/exclusive conventions as the simplemli package.
+
+Validate reads an MLI-framed stream from a file, or stdin if no file is given, and reports the offset and size of every
+frame. The first framing inconsistency is reported and validate exits with a non-zero status, making it suitable for
+checking fixtures in CI pipelines. Use -max to also fail frames larger than a given size and -q to only print failures.
+
+Convert rewrites a file of framed messages from one MLI type to another so archived traffic can be replayed against
+hosts using different framing. Input is read from a file or stdin and output is written to -o or stdout.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+const usage = `Usage: mli <command> [flags] <args>
+
+Commands:
+  encode -type <mli type> <length>    print the hex MLI for a message length
+  decode -type <mli type> <hex mli>   print the message length for a hex MLI
+  validate -type <mli type> [file]    check an MLI-framed stream, reading stdin if no file is given
+  convert -from <type> -to <type> [-o out] [file]
+                                      rewrite framed messages from one MLI type to another
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command described by args and returns the process exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "encode":
+		return encode(args[1:], stdout, stderr)
+	case "decode":
+		return decode(args[1:], stdout, stderr)
+	case "validate":
+		return validate(args[1:], stdin, stdout, stderr)
+	case "convert":
+		return convert(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// newFlagSet returns a flag set for a sub-command with the common -type flag registered
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	key := fs.String("type", simplemli.MLI2I, framing.TypeUsage())
+	return fs, key
+}
+
+// encode prints the hex encoded MLI for the provided length
+func encode(args []string, stdout, stderr io.Writer) int {
+	fs, key := newFlagSet("encode", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "encode requires a single length argument")
+		return 2
+	}
+
+	length, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid length %q - %s\n", fs.Arg(0), err)
+		return 1
+	}
+
+	b, err := simplemli.Encode(*key, length)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to encode length - %s\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, hex.EncodeToString(b))
+	return 0
+}
+
+// decode prints the message length for the provided hex encoded MLI
+func decode(args []string, stdout, stderr io.Writer) int {
+	fs, key := newFlagSet("decode", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fmt.Fprintln(stderr, "decode requires a hex MLI argument")
+		return 2
+	}
+
+	// Allow the MLI to be pasted with spaces between bytes, e.g. "00 00 02 88"
+	n, err := simplemli.DecodeHex(*key, strings.Join(fs.Args(), " "))
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to decode MLI - %s\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, n)
+	return 0
+}
+
+// validate checks that a stream consists entirely of valid frames
+func validate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs, key := newFlagSet("validate", stderr)
+	maxLen := fs.Int("max", 0, "maximum valid message size in bytes, 0 for no limit")
+	quiet := fs.Bool("q", false, "only report failures")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "validate accepts at most one file argument")
+		return 2
+	}
+
+	var buf []byte
+	var err error
+	if fs.NArg() == 0 || fs.Arg(0) == "-" {
+		buf, err = io.ReadAll(stdin)
+	} else {
+		buf, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to read input - %s\n", err)
+		return 1
+	}
+
+	frames, splitErr := framing.Split(buf, *key)
+	if _, ok := splitErr.(*framing.BreakError); splitErr != nil && !ok {
+		fmt.Fprintln(stderr, splitErr)
+		return 2
+	}
+
+	for i, f := range frames {
+		if *maxLen > 0 && len(f.Body) > *maxLen {
+			fmt.Fprintf(stdout, "frame %d offset %d length %d exceeds maximum of %d\n", i+1, f.Offset, len(f.Body), *maxLen)
+			return 1
+		}
+		if !*quiet {
+			fmt.Fprintf(stdout, "frame %d offset %d length %d ok\n", i+1, f.Offset, len(f.Body))
+		}
+	}
+
+	if splitErr != nil {
+		fmt.Fprintf(stdout, "invalid after %d frames, %s\n", len(frames), splitErr)
+		return 1
+	}
+	if !*quiet {
+		fmt.Fprintf(stdout, "valid, %d frames %d bytes\n", len(frames), len(buf))
+	}
+	return 0
+}
+
+// convert rewrites a stream of framed messages from one MLI type to another
+func convert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "MLI type of the input")
+	to := fs.String("to", "", "MLI type of the output")
+	out := fs.String("o", "-", "output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" || *to == "" || fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "convert requires -from and -to and accepts at most one file argument")
+		return 2
+	}
+
+	in := stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to open input - %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	w := stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to create output - %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	n, err := simplemli.Reframe(bw, bufio.NewReader(in), *from, *to)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "conversion failed after %d messages - %s\n", n, err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "converted %d messages\n", n)
+	return 0
+}
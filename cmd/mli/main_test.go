@@ -0,0 +1,69 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type runCase struct {
+	Name   string
+	Args   []string
+	Code   int
+	Stdin  []byte
+	Stdout string
+}
+
+func TestRun(t *testing.T) {
+	rc := []runCase{
+		{Name: "Encode 2I", Args: []string{"encode", "-type", "2I", "1500"}, Stdout: "05de\n"},
+		{Name: "Encode A4E", Args: []string{"encode", "-type", "A4E", "43"}, Stdout: "30303433\n"},
+		{Name: "Decode 2BCD2", Args: []string{"decode", "-type", "2BCD2", "00000288"}, Stdout: "284\n"},
+		{Name: "Decode with spaces", Args: []string{"decode", "-type", "2BCD2", "00", "00", "02", "88"}, Stdout: "284\n"},
+		{Name: "Decode with prefix", Args: []string{"decode", "-type", "2I", "0x05DE"}, Stdout: "1500\n"},
+		{Name: "Encode bad length", Args: []string{"encode", "-type", "2I", "abc"}, Code: 1},
+		{Name: "Encode bad type", Args: []string{"encode", "-type", "9Z", "10"}, Code: 1},
+		{Name: "Decode bad hex", Args: []string{"decode", "-type", "2I", "zz"}, Code: 1},
+		{Name: "Decode wrong size", Args: []string{"decode", "-type", "4I", "05de"}, Code: 1},
+		{Name: "Validate", Args: []string{"validate", "-type", "2E"}, Stdin: []byte("\x00\x01a\x00\x02bc"), Stdout: "frame 1 offset 0 length 1 ok\nframe 2 offset 3 length 2 ok\nvalid, 2 frames 7 bytes\n"},
+		{Name: "Validate quiet", Args: []string{"validate", "-type", "2E", "-q", "-"}, Stdin: []byte("\x00\x01a")},
+		{Name: "Validate empty", Args: []string{"validate", "-type", "4I"}, Stdout: "valid, 0 frames 0 bytes\n"},
+		{Name: "Validate truncated", Args: []string{"validate", "-type", "2E", "-q"}, Stdin: []byte("\x00\x01a\x00\x05bc"), Code: 1, Stdout: "invalid after 1 frames, framing breaks at offset 3 - truncated message, MLI 0005 declares more than the 2 bytes remaining\n"},
+		{Name: "Validate invalid MLI", Args: []string{"validate", "-type", "2I"}, Stdin: []byte("\x00\x01"), Code: 1},
+		{Name: "Validate too large", Args: []string{"validate", "-type", "2E", "-max", "1"}, Stdin: []byte("\x00\x02bc"), Code: 1, Stdout: "frame 1 offset 0 length 2 exceeds maximum of 1\n"},
+		{Name: "Validate missing file", Args: []string{"validate", "/does/not/exist"}, Code: 1},
+		{Name: "Convert", Args: []string{"convert", "-from", "2E", "-to", "A4E"}, Stdin: []byte("\x00\x01a\x00\x02bc"), Stdout: "0001a0002bc"},
+		{Name: "Convert truncated", Args: []string{"convert", "-from", "2E", "-to", "A4E"}, Stdin: []byte("\x00\x01a\x00\x05bc"), Code: 1},
+		{Name: "Convert bad type", Args: []string{"convert", "-from", "2E", "-to", "9Z"}, Stdin: []byte("\x00\x01a"), Code: 1},
+		{Name: "Convert missing types", Args: []string{"convert", "-from", "2E"}, Code: 2},
+		{Name: "Missing args", Args: []string{"encode"}, Code: 2},
+		{Name: "No command", Args: []string{}, Code: 2},
+		{Name: "Unknown command", Args: []string{"frobnicate"}, Code: 2},
+	}
+
+	for _, c := range rc {
+		t.Run(c.Name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(c.Args, bytes.NewReader(c.Stdin), &stdout, &stderr)
+			if code != c.Code {
+				t.Errorf("Unexpected exit code running %s, got %d expected %d - %s", strings.Join(c.Args, " "), code, c.Code, stderr.String())
+			}
+			if c.Stdout != "" && stdout.String() != c.Stdout {
+				t.Errorf("Unexpected output, got %q expected %q", stdout.String(), c.Stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mlibench is a load-test client for MLI-framed hosts. It opens a number of connections, sends messages at a
+target rate and reports latency percentiles and error counts.
+
+Usage:
+
+	mlibench -addr host:9000 -type 2I -conns 4 -tps 200 -duration 30s
+
+Every request carries a 12-digit sequence number at -id-offset which the host must return at the same position of its
+response, responses are matched to requests using that number so hosts may respond out of order. An echo server such
+as mliecho satisfies this requirement. Requests are built from -size bytes of padding, or from the contents of -file
+with the sequence number written over the template at -id-offset.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// idLen is the length of the sequence number embedded in each request
+const idLen = 12
+
+// bench holds the configuration of a load test
+type bench struct {
+	addr     string
+	key      string
+	conns    int
+	tps      float64
+	duration time.Duration
+	total    int
+	timeout  time.Duration
+	inflight int
+	template []byte
+	idOffset int
+}
+
+// report summarizes the results of a load test
+type report struct {
+	sent      int
+	ok        int
+	timeouts  int
+	errors    int
+	elapsed   time.Duration
+	latencies []time.Duration
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run parses flags and runs the load test, returning the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mlibench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	b := &bench{}
+	fs.StringVar(&b.addr, "addr", "", "host address")
+	fs.StringVar(&b.key, "type", simplemli.MLI2I, framing.TypeUsage())
+	fs.IntVar(&b.conns, "conns", 1, "number of connections")
+	fs.Float64Var(&b.tps, "tps", 100, "target requests per second across all connections")
+	fs.DurationVar(&b.duration, "duration", 10*time.Second, "test duration")
+	fs.IntVar(&b.total, "n", 0, "stop after sending this many requests, 0 to run for -duration")
+	fs.DurationVar(&b.timeout, "timeout", 5*time.Second, "response timeout")
+	fs.IntVar(&b.inflight, "inflight", 1000, "maximum outstanding requests")
+	fs.IntVar(&b.idOffset, "id-offset", 0, "position of the sequence number within each message")
+	size := fs.Int("size", 64, "request size in bytes")
+	file := fs.String("file", "", "request template file, overrides -size")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if b.addr == "" {
+		fmt.Fprintln(stderr, "-addr is required")
+		return 2
+	}
+	if _, err := framing.Size(b.key); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if b.conns < 1 || b.tps <= 0 || b.inflight < 1 {
+		fmt.Fprintln(stderr, "-conns, -tps and -inflight must be positive")
+		return 2
+	}
+
+	b.template = make([]byte, *size)
+	for i := range b.template {
+		b.template[i] = ' '
+	}
+	if *file != "" {
+		t, err := os.ReadFile(*file)
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to read template - %s\n", err)
+			return 1
+		}
+		b.template = t
+	}
+	if b.idOffset < 0 || b.idOffset+idLen > len(b.template) {
+		fmt.Fprintf(stderr, "messages must be at least %d bytes to hold the sequence number at -id-offset\n", b.idOffset+idLen)
+		return 2
+	}
+
+	r, err := b.run(context.Background())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	r.print(stdout)
+	if r.ok == 0 || r.errors+r.timeouts > 0 {
+		return 1
+	}
+	return 0
+}
+
+// run executes the load test
+func (b *bench) run(ctx context.Context) (*report, error) {
+	correlate := func(msg []byte) (string, error) {
+		if len(msg) < b.idOffset+idLen {
+			return "", fmt.Errorf("message too short to contain sequence number")
+		}
+		return string(msg[b.idOffset : b.idOffset+idLen]), nil
+	}
+
+	limiter, err := simplemli.NewRateLimiter(b.tps, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	muxes := make([]*simplemli.Mux, 0, b.conns)
+	defer func() {
+		for _, m := range muxes {
+			m.Close()
+		}
+	}()
+	for i := 0; i < b.conns; i++ {
+		conn, err := net.DialTimeout("tcp", b.addr, b.timeout)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect - %w", err)
+		}
+		muxes = append(muxes, simplemli.NewMux(conn, b.key, correlate))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.duration)
+	defer cancel()
+
+	r := &report{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, b.inflight)
+
+	start := time.Now()
+	for seq := 1; b.total == 0 || seq <= b.total; seq++ {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		msg := append([]byte{}, b.template...)
+		copy(msg[b.idOffset:], fmt.Sprintf("%0*d", idLen, seq))
+		m := muxes[seq%len(muxes)]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			rctx, rcancel := context.WithTimeout(context.Background(), b.timeout)
+			defer rcancel()
+			sent := time.Now()
+			_, err := m.RoundTrip(rctx, msg)
+			latency := time.Since(sent)
+
+			mu.Lock()
+			defer mu.Unlock()
+			r.sent++
+			switch {
+			case err == nil:
+				r.ok++
+				r.latencies = append(r.latencies, latency)
+			case err == context.DeadlineExceeded:
+				r.timeouts++
+			default:
+				r.errors++
+			}
+		}()
+	}
+	wg.Wait()
+	r.elapsed = time.Since(start)
+	return r, nil
+}
+
+// percentile returns the latency at percentile p of the sorted latencies
+func (r *report) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.latencies))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(r.latencies) {
+		i = len(r.latencies) - 1
+	}
+	return r.latencies[i]
+}
+
+// print writes a human readable summary of the report
+func (r *report) print(w io.Writer) {
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+
+	var sum time.Duration
+	for _, l := range r.latencies {
+		sum += l
+	}
+	var mean time.Duration
+	if len(r.latencies) > 0 {
+		mean = sum / time.Duration(len(r.latencies))
+	}
+
+	fmt.Fprintf(w, "requests:  %d sent, %d ok, %d timeouts, %d errors\n", r.sent, r.ok, r.timeouts, r.errors)
+	fmt.Fprintf(w, "duration:  %s (%s tps)\n", r.elapsed.Round(time.Millisecond), strconv.FormatFloat(float64(r.sent)/r.elapsed.Seconds(), 'f', 1, 64))
+	fmt.Fprintf(w, "latency:   mean %s", mean)
+	for _, p := range []float64{50, 90, 99, 99.9, 100} {
+		fmt.Fprintf(w, ", p%s %s", strconv.FormatFloat(p, 'f', -1, 64), r.percentile(p))
+	}
+	fmt.Fprintln(w)
+}
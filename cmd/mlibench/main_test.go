@@ -0,0 +1,96 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// echoServer starts a framed echo server returning its address
+func echoServer(t *testing.T, key string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start listener - %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					msg, err := simplemli.ReadFrame(conn, key)
+					if err != nil {
+						return
+					}
+					_ = simplemli.WriteFrame(conn, key, msg)
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRun(t *testing.T) {
+	addr := echoServer(t, "2E")
+
+	t.Run("Fixed count", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-addr", addr, "-type", "2E", "-conns", "3", "-tps", "1000", "-n", "50", "-size", "20"}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Unexpected exit code %d - %s %s", code, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "50 sent, 50 ok, 0 timeouts, 0 errors") {
+			t.Errorf("Unexpected report %s", stdout.String())
+		}
+	})
+
+	t.Run("Bad usage", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{}, &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 without -addr, got %d", code)
+		}
+		if code := run([]string{"-addr", addr, "-size", "4"}, &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 for messages too small for the sequence number, got %d", code)
+		}
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	r := &report{}
+	for i := 1; i <= 100; i++ {
+		r.latencies = append(r.latencies, time.Duration(i)*time.Millisecond)
+	}
+	if p := r.percentile(50); p != 50*time.Millisecond {
+		t.Errorf("Unexpected p50, got %s", p)
+	}
+	if p := r.percentile(99); p != 99*time.Millisecond {
+		t.Errorf("Unexpected p99, got %s", p)
+	}
+	if p := r.percentile(100); p != 100*time.Millisecond {
+		t.Errorf("Unexpected max, got %s", p)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mliecho is an MLI-framed echo server for connectivity tests and load rigs. Every message received is sent back
+to the client unchanged.
+
+Usage:
+
+	mliecho -listen :9000 -type 2I [-latency 50ms] [-jitter 20ms]
+
+With -latency each response is delayed by the given duration, -jitter adds a further random delay of up to the given
+duration. Delayed responses are sent independently, so with jitter responses may be returned in a different order
+than the requests were received.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// server holds the configuration of a running echo server
+type server struct {
+	key     string
+	maxLen  int
+	latency time.Duration
+	jitter  time.Duration
+	verbose bool
+	logger  *log.Logger
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run parses flags and serves until interrupted, returning the process exit code
+func run(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mliecho", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", ":9000", "address to accept connections on")
+	s := &server{logger: log.New(stderr, "mliecho: ", log.LstdFlags)}
+	fs.StringVar(&s.key, "type", simplemli.MLI2I, framing.TypeUsage())
+	fs.IntVar(&s.maxLen, "max", 0, "maximum message size in bytes, 0 for no limit")
+	fs.DurationVar(&s.latency, "latency", 0, "fixed delay before each response")
+	fs.DurationVar(&s.jitter, "jitter", 0, "maximum random delay added to each response")
+	fs.BoolVar(&s.verbose, "v", false, "log every message")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := framing.Size(s.key); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to listen - %s\n", err)
+		return 1
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		ln.Close()
+	}()
+
+	s.logger.Printf("echoing %s messages on %s", s.key, ln.Addr())
+	s.serve(ln)
+	return 0
+}
+
+// serve accepts connections until the listener is closed
+func (s *server) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle echoes messages on a single connection until it is closed
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	s.logger.Printf("%s: connected", conn.RemoteAddr())
+
+	var wmu sync.Mutex
+	var wg sync.WaitGroup
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for {
+		msg, err := simplemli.ReadFrame(conn, s.key, simplemli.MaxLength(s.maxLen))
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Printf("%s: read failed - %s", conn.RemoteAddr(), err)
+			}
+			break
+		}
+		if s.verbose {
+			s.logger.Printf("%s: %d bytes", conn.RemoteAddr(), len(msg))
+		}
+
+		delay := s.latency
+		if s.jitter > 0 {
+			delay += time.Duration(rnd.Int63n(int64(s.jitter)))
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			wmu.Lock()
+			defer wmu.Unlock()
+			err := simplemli.WriteFrame(conn, s.key, msg)
+			if err != nil && s.verbose {
+				s.logger.Printf("%s: write failed - %s", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	s.logger.Printf("%s: disconnected", conn.RemoteAddr())
+}
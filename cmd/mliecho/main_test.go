@@ -0,0 +1,68 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestEcho(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start listener - %s", err)
+	}
+	defer ln.Close()
+
+	s := &server{
+		key:     "4E",
+		latency: 20 * time.Millisecond,
+		jitter:  5 * time.Millisecond,
+		logger:  log.New(io.Discard, "", 0),
+	}
+	go s.serve(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Unable to connect - %s", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	start := time.Now()
+	err = simplemli.WriteFrame(conn, "4E", []byte("ping"))
+	if err != nil {
+		t.Fatalf("Unable to write message - %s", err)
+	}
+	msg, err := simplemli.ReadFrame(conn, "4E")
+	if err != nil || string(msg) != "ping" {
+		t.Errorf("Unexpected echo, got %q, %v", msg, err)
+	}
+	if elapsed := time.Since(start); elapsed < s.latency {
+		t.Errorf("Expected response to be delayed by at least %s, took %s", s.latency, elapsed)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{"-type", "9Z"}, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 with invalid MLI type, got %d", code)
+	}
+}
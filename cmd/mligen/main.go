@@ -0,0 +1,167 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mligen generates MLI-framed sample messages for seeding load tests and validating partner readers.
+
+Usage:
+
+	mligen -type 2I -n 1000 -min 100 -max 1500 -content random -out sample.bin
+	mligen -type 4E -n 10 -min 64 -content pattern -pattern 0200 -connect host:9000
+	mligen -type 2E -n 5 -min 512 -content file -file body.bin
+
+Message sizes are chosen uniformly between -min and -max, -max defaults to -min for fixed size messages. The random
+content type fills each message with random bytes, pattern repeats the -pattern string and file repeats the contents
+of -file to the chosen size. Messages are written to -out ("-" for stdout) or sent to the -connect address.
+*/
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// generator produces message bodies
+type generator struct {
+	rnd     *rand.Rand
+	min     int
+	max     int
+	content string
+	fill    []byte
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run parses flags and generates messages, returning the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mligen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	key := fs.String("type", simplemli.MLI2I, framing.TypeUsage())
+	count := fs.Int("n", 1, "number of messages to generate")
+	minLen := fs.Int("min", 64, "minimum message size in bytes")
+	maxLen := fs.Int("max", 0, "maximum message size in bytes, defaults to -min")
+	content := fs.String("content", "random", "message content (random, pattern, file)")
+	pattern := fs.String("pattern", "0123456789", "repeated content for the pattern content type")
+	file := fs.String("file", "", "file whose contents are repeated for the file content type")
+	seed := fs.Int64("seed", 0, "random seed, 0 uses the current time")
+	out := fs.String("out", "-", "output file, - for stdout")
+	connect := fs.String("connect", "", "send messages to this TCP address instead of -out")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := framing.Size(*key); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *maxLen == 0 {
+		*maxLen = *minLen
+	}
+	if *minLen < 0 || *maxLen < *minLen {
+		fmt.Fprintln(stderr, "-min must be positive and no greater than -max")
+		return 2
+	}
+
+	g := &generator{min: *minLen, max: *maxLen, content: *content}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g.rnd = rand.New(rand.NewSource(*seed))
+
+	switch *content {
+	case "random":
+	case "pattern":
+		g.fill = []byte(*pattern)
+	case "file":
+		b, err := os.ReadFile(*file)
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to read content file - %s\n", err)
+			return 1
+		}
+		g.fill = b
+	default:
+		fmt.Fprintf(stderr, "unknown content type %q\n", *content)
+		return 2
+	}
+	if *content != "random" && len(g.fill) == 0 && *maxLen > 0 {
+		fmt.Fprintln(stderr, "content must not be empty")
+		return 2
+	}
+
+	var w io.Writer
+	switch {
+	case *connect != "":
+		conn, err := net.Dial("tcp", *connect)
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to connect - %s\n", err)
+			return 1
+		}
+		defer conn.Close()
+		w = conn
+	case *out == "-":
+		w = stdout
+	default:
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to create output file - %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	for i := 0; i < *count; i++ {
+		err := simplemli.WriteFrame(bw, *key, g.next())
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to write message %d - %s\n", i+1, err)
+			return 1
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "unable to write messages - %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// next returns the next message body
+func (g *generator) next() []byte {
+	n := g.min
+	if g.max > g.min {
+		n += g.rnd.Intn(g.max - g.min + 1)
+	}
+
+	b := make([]byte, n)
+	if g.content == "random" {
+		_, _ = g.rnd.Read(b)
+		return b
+	}
+
+	for i := 0; i < n; i += len(g.fill) {
+		copy(b[i:], g.fill)
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("Random sizes", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2I", "-n", "50", "-min", "10", "-max", "20", "-seed", "1"}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+
+		frames, err := framing.Split(stdout.Bytes(), "2I")
+		if err != nil {
+			t.Fatalf("Generated output is not validly framed - %s", err)
+		}
+		if len(frames) != 50 {
+			t.Errorf("Unexpected number of messages, got %d expected 50", len(frames))
+		}
+		for _, f := range frames {
+			if len(f.Body) < 10 || len(f.Body) > 20 {
+				t.Errorf("Message size %d outside of requested range", len(f.Body))
+			}
+		}
+	})
+
+	t.Run("Deterministic seed", func(t *testing.T) {
+		var a, b, stderr bytes.Buffer
+		run([]string{"-n", "3", "-seed", "42"}, &a, &stderr)
+		run([]string{"-n", "3", "-seed", "42"}, &b, &stderr)
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			t.Errorf("Expected identical output for identical seeds")
+		}
+	})
+
+	t.Run("Pattern", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2E", "-min", "10", "-content", "pattern", "-pattern", "0200"}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+		expected := "\x00\x0a0200020002"
+		if stdout.String() != expected {
+			t.Errorf("Unexpected output, got %q expected %q", stdout.String(), expected)
+		}
+	})
+
+	t.Run("File content to output file", func(t *testing.T) {
+		dir := t.TempDir()
+		content := filepath.Join(dir, "body.bin")
+		out := filepath.Join(dir, "out.bin")
+		if err := os.WriteFile(content, []byte("abc"), 0600); err != nil {
+			t.Fatalf("Unable to write content file - %s", err)
+		}
+
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2E", "-n", "2", "-min", "4", "-content", "file", "-file", content, "-out", out}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+		b, _ := os.ReadFile(out)
+		if string(b) != "\x00\x04abca\x00\x04abca" {
+			t.Errorf("Unexpected output file contents %q", b)
+		}
+	})
+
+	t.Run("Socket", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Unable to start listener - %s", err)
+		}
+		defer ln.Close()
+
+		got := make(chan int)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				got <- -1
+				return
+			}
+			defer conn.Close()
+			n := 0
+			for {
+				if _, err := simplemli.ReadFrame(conn, "4E"); err != nil {
+					got <- n
+					return
+				}
+				n++
+			}
+		}()
+
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "4E", "-n", "7", "-connect", ln.Addr().String()}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+		if n := <-got; n != 7 {
+			t.Errorf("Unexpected number of messages received, got %d expected 7", n)
+		}
+	})
+
+	t.Run("Bad usage", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"-min", "10", "-max", "5"}, &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 for invalid size range, got %d", code)
+		}
+		if code := run([]string{"-content", "nope"}, &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 for unknown content type, got %d", code)
+		}
+	})
+}
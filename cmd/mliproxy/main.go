@@ -0,0 +1,166 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mliproxy is a reframing TCP proxy. It accepts connections framed with one MLI type and forwards each message
+to an upstream host framed with another, translating responses back to the original framing.
+
+Usage:
+
+	mliproxy -listen :9000 -in-type 2I -upstream host:9100 -out-type 2BCD2
+
+Each accepted connection is given its own upstream connection. Messages larger than -max-in (client to upstream) or
+-max-out (upstream to client) close both connections. Use -v to log every message.
+
+Only the MLIs are parsed by the proxy. Message bodies are copied between connections with io.CopyN, which on Linux
+splices them kernel-side without copying them through user space.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// proxy holds the configuration of a running proxy
+type proxy struct {
+	upstream    string
+	inKey       string
+	outKey      string
+	maxIn       int
+	maxOut      int
+	dialTimeout time.Duration
+	verbose     bool
+	logger      *log.Logger
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run parses flags and serves until interrupted, returning the process exit code
+func run(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mliproxy", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", ":9000", "address to accept client connections on")
+	p := &proxy{logger: log.New(stderr, "mliproxy: ", log.LstdFlags)}
+	fs.StringVar(&p.upstream, "upstream", "", "upstream host address")
+	fs.StringVar(&p.inKey, "in-type", simplemli.MLI2I, "MLI type used by clients")
+	fs.StringVar(&p.outKey, "out-type", simplemli.MLI2I, "MLI type used by the upstream host")
+	fs.IntVar(&p.maxIn, "max-in", 0, "maximum client message size in bytes, 0 for no limit")
+	fs.IntVar(&p.maxOut, "max-out", 0, "maximum upstream message size in bytes, 0 for no limit")
+	fs.DurationVar(&p.dialTimeout, "dial-timeout", 10*time.Second, "upstream connect timeout")
+	fs.BoolVar(&p.verbose, "v", false, "log every message")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if p.upstream == "" {
+		fmt.Fprintln(stderr, "-upstream is required")
+		return 2
+	}
+	for _, k := range []string{p.inKey, p.outKey} {
+		if _, err := framing.Size(k); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to listen - %s\n", err)
+		return 1
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		ln.Close()
+	}()
+
+	p.logger.Printf("proxying %s (%s) to %s (%s)", ln.Addr(), p.inKey, p.upstream, p.outKey)
+	p.serve(ln)
+	return 0
+}
+
+// serve accepts client connections until the listener is closed
+func (p *proxy) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(conn)
+	}
+}
+
+// handle proxies a single client connection
+func (p *proxy) handle(client net.Conn) {
+	defer client.Close()
+
+	upstream, err := net.DialTimeout("tcp", p.upstream, p.dialTimeout)
+	if err != nil {
+		p.logger.Printf("%s: unable to connect upstream - %s", client.RemoteAddr(), err)
+		return
+	}
+	defer upstream.Close()
+
+	p.logger.Printf("%s: connected to %s", client.RemoteAddr(), upstream.RemoteAddr())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.relay(client, p.inKey, p.maxIn, upstream, p.outKey, client.RemoteAddr().String()+" ->")
+		upstream.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		p.relay(upstream, p.outKey, p.maxOut, client, p.inKey, client.RemoteAddr().String()+" <-")
+		client.Close()
+	}()
+	wg.Wait()
+
+	p.logger.Printf("%s: disconnected", client.RemoteAddr())
+}
+
+// relay reads messages framed with srcKey from src and writes them to dst framed with dstKey until either side fails
+func (p *proxy) relay(src io.Reader, srcKey string, maxLen int, dst io.Writer, dstKey, label string) {
+	// Messages are not transformed, so bodies are copied with framing.Copy which lets the kernel splice them between
+	// the TCP connections and only the MLIs are handled in user space
+	for {
+		n, err := framing.Copy(dst, src, srcKey, dstKey, maxLen)
+		if err != nil {
+			if err != io.EOF {
+				p.logger.Printf("%s relay failed - %s", label, err)
+			}
+			return
+		}
+
+		if p.verbose {
+			p.logger.Printf("%s %d bytes", label, n)
+		}
+	}
+}
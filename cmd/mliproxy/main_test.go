@@ -0,0 +1,107 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestProxy(t *testing.T) {
+	// Upstream echo server speaking 2BCD2
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start upstream listener - %s", err)
+	}
+	defer up.Close()
+	go func() {
+		for {
+			conn, err := up.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					msg, err := simplemli.ReadFrame(conn, "2BCD2")
+					if err != nil {
+						return
+					}
+					_ = simplemli.WriteFrame(conn, "2BCD2", msg)
+				}
+			}()
+		}
+	}()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start proxy listener - %s", err)
+	}
+	defer ln.Close()
+
+	p := &proxy{
+		upstream:    up.Addr().String(),
+		inKey:       "2I",
+		outKey:      "2BCD2",
+		maxIn:       16,
+		dialTimeout: time.Second,
+		logger:      log.New(io.Discard, "", 0),
+	}
+	go p.serve(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Unable to connect to proxy - %s", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	t.Run("Reframes both directions", func(t *testing.T) {
+		err := simplemli.WriteFrame(conn, "2I", []byte("0800 echo"))
+		if err != nil {
+			t.Fatalf("Unable to write to proxy - %s", err)
+		}
+		msg, err := simplemli.ReadFrame(conn, "2I")
+		if err != nil || string(msg) != "0800 echo" {
+			t.Errorf("Unexpected response through proxy, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Oversized message closes connection", func(t *testing.T) {
+		err := simplemli.WriteFrame(conn, "2I", bytes.Repeat([]byte("x"), 17))
+		if err != nil {
+			t.Fatalf("Unable to write to proxy - %s", err)
+		}
+		_, err = simplemli.ReadFrame(conn, "2I")
+		if err == nil {
+			t.Errorf("Expected connection to be closed after oversized message")
+		}
+	})
+}
+
+func TestRunUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{}, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 without -upstream, got %d", code)
+	}
+	if code := run([]string{"-upstream", "localhost:1", "-in-type", "9Z"}, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 with invalid MLI type, got %d", code)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mlisniff decodes MLI-framed messages from a pcap capture file.
+
+Usage:
+
+	mlisniff -type 2I [-port 9000] [-hex] capture.pcap
+
+TCP streams are reassembled per direction and each message is printed with the timestamp of the packet which
+completed it, the direction and the message length. With -hex the message body is also printed. Use -port to only
+decode connections to or from a given port.
+
+Classic pcap files are supported with Ethernet, Linux cooked, loopback and raw IP link types. Captures in pcapng format
+can be converted with "editcap -F pcap in.pcapng out.pcap". Streams which were already established when the capture
+started are decoded from the first data seen, which must fall on a message boundary.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// timeFormat is used for frame timestamps
+const timeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run decodes the capture described by args and returns the process exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mlisniff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	key := fs.String("type", simplemli.MLI2I, framing.TypeUsage())
+	port := fs.Int("port", 0, "only decode connections using this port, 0 for all")
+	showHex := fs.Bool("hex", false, "print message bodies in hex")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mlisniff [flags] <capture.pcap>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if _, err := framing.Size(*key); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	in := stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to open capture - %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	pr, err := newPcapReader(bufio.NewReader(in))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+
+	streams := make(map[string]*stream)
+	for {
+		pkt, err := pr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			out.Flush()
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		seg, ok := pr.decode(pkt.data)
+		if !ok || (*port != 0 && int(seg.sport) != *port && int(seg.dport) != *port) {
+			continue
+		}
+
+		name := net.JoinHostPort(seg.src.String(), strconv.Itoa(int(seg.sport))) + " > " +
+			net.JoinHostPort(seg.dst.String(), strconv.Itoa(int(seg.dport)))
+		s, ok := streams[name]
+		if !ok || seg.syn {
+			s = newStream(name, *key)
+			streams[name] = s
+		}
+
+		hadErr := s.err != nil
+		for _, f := range s.add(seg, pkt.ts) {
+			fmt.Fprintf(out, "%s %s len=%d", f.ts.Format(timeFormat), s.name, len(f.body))
+			if *showHex {
+				fmt.Fprintf(out, " %s", hex.EncodeToString(f.body))
+			}
+			fmt.Fprintln(out)
+		}
+		if s.err != nil && !hadErr {
+			fmt.Fprintf(out, "%s %s %s\n", pkt.ts.Format(timeFormat), s.name, s.err)
+		}
+	}
+
+	// Report streams which ended with data that could not be framed
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	code := 0
+	for _, name := range names {
+		s := streams[name]
+		if s.err != nil {
+			code = 1
+			continue
+		}
+		n, gaps := s.missing()
+		if n > 0 || gaps {
+			fmt.Fprintf(stderr, "%s: %d trailing bytes not framed, missing segments %t\n", name, n, gaps)
+		}
+	}
+	return code
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// testPacket describes a TCP segment to include in a generated capture
+type testPacket struct {
+	src, dst     [4]byte
+	sport, dport uint16
+	seq          uint32
+	flags        byte
+	payload      []byte
+}
+
+// buildPcap returns a little-endian microsecond pcap of Ethernet/IPv4/TCP packets
+func buildPcap(pkts []testPacket) []byte {
+	var buf bytes.Buffer
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:], linkEthernet)
+	buf.Write(hdr)
+
+	for i, p := range pkts {
+		tcp := make([]byte, 20)
+		binary.BigEndian.PutUint16(tcp[0:], p.sport)
+		binary.BigEndian.PutUint16(tcp[2:], p.dport)
+		binary.BigEndian.PutUint32(tcp[4:], p.seq)
+		tcp[12] = 5 << 4
+		tcp[13] = p.flags
+		tcp = append(tcp, p.payload...)
+
+		ip := make([]byte, 20)
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(20+len(tcp)))
+		ip[8] = 64
+		ip[9] = 6
+		copy(ip[12:], p.src[:])
+		copy(ip[16:], p.dst[:])
+		ip = append(ip, tcp...)
+
+		eth := make([]byte, 14)
+		binary.BigEndian.PutUint16(eth[12:], 0x0800)
+		eth = append(eth, ip...)
+
+		rec := make([]byte, 16)
+		binary.LittleEndian.PutUint32(rec[0:], 1600000000)
+		binary.LittleEndian.PutUint32(rec[4:], uint32(i))
+		binary.LittleEndian.PutUint32(rec[8:], uint32(len(eth)))
+		binary.LittleEndian.PutUint32(rec[12:], uint32(len(eth)))
+		buf.Write(rec)
+		buf.Write(eth)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	client := [4]byte{10, 0, 0, 1}
+	server := [4]byte{10, 0, 0, 2}
+	req := []byte{0x00, 0x06, 'a', 'b', 'c', 'd'}
+	resp := []byte{0x00, 0x04, 'o', 'k'}
+
+	pkts := []testPacket{
+		{src: client, dst: server, sport: 40000, dport: 9000, seq: 99, flags: tcpSYN},
+		{src: server, dst: client, sport: 9000, dport: 40000, seq: 499, flags: tcpSYN},
+		// Request split across two segments delivered out of order
+		{src: client, dst: server, sport: 40000, dport: 9000, seq: 103, payload: req[3:]},
+		{src: client, dst: server, sport: 40000, dport: 9000, seq: 100, payload: req[:3]},
+		// Retransmission of data already seen
+		{src: client, dst: server, sport: 40000, dport: 9000, seq: 100, payload: req[:3]},
+		{src: server, dst: client, sport: 9000, dport: 40000, seq: 500, payload: resp},
+		// Unrelated traffic filtered by port
+		{src: client, dst: server, sport: 40001, dport: 8080, seq: 1, payload: []byte("\x00\x01junk")},
+	}
+	capture := buildPcap(pkts)
+
+	t.Run("Frames per direction", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2I", "-port", "9000", "-hex", "-"}, bytes.NewReader(capture), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Unexpected number of frames, got %d - %s", len(lines), stdout.String())
+		}
+		expected := []string{
+			"2020-09-13T12:26:40.000003Z 10.0.0.1:40000 > 10.0.0.2:9000 len=4 61626364",
+			"2020-09-13T12:26:40.000005Z 10.0.0.2:9000 > 10.0.0.1:40000 len=2 6f6b",
+		}
+		for i := range expected {
+			if lines[i] != expected[i] {
+				t.Errorf("Unexpected output line, got %q expected %q", lines[i], expected[i])
+			}
+		}
+	})
+
+	t.Run("Framing break", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2I", "-port", "8080", "-"}, bytes.NewReader(capture), &stdout, &stderr)
+		if code != 1 {
+			t.Errorf("Expected exit code 1 for stream with broken framing, got %d", code)
+		}
+		if !strings.Contains(stdout.String(), "framing breaks at offset 0") {
+			t.Errorf("Expected framing break to be reported, got %q", stdout.String())
+		}
+	})
+
+	t.Run("Not a pcap", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-"}, bytes.NewReader(make([]byte, 24)), &stdout, &stderr)
+		if code != 1 {
+			t.Errorf("Expected exit code 1 for invalid capture, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Link layer header types supported by the pcap reader
+const (
+	linkNull     = 0
+	linkEthernet = 1
+	linkRaw      = 101
+	linkRawAlt   = 12
+	linkLinuxSLL = 113
+	linkLinuxSL2 = 276
+)
+
+// pcapReader reads packets from a classic libpcap capture file
+type pcapReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	nanos bool
+	link  uint32
+	hdr   [16]byte
+}
+
+// packet is a single captured packet
+type packet struct {
+	ts   time.Time
+	data []byte
+}
+
+// newPcapReader reads the global header from r and returns a reader for the packets that follow
+func newPcapReader(r io.Reader) (*pcapReader, error) {
+	var hdr [24]byte
+	_, err := io.ReadFull(r, hdr[:])
+	if err != nil {
+		return nil, fmt.Errorf("unable to read pcap header - %w", err)
+	}
+
+	p := &pcapReader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(hdr[:]) == 0xa1b2c3d4:
+		p.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr[:]) == 0xa1b2c3d4:
+		p.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(hdr[:]) == 0xa1b23c4d:
+		p.order, p.nanos = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(hdr[:]) == 0xa1b23c4d:
+		p.order, p.nanos = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("not a pcap file, pcapng captures must be converted with editcap -F pcap")
+	}
+
+	p.link = p.order.Uint32(hdr[20:])
+	switch p.link {
+	case linkNull, linkEthernet, linkRaw, linkRawAlt, linkLinuxSLL, linkLinuxSL2:
+	default:
+		return nil, fmt.Errorf("unsupported pcap link type %d", p.link)
+	}
+	return p, nil
+}
+
+// next returns the next packet in the capture, or io.EOF at the end of the file
+func (p *pcapReader) next() (packet, error) {
+	_, err := io.ReadFull(p.r, p.hdr[:])
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("truncated pcap record header")
+		}
+		return packet{}, err
+	}
+
+	sec := int64(p.order.Uint32(p.hdr[0:]))
+	frac := int64(p.order.Uint32(p.hdr[4:]))
+	if !p.nanos {
+		frac *= int64(time.Microsecond)
+	}
+
+	data := make([]byte, p.order.Uint32(p.hdr[8:]))
+	_, err = io.ReadFull(p.r, data)
+	if err != nil {
+		return packet{}, fmt.Errorf("truncated pcap record - %w", err)
+	}
+	return packet{ts: time.Unix(sec, frac).UTC(), data: data}, nil
+}
+
+// segment is the TCP portion of a packet
+type segment struct {
+	src, dst net.IP
+	sport    uint16
+	dport    uint16
+	seq      uint32
+	syn      bool
+	fin      bool
+	rst      bool
+	payload  []byte
+}
+
+// TCP flag bits
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpRST = 0x04
+)
+
+// decode extracts the TCP segment from a packet, ok is false for packets which do not carry TCP
+func (p *pcapReader) decode(data []byte) (seg segment, ok bool) {
+	var etherType uint16
+	switch p.link {
+	case linkEthernet:
+		if len(data) < 14 {
+			return seg, false
+		}
+		etherType = binary.BigEndian.Uint16(data[12:])
+		data = data[14:]
+		// Skip 802.1Q VLAN tags
+		for etherType == 0x8100 && len(data) >= 4 {
+			etherType = binary.BigEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+	case linkLinuxSLL:
+		if len(data) < 16 {
+			return seg, false
+		}
+		etherType = binary.BigEndian.Uint16(data[14:])
+		data = data[16:]
+	case linkLinuxSL2:
+		if len(data) < 20 {
+			return seg, false
+		}
+		etherType = binary.BigEndian.Uint16(data[0:])
+		data = data[20:]
+	case linkNull:
+		if len(data) < 4 {
+			return seg, false
+		}
+		// Address family is in host byte order, IPv4 is 2 and IPv6 is one of 24, 28 or 30 depending on platform
+		family := p.order.Uint32(data)
+		data = data[4:]
+		etherType = 0x86dd
+		if family == 2 {
+			etherType = 0x0800
+		}
+	default:
+		if len(data) < 1 {
+			return seg, false
+		}
+		etherType = 0x86dd
+		if data[0]>>4 == 4 {
+			etherType = 0x0800
+		}
+	}
+
+	switch etherType {
+	case 0x0800:
+		if len(data) < 20 || data[0]>>4 != 4 {
+			return seg, false
+		}
+		ihl := int(data[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(data[2:]))
+		// Fragmented packets are not reassembled
+		if binary.BigEndian.Uint16(data[6:])&0x3fff != 0 || data[9] != 6 {
+			return seg, false
+		}
+		if total > len(data) || ihl > total {
+			return seg, false
+		}
+		seg.src, seg.dst = net.IP(data[12:16]), net.IP(data[16:20])
+		data = data[ihl:total]
+
+	case 0x86dd:
+		if len(data) < 40 || data[0]>>4 != 6 {
+			return seg, false
+		}
+		plen := int(binary.BigEndian.Uint16(data[4:]))
+		next := data[6]
+		seg.src, seg.dst = net.IP(data[8:24]), net.IP(data[24:40])
+		data = data[40:]
+		if plen > len(data) {
+			return seg, false
+		}
+		data = data[:plen]
+		// Walk hop-by-hop, routing and destination option extension headers
+		for next == 0 || next == 43 || next == 60 {
+			if len(data) < 8 {
+				return seg, false
+			}
+			l := (int(data[1]) + 1) * 8
+			if l > len(data) {
+				return seg, false
+			}
+			next = data[0]
+			data = data[l:]
+		}
+		if next != 6 {
+			return seg, false
+		}
+
+	default:
+		return seg, false
+	}
+
+	if len(data) < 20 {
+		return seg, false
+	}
+	off := int(data[12]>>4) * 4
+	if off < 20 || off > len(data) {
+		return seg, false
+	}
+	flags := data[13]
+	seg.sport = binary.BigEndian.Uint16(data[0:])
+	seg.dport = binary.BigEndian.Uint16(data[2:])
+	seg.seq = binary.BigEndian.Uint32(data[4:])
+	seg.syn = flags&tcpSYN != 0
+	seg.fin = flags&tcpFIN != 0
+	seg.rst = flags&tcpRST != 0
+	seg.payload = data[off:]
+	return seg, true
+}
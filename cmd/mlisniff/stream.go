@@ -0,0 +1,117 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package main
+
+import (
+	"time"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// frame is a message found within a reassembled stream
+type frame struct {
+	ts     time.Time
+	offset int
+	body   []byte
+}
+
+// stream reassembles one direction of a TCP connection and splits it into frames
+type stream struct {
+	name    string
+	key     string
+	started bool
+	next    uint32
+	pending map[uint32][]byte
+	buf     []byte
+	offset  int
+	err     error
+}
+
+// newStream returns an empty stream for the named direction
+func newStream(name, key string) *stream {
+	return &stream{name: name, key: key, pending: make(map[uint32][]byte)}
+}
+
+// add feeds a TCP segment into the stream and returns any frames completed by it
+func (s *stream) add(seg segment, ts time.Time) []frame {
+	if seg.syn {
+		s.started = true
+		s.next = seg.seq + 1
+		s.pending = make(map[uint32][]byte)
+		return nil
+	}
+	if len(seg.payload) == 0 || s.err != nil {
+		return nil
+	}
+
+	// Capture started mid-connection, assume the first data seen is in order
+	if !s.started {
+		s.started = true
+		s.next = seg.seq
+	}
+
+	s.pending[seg.seq] = seg.payload
+	for {
+		progressed := false
+		for seq, data := range s.pending {
+			diff := int32(seq - s.next)
+			switch {
+			case diff > 0:
+				// Gap before this segment, keep waiting
+				continue
+			case int(-diff) >= len(data):
+				// Retransmission of data already seen
+				delete(s.pending, seq)
+			default:
+				delete(s.pending, seq)
+				data = data[-diff:]
+				s.buf = append(s.buf, data...)
+				s.next += uint32(len(data))
+				progressed = true
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return s.frames(ts)
+}
+
+// frames splits complete frames off the front of the buffered stream
+func (s *stream) frames(ts time.Time) []frame {
+	var out []frame
+	for s.err == nil {
+		body, rest, err := simplemli.Deframe(s.key, s.buf)
+		if err == simplemli.ErrIncomplete {
+			break
+		}
+		if err != nil {
+			s.err = &framing.BreakError{Offset: s.offset, Reason: err.Error()}
+			break
+		}
+
+		out = append(out, frame{ts: ts, offset: s.offset, body: body})
+		s.offset += len(s.buf) - len(rest)
+		s.buf = rest
+	}
+	return out
+}
+
+// missing returns the number of bytes buffered but not framed and whether sequence gaps remain unfilled
+func (s *stream) missing() (int, bool) {
+	return len(s.buf), len(s.pending) > 0
+}
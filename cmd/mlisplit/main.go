@@ -0,0 +1,136 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mlisplit splits a raw byte dump into MLI-framed messages.
+
+Usage:
+
+	mlisplit [-type 2I] [-format hex|json|files] [-out dir] <file>
+
+The input file is read in full and split into messages. With the hex format each message is printed as a line
+containing its offset, length and hex body. The json format prints a JSON array describing each message and the files
+format writes each message body to a numbered file within the -out directory.
+
+If the input stops being validly framed, mlisplit outputs every message found before the break, reports the offset at
+which framing broke and exits with a non-zero status. Use "-" as the file name to read from stdin.
+*/
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// jsonFrame is the JSON representation of a single message
+type jsonFrame struct {
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	MLI    string `json:"mli"`
+	Body   string `json:"body"`
+}
+
+// run splits the input described by args and returns the process exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mlisplit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	key := fs.String("type", simplemli.MLI2I, framing.TypeUsage())
+	format := fs.String("format", "hex", "output format (hex, json, files)")
+	out := fs.String("out", ".", "output directory for the files format")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mlisplit [flags] <file>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	var buf []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		buf, err = io.ReadAll(stdin)
+	} else {
+		buf, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to read input - %s\n", err)
+		return 1
+	}
+
+	frames, splitErr := framing.Split(buf, *key)
+	if _, ok := splitErr.(*framing.BreakError); splitErr != nil && !ok {
+		fmt.Fprintln(stderr, splitErr)
+		return 1
+	}
+
+	switch *format {
+	case "hex":
+		for _, f := range frames {
+			fmt.Fprintf(stdout, "%d %d %s\n", f.Offset, len(f.Body), hex.EncodeToString(f.Body))
+		}
+
+	case "json":
+		list := make([]jsonFrame, 0, len(frames))
+		for _, f := range frames {
+			list = append(list, jsonFrame{
+				Offset: f.Offset,
+				Length: len(f.Body),
+				MLI:    hex.EncodeToString(f.MLI),
+				Body:   hex.EncodeToString(f.Body),
+			})
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			fmt.Fprintf(stderr, "unable to write JSON - %s\n", err)
+			return 1
+		}
+
+	case "files":
+		for i, f := range frames {
+			name := filepath.Join(*out, fmt.Sprintf("frame-%06d.bin", i+1))
+			if err := os.WriteFile(name, f.Body, 0644); err != nil {
+				fmt.Fprintf(stderr, "unable to write message - %s\n", err)
+				return 1
+			}
+		}
+
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return 2
+	}
+
+	if splitErr != nil {
+		fmt.Fprintf(stderr, "%d messages, %s\n", len(frames), splitErr)
+		return 1
+	}
+	fmt.Fprintf(stderr, "%d messages\n", len(frames))
+	return 0
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dump := []byte{0x00, 0x02, 'h', 'i', 0x00, 0x03, 'y', 'o', 'u'}
+
+	t.Run("Hex", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2E", "-"}, bytes.NewReader(dump), &stdout, &stderr)
+		if code != 0 {
+			t.Errorf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+		expected := "0 2 6869\n4 3 796f75\n"
+		if stdout.String() != expected {
+			t.Errorf("Unexpected output, got %q expected %q", stdout.String(), expected)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2E", "-format", "json", "-"}, bytes.NewReader(dump), &stdout, &stderr)
+		if code != 0 {
+			t.Errorf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+		var list []jsonFrame
+		if err := json.Unmarshal(stdout.Bytes(), &list); err != nil {
+			t.Fatalf("Unable to parse JSON output - %s", err)
+		}
+		if len(list) != 2 || list[1].Offset != 4 || list[1].MLI != "0003" {
+			t.Errorf("Unexpected JSON output %s", stdout.String())
+		}
+	})
+
+	t.Run("Files", func(t *testing.T) {
+		dir := t.TempDir()
+		in := filepath.Join(dir, "dump.bin")
+		if err := os.WriteFile(in, dump, 0600); err != nil {
+			t.Fatalf("Unable to write test input - %s", err)
+		}
+
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-type", "2E", "-format", "files", "-out", dir, in}, nil, &stdout, &stderr)
+		if code != 0 {
+			t.Errorf("Unexpected exit code %d - %s", code, stderr.String())
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "frame-000002.bin"))
+		if err != nil || string(b) != "you" {
+			t.Errorf("Unexpected message file contents %q, %v", b, err)
+		}
+	})
+
+	t.Run("Framing break", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		broken := append(append([]byte{}, dump...), 0x00, 0x09, 'x')
+		code := run([]string{"-type", "2E", "-"}, bytes.NewReader(broken), &stdout, &stderr)
+		if code != 1 {
+			t.Errorf("Expected exit code 1 for broken framing, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), "offset 9") {
+			t.Errorf("Expected break offset to be reported, got %q", stderr.String())
+		}
+		if strings.Count(stdout.String(), "\n") != 2 {
+			t.Errorf("Expected messages before the break to be output, got %q", stdout.String())
+		}
+	})
+
+	t.Run("Bad usage", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{}, nil, &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 without a file argument, got %d", code)
+		}
+		if code := run([]string{"-format", "xml", "-"}, bytes.NewReader(nil), &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 for unknown format, got %d", code)
+		}
+	})
+}
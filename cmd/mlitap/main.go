@@ -0,0 +1,333 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Command mlitap is a transparent TCP tap for MLI-framed traffic. It sits between clients and an upstream host, relays
+every frame unchanged and mirrors each frame to a dump file and/or a secondary TCP destination.
+
+Usage:
+
+	mlitap -listen :9000 -upstream host:9000 -type 2I -dump frames.log [-mirror collector:7000]
+
+Each mirrored frame is written as a single line containing the timestamp, the direction and the message length,
+followed by the hex message body unless -hex=false is given. Mirroring is best-effort, if the mirror destination falls
+behind frames are dropped from the mirror rather than delaying the relayed traffic.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// timeFormat is used for mirrored frame timestamps
+const timeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// tap holds the configuration of a running tap
+type tap struct {
+	upstream    string
+	key         string
+	maxLen      int
+	dialTimeout time.Duration
+	showHex     bool
+	mirrors     []*mirror
+	logger      *log.Logger
+
+	// mu guards the open connections, which are closed when the tap stops serving
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	closing bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run parses flags and serves until interrupted, returning the process exit code
+func run(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mlitap", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", ":9000", "address to accept client connections on")
+	t := &tap{logger: log.New(stderr, "mlitap: ", log.LstdFlags)}
+	fs.StringVar(&t.upstream, "upstream", "", "upstream host address")
+	fs.StringVar(&t.key, "type", simplemli.MLI2I, framing.TypeUsage())
+	fs.IntVar(&t.maxLen, "max", 0, "maximum message size in bytes, 0 for no limit")
+	fs.DurationVar(&t.dialTimeout, "dial-timeout", 10*time.Second, "upstream connect timeout")
+	fs.BoolVar(&t.showHex, "hex", true, "include hex message bodies in mirrored records")
+	dump := fs.String("dump", "", "file to append mirrored frames to")
+	mirrorAddr := fs.String("mirror", "", "TCP address to send mirrored frames to")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if t.upstream == "" {
+		fmt.Fprintln(stderr, "-upstream is required")
+		return 2
+	}
+	if _, err := framing.Size(t.key); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if *dump != "" {
+		f, err := os.OpenFile(*dump, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			fmt.Fprintf(stderr, "unable to open dump file - %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		t.mirrors = append(t.mirrors, newMirror(f, t.logger))
+	}
+	if *mirrorAddr != "" {
+		t.mirrors = append(t.mirrors, newMirror(&redialer{addr: *mirrorAddr, timeout: t.dialTimeout}, t.logger))
+	}
+	defer func() {
+		for _, m := range t.mirrors {
+			m.close()
+		}
+	}()
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "unable to listen - %s\n", err)
+		return 1
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		ln.Close()
+	}()
+
+	t.logger.Printf("tapping %s to %s", ln.Addr(), t.upstream)
+	t.serve(ln)
+	return 0
+}
+
+// serve accepts client connections until the listener is closed, then closes every open connection and waits for
+// their relays to finish, so no frame is mirrored once serve returns
+func (t *tap) serve(ln net.Listener) {
+	var wg sync.WaitGroup
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.handle(conn)
+		}()
+	}
+
+	t.mu.Lock()
+	t.closing = true
+	for c := range t.conns {
+		c.Close()
+	}
+	t.mu.Unlock()
+	wg.Wait()
+}
+
+// track records an open connection so serve can close it, returning false once the tap is stopping
+func (t *tap) track(c net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return false
+	}
+	if t.conns == nil {
+		t.conns = make(map[net.Conn]struct{})
+	}
+	t.conns[c] = struct{}{}
+	return true
+}
+
+// untrack forgets a connection once its relay has finished
+func (t *tap) untrack(c net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, c)
+}
+
+// handle relays a single client connection
+func (t *tap) handle(client net.Conn) {
+	defer client.Close()
+	if !t.track(client) {
+		return
+	}
+	defer t.untrack(client)
+
+	upstream, err := net.DialTimeout("tcp", t.upstream, t.dialTimeout)
+	if err != nil {
+		t.logger.Printf("%s: unable to connect upstream - %s", client.RemoteAddr(), err)
+		return
+	}
+	defer upstream.Close()
+	if !t.track(upstream) {
+		return
+	}
+	defer t.untrack(upstream)
+
+	in := client.RemoteAddr().String() + " > " + upstream.RemoteAddr().String()
+	out := upstream.RemoteAddr().String() + " > " + client.RemoteAddr().String()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		t.relay(client, upstream, in)
+		upstream.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		t.relay(upstream, client, out)
+		client.Close()
+	}()
+	wg.Wait()
+}
+
+// relay copies frames from src to dst unchanged, mirroring each one, until either side fails
+func (t *tap) relay(src io.Reader, dst io.Writer, direction string) {
+	for {
+		msg, err := simplemli.ReadFrame(src, t.key, simplemli.MaxLength(t.maxLen))
+		if err != nil {
+			if err != io.EOF {
+				t.logger.Printf("%s: read failed - %s", direction, err)
+			}
+			return
+		}
+		ts := time.Now()
+
+		// Only canonical MLIs decode, so the frame is re-encoded exactly as received
+		err = simplemli.WriteFrame(dst, t.key, msg)
+		if err != nil {
+			t.logger.Printf("%s: write failed - %s", direction, err)
+			return
+		}
+
+		rec := fmt.Sprintf("%s %s len=%d", ts.UTC().Format(timeFormat), direction, len(msg))
+		if t.showHex {
+			rec += " " + hex.EncodeToString(msg)
+		}
+		for _, m := range t.mirrors {
+			m.record(rec)
+		}
+	}
+}
+
+// mirror writes records to a destination without blocking the relay
+type mirror struct {
+	w       io.Writer
+	ch      chan string
+	done    chan struct{}
+	logger  *log.Logger
+	mu      sync.Mutex
+	dropped int
+}
+
+// newMirror starts a mirror writing to w
+func newMirror(w io.Writer, logger *log.Logger) *mirror {
+	m := &mirror{
+		w:      w,
+		ch:     make(chan string, 4096),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+	go m.loop()
+	return m
+}
+
+// record queues a record for writing, dropping it if the mirror is backed up
+func (m *mirror) record(rec string) {
+	select {
+	case m.ch <- rec:
+	default:
+		m.mu.Lock()
+		m.dropped++
+		m.mu.Unlock()
+	}
+}
+
+// loop writes queued records until the mirror is closed
+func (m *mirror) loop() {
+	defer close(m.done)
+	bw := bufio.NewWriter(m.w)
+	for rec := range m.ch {
+		_, err := bw.WriteString(rec + "\n")
+		// Flush once the queue is drained to batch writes under load
+		if err == nil && len(m.ch) == 0 {
+			err = bw.Flush()
+		}
+		if err != nil {
+			m.logger.Printf("mirror write failed - %s", err)
+			bw.Reset(m.w)
+		}
+	}
+	_ = bw.Flush()
+}
+
+// close flushes outstanding records and stops the mirror
+func (m *mirror) close() {
+	close(m.ch)
+	<-m.done
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.dropped > 0 {
+		m.logger.Printf("mirror dropped %d records", m.dropped)
+	}
+}
+
+// redialer is a TCP writer which reconnects after failures, attempting at most one connection per second
+type redialer struct {
+	addr     string
+	timeout  time.Duration
+	conn     net.Conn
+	lastDial time.Time
+}
+
+// Write writes p to the current connection, connecting first if required
+func (r *redialer) Write(p []byte) (int, error) {
+	if r.conn == nil {
+		if time.Since(r.lastDial) < time.Second {
+			return 0, fmt.Errorf("mirror %s unavailable", r.addr)
+		}
+		r.lastDial = time.Now()
+		conn, err := net.DialTimeout("tcp", r.addr, r.timeout)
+		if err != nil {
+			return 0, err
+		}
+		r.conn = conn
+	}
+
+	n, err := r.conn.Write(p)
+	if err != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+	return n, err
+}
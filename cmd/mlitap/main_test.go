@@ -0,0 +1,141 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestTap(t *testing.T) {
+	// Upstream responds to every message with "ok"
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start upstream listener - %s", err)
+	}
+	defer up.Close()
+	go func() {
+		conn, err := up.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, err := simplemli.ReadFrame(conn, "2BCD2"); err != nil {
+				return
+			}
+			_ = simplemli.WriteFrame(conn, "2BCD2", []byte("ok"))
+		}
+	}()
+
+	// Secondary mirror destination
+	col, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start mirror listener - %s", err)
+	}
+	defer col.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := col.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+
+	logger := log.New(io.Discard, "", 0)
+	var dump bytes.Buffer
+	dumpMirror := newMirror(&dump, logger)
+	tp := &tap{
+		upstream:    up.Addr().String(),
+		key:         "2BCD2",
+		dialTimeout: time.Second,
+		showHex:     true,
+		mirrors:     []*mirror{dumpMirror, newMirror(&redialer{addr: col.Addr().String(), timeout: time.Second}, logger)},
+		logger:      logger,
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start tap listener - %s", err)
+	}
+	defer ln.Close()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		tp.serve(ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Unable to connect to tap - %s", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	err = simplemli.WriteFrame(conn, "2BCD2", []byte("0800"))
+	if err != nil {
+		t.Fatalf("Unable to write to tap - %s", err)
+	}
+	raw := make([]byte, 6)
+	_, err = io.ReadFull(conn, raw)
+	if err != nil || !bytes.Equal(raw, []byte{0, 0, 0, 0x06, 'o', 'k'}) {
+		t.Fatalf("Unexpected response relayed through tap, got %x, %v", raw, err)
+	}
+
+	for _, expected := range []string{" len=4 30383030", " len=2 6f6b"} {
+		select {
+		case l := <-lines:
+			if !strings.HasSuffix(l, expected) {
+				t.Errorf("Unexpected mirrored record, got %q expected suffix %q", l, expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for mirrored record")
+		}
+	}
+
+	// Stopping the tap closes the client connection and waits for its relays, so the mirrors can be closed safely
+	ln.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the tap to stop")
+	}
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Expected client connection to be closed when the tap stops - got nil")
+	}
+	dumpMirror.close()
+	if n := strings.Count(dump.String(), "\n"); n != 2 {
+		t.Errorf("Unexpected number of records in dump, got %d - %q", n, dump.String())
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{}, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 without -upstream, got %d", code)
+	}
+}
@@ -0,0 +1,392 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Codec encodes and decodes a single MLI format. Codecs allow user-defined MLI formats to be used anywhere the
+// built-in types are accepted.
+//
+// Codec implementations must be safe for concurrent use and must not change once constructed, so a single Codec can
+// be shared by any number of connections. Encode and Decode must only touch the bytes of dst and src.
+//
+// The built-in types are themselves Codecs, returned by New, so they can be wrapped with metrics or other middleware
+// and registered under a new name with Register.
+//
+//	c, err := simplemli.New(simplemli.MLI2I)
+//	if err != nil {
+//		// Do something
+//	}
+//
+//	mli := make([]byte, c.Size())
+//	err = c.Encode(len(msg), mli)
+type Codec interface {
+	// Size returns the number of bytes in the MLI.
+	Size() int
+
+	// Encode writes the MLI for a message of length bytes to the start of dst, which must be at least Size bytes.
+	Encode(length int, dst []byte) error
+
+	// Decode returns the message length described by the MLI at the start of src, which must be at least Size bytes.
+	Decode(src []byte) (int, error)
+}
+
+// New returns the Codec for the built-in MLI type key. The MLI type is resolved once, when the Codec is created, so
+// Encode and Decode on the returned Codec dispatch directly to the type's implementation without looking up the key.
+// The returned Codec is an immutable value with no shared state and is safe for concurrent use.
+//
+// Codecs added with Register are returned for their registered name. Options such as Adjust are applied in order to
+// the resolved Codec.
+func New(key string, opts ...CodecOption) (Codec, error) {
+	var c Codec
+	if b, ok := builtin(key); ok {
+		c = b
+	} else if r, ok := registered(key); ok {
+		c = namedCodec{Codec: r, key: key}
+	} else {
+		return nil, ErrInvalidType
+	}
+	for _, opt := range opts {
+		c = opt(c)
+	}
+	return c, nil
+}
+
+// CodecOption modifies the Codec returned by New.
+type CodecOption func(Codec) Codec
+
+// Adjust offsets the MLI value from the message length by n bytes, for specs defining the MLI as the message length
+// plus or minus a fixed header or trailer. Encode writes an MLI of length+n and Decode returns the MLI value less n,
+// on top of any adjustment made by the MLI type itself. Decoded lengths below zero return ErrLength.
+//
+//	// A 2E MLI which also counts a 4-byte trailer
+//	c, err := simplemli.New(simplemli.MLI2E, simplemli.Adjust(4))
+//
+// The adjusted Codec is no longer the named MLI type, so it can be registered under a new name.
+func Adjust(n int) CodecOption {
+	return func(c Codec) Codec {
+		if n == 0 {
+			return c
+		}
+		return adjustedCodec{Codec: c, n: n}
+	}
+}
+
+// LowerHex encodes H4E MLIs with lowercase hexadecimal digits, such as "05dc", in place of the default uppercase.
+// Decoding accepts either case regardless. The option has no effect on other MLI types and may be combined with
+// Adjust in either order.
+//
+//	c, err := simplemli.New(simplemli.MLIH4E, simplemli.LowerHex())
+func LowerHex() CodecOption {
+	return lowerHex
+}
+
+// lowerHex switches an H4E codec to lowercase digits, looking through any adjustment wrapping it
+func lowerHex(c Codec) Codec {
+	switch k := c.(type) {
+	case keyCodec:
+		if k.key == MLIH4E {
+			k.append = appendH4ELower
+		}
+		return k
+	case adjustedCodec:
+		k.Codec = lowerHex(k.Codec)
+		return k
+	}
+	return c
+}
+
+// adjustedCodec offsets the lengths of the wrapped codec by n
+type adjustedCodec struct {
+	Codec
+	n int
+}
+
+func (c adjustedCodec) Encode(length int, dst []byte) error {
+	if length < 0 {
+		return &LengthError{Key: codecKey(c.Codec), Value: int64(length)}
+	}
+	err := c.Codec.Encode(length+c.n, dst)
+	var re *RangeError
+	if errors.As(err, &re) {
+		// Report the range in terms of the unadjusted message length
+		limit := re.Max - c.n
+		if limit < 0 {
+			limit = 0
+		}
+		return &RangeError{Key: re.Key, Length: length, Max: limit}
+	}
+	return err
+}
+
+func (c adjustedCodec) Decode(src []byte) (int, error) {
+	n, err := c.Codec.Decode(src)
+	if err != nil {
+		return 0, err
+	}
+	if n -= c.n; n < 0 {
+		return 0, &LengthError{Key: codecKey(c.Codec), Value: int64(n + c.n)}
+	}
+	return n, nil
+}
+
+// ErrRegistered reports an attempt to Register a name which is already a built-in or registered MLI type.
+var ErrRegistered = fmt.Errorf("mli type is already registered")
+
+// registry holds the codecs added with Register
+var registry = struct {
+	sync.RWMutex
+	codecs map[string]Codec
+}{codecs: make(map[string]Codec)}
+
+// Register adds a custom MLI format under name, so name can be passed as the key to Encode, Decode, New and every
+// other API accepting an MLI type. Registration is typically done from an init func, names cannot be re-registered
+// and the built-in types cannot be replaced.
+//
+//	err := simplemli.Register("A5E", myCodec{})
+//	if err != nil {
+//		// Do something
+//	}
+//
+//	mli, err := simplemli.Encode("A5E", len(msg))
+func Register(name string, codec Codec) error {
+	if name == "" || codec == nil || codec.Size() <= 0 {
+		return ErrInvalidType
+	}
+	if _, ok := builtin(name); ok {
+		return ErrRegistered
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.codecs[name]; ok {
+		return ErrRegistered
+	}
+	registry.codecs[name] = codec
+	return nil
+}
+
+// registered returns the codec added with Register for key
+func registered(key string) (Codec, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	c, ok := registry.codecs[key]
+	return c, ok
+}
+
+// registeredKeys returns the names of the registered codecs in sorted order
+func registeredKeys() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	keys := make([]string, 0, len(registry.codecs))
+	for key := range registry.codecs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// namedCodec carries the registered name of a custom codec so frames can report their MLI type
+type namedCodec struct {
+	Codec
+	key string
+}
+
+// NewEmbedded returns a Codec for 2EE-style MLIs whose messages carry an embedded header of header bytes. Like 2EE, the
+// MLI is 2 bytes in network byte order and excludes the embedded header, while lengths passed to Encode and returned
+// by Decode include it. NewEmbedded(2) is equivalent to the built-in 2EE type. Errors from other header sizes report
+// the MLI type as 2EE followed by the header size, such as "2EE(4)".
+//
+// The returned Codec can be used directly or registered under a name for a host's format.
+//
+//	c, err := simplemli.NewEmbedded(4)
+//	if err != nil {
+//		// Do something
+//	}
+//	err = simplemli.Register("2EE4", c)
+func NewEmbedded(header int) (Codec, error) {
+	if header < 0 {
+		return nil, &LengthError{Key: MLI2EE, Value: int64(header)}
+	}
+	key := MLI2EE
+	if header != 2 {
+		key = fmt.Sprintf("%s(%d)", MLI2EE, header)
+	}
+	return embeddedCodec{key: key, header: header}, nil
+}
+
+// embeddedCodec is a 2EE codec with a configurable embedded header size, key names it in errors
+type embeddedCodec struct {
+	key    string
+	header int
+}
+
+func (c embeddedCodec) Size() int {
+	return Size2EE
+}
+
+func (c embeddedCodec) Encode(length int, dst []byte) error {
+	if len(dst) < Size2EE {
+		return &SizeError{Key: c.key, Expected: Size2EE, Got: len(dst)}
+	}
+	_, err := appendEmbedded(dst[:0:Size2EE], c.key, length, c.header)
+	return err
+}
+
+func (c embeddedCodec) Decode(src []byte) (int, error) {
+	if len(src) < Size2EE {
+		return 0, &SizeError{Key: c.key, Expected: Size2EE, Got: len(src)}
+	}
+	return decodeEmbedded(c.key, src[:Size2EE], c.header)
+}
+
+// Resolved codecs for the built-in MLI types
+var (
+	codec2I     = keyCodec{key: MLI2I, size: Size2I, decode: decode2I, append: append2I}
+	codec2E     = keyCodec{key: MLI2E, size: Size2E, decode: decode2E, append: append2E}
+	codec4I     = keyCodec{key: MLI4I, size: Size4I, decode: decode4I, append: append4I}
+	codec4E     = keyCodec{key: MLI4E, size: Size4E, decode: decode4E, append: append4E}
+	codec2EE    = keyCodec{key: MLI2EE, size: Size2EE, decode: decode2EE, append: append2EE}
+	codec2BCD2  = keyCodec{key: MLI2BCD2, size: Size2BCD2, decode: decode2BCD2, append: append2BCD2}
+	codecA4E    = keyCodec{key: MLIA4E, size: SizeA4E, decode: decodeA4E, append: appendA4E}
+	codec2IL    = keyCodec{key: MLI2IL, size: Size2IL, decode: decode2IL, append: append2IL}
+	codec2EL    = keyCodec{key: MLI2EL, size: Size2EL, decode: decode2EL, append: append2EL}
+	codec4IL    = keyCodec{key: MLI4IL, size: Size4IL, decode: decode4IL, append: append4IL}
+	codec4EL    = keyCodec{key: MLI4EL, size: Size4EL, decode: decode4EL, append: append4EL}
+	codec1I     = keyCodec{key: MLI1I, size: Size1I, decode: decode1I, append: append1I}
+	codec1E     = keyCodec{key: MLI1E, size: Size1E, decode: decode1E, append: append1E}
+	codec3I     = keyCodec{key: MLI3I, size: Size3I, decode: decode3I, append: append3I}
+	codec3E     = keyCodec{key: MLI3E, size: Size3E, decode: decode3E, append: append3E}
+	codec8I     = keyCodec{key: MLI8I, size: Size8I, decode: decode8I, append: append8I}
+	codec8E     = keyCodec{key: MLI8E, size: Size8E, decode: decode8E, append: append8E}
+	codecA2E    = keyCodec{key: MLIA2E, size: SizeA2E, decode: decodeA2E, append: appendA2E}
+	codecA6E    = keyCodec{key: MLIA6E, size: SizeA6E, decode: decodeA6E, append: appendA6E}
+	codecA6I    = keyCodec{key: MLIA6I, size: SizeA6I, decode: decodeA6I, append: appendA6I}
+	codecH4E    = keyCodec{key: MLIH4E, size: SizeH4E, decode: decodeH4E, append: appendH4E}
+	codecE4E    = keyCodec{key: MLIE4E, size: SizeE4E, decode: decodeE4E, append: appendE4E}
+	codecBCD2   = keyCodec{key: MLIBCD2, size: SizeBCD2, decode: decodeBCD2, append: appendBCD2}
+	codecBCD4   = keyCodec{key: MLIBCD4, size: SizeBCD4, decode: decodeBCD4, append: appendBCD4}
+	codecBCD4I  = keyCodec{key: MLIBCD4I, size: SizeBCD4I, decode: decodeBCD4I, append: appendBCD4I}
+	codec2BCD2I = keyCodec{key: MLI2BCD2I, size: Size2BCD2I, decode: decode2BCD2I, append: append2BCD2I}
+)
+
+// builtin returns the codec for a built-in MLI type. A switch is used rather than a map as it is several times faster
+// for the handful of built-in keys
+func builtin(key string) (keyCodec, bool) {
+	switch key {
+	case MLI2I:
+		return codec2I, true
+	case MLI2E:
+		return codec2E, true
+	case MLI4I:
+		return codec4I, true
+	case MLI4E:
+		return codec4E, true
+	case MLI2EE:
+		return codec2EE, true
+	case MLI2BCD2:
+		return codec2BCD2, true
+	case MLIA4E:
+		return codecA4E, true
+	case MLI2IL:
+		return codec2IL, true
+	case MLI2EL:
+		return codec2EL, true
+	case MLI4IL:
+		return codec4IL, true
+	case MLI4EL:
+		return codec4EL, true
+	case MLI1I:
+		return codec1I, true
+	case MLI1E:
+		return codec1E, true
+	case MLI3I:
+		return codec3I, true
+	case MLI3E:
+		return codec3E, true
+	case MLI8I:
+		return codec8I, true
+	case MLI8E:
+		return codec8E, true
+	case MLIA2E:
+		return codecA2E, true
+	case MLIA6E:
+		return codecA6E, true
+	case MLIA6I:
+		return codecA6I, true
+	case MLIH4E:
+		return codecH4E, true
+	case MLIE4E:
+		return codecE4E, true
+	case MLIBCD2:
+		return codecBCD2, true
+	case MLIBCD4:
+		return codecBCD4, true
+	case MLIBCD4I:
+		return codecBCD4I, true
+	case MLI2BCD2I:
+		return codec2BCD2I, true
+	default:
+		return keyCodec{}, false
+	}
+}
+
+// keyCodec binds a built-in type to its encode and decode funcs. keyCodec is passed by value and its fields are
+// unexported, so a codec cannot be modified once created
+type keyCodec struct {
+	key    string
+	size   int
+	decode func(b []byte) (int, error)
+	append func(dst []byte, length int) ([]byte, error)
+}
+
+func (c keyCodec) Size() int {
+	return c.size
+}
+
+func (c keyCodec) Encode(length int, dst []byte) error {
+	if len(dst) < c.size {
+		return &SizeError{Key: c.key, Expected: c.size, Got: len(dst)}
+	}
+	if length < 0 {
+		return &LengthError{Key: c.key, Value: int64(length)}
+	}
+	// Appending to a zero length slice of dst writes the MLI in place
+	_, err := c.append(dst[:0:c.size], length)
+	return err
+}
+
+func (c keyCodec) Decode(src []byte) (int, error) {
+	if len(src) < c.size {
+		return 0, &SizeError{Key: c.key, Expected: c.size, Got: len(src)}
+	}
+	return c.decode(src[:c.size])
+}
+
+// codecKey returns the MLI type of a built-in or registered codec, or an empty string for other codecs
+func codecKey(c Codec) string {
+	switch c := c.(type) {
+	case keyCodec:
+		return c.key
+	case namedCodec:
+		return c.key
+	}
+	return ""
+}
@@ -0,0 +1,424 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	for _, k := range []string{MLI2I, MLI2E, MLI4I, MLI4E, MLI2EE, MLI2BCD2, MLIA4E, MLI2IL, MLI2EL, MLI4IL, MLI4EL, MLI3I,
+		MLI3E, MLI8I, MLI8E} {
+		t.Run(k, func(t *testing.T) {
+			c, err := New(k)
+			if err != nil {
+				t.Fatalf("Unexpected error creating codec - %s", err)
+			}
+
+			expected, _ := Encode(k, 1500)
+			if c.Size() != len(expected) {
+				t.Errorf("Unexpected codec size, got %d expected %d", c.Size(), len(expected))
+			}
+
+			// Encode into a larger buffer, Decode ignores trailing bytes
+			b := make([]byte, c.Size()+2)
+			err = c.Encode(1500, b)
+			if err != nil || !bytes.Equal(b[:c.Size()], expected) {
+				t.Errorf("Unexpected result from Encode, got %x, %v expected %x", b, err, expected)
+			}
+			n, err := c.Decode(b)
+			if err != nil || n != 1500 {
+				t.Errorf("Unexpected result from Decode, got %d, %v", n, err)
+			}
+
+			err = c.Encode(1500, b[:c.Size()-1])
+			if !errors.Is(err, ErrByteSize) {
+				t.Errorf("Expected ErrByteSize encoding to a short buffer, got %v", err)
+			}
+			_, err = c.Decode(b[:c.Size()-1])
+			if !errors.Is(err, ErrByteSize) {
+				t.Errorf("Expected ErrByteSize decoding a short buffer, got %v", err)
+			}
+			// Encode must not write past the MLI
+			b[c.Size()] = 0xff
+			_ = c.Encode(9999, b)
+			if b[c.Size()] != 0xff {
+				t.Errorf("Unexpected write past the MLI, got %x", b)
+			}
+
+			err = c.Encode(-1, b)
+			if err == nil {
+				t.Errorf("Expected error encoding a negative length - got nil")
+			}
+		})
+	}
+
+	t.Run("Concurrent Use", func(t *testing.T) {
+		c, err := New(MLI2BCD2)
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 16)
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				b := make([]byte, c.Size())
+				for j := 0; j < 1000; j++ {
+					if err := c.Encode(n, b); err != nil {
+						errs <- err
+						return
+					}
+					got, err := c.Decode(b)
+					if err != nil || got != n {
+						errs <- fmt.Errorf("decoded %d, %v expected %d", got, err, n)
+						return
+					}
+				}
+			}(i * 100)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("Unexpected result from shared codec - %s", err)
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := New("bad")
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+	})
+}
+
+// ascii5 is a 5-byte ASCII decimal MLI with the MLI excluded
+type ascii5 struct{}
+
+func (ascii5) Size() int {
+	return 5
+}
+
+func (ascii5) Encode(length int, dst []byte) error {
+	if len(dst) < 5 {
+		return ErrByteSize
+	}
+	if length > 99999 {
+		return ErrTooLarge
+	}
+	copy(dst, fmt.Sprintf("%05d", length))
+	return nil
+}
+
+func (ascii5) Decode(src []byte) (int, error) {
+	if len(src) < 5 {
+		return 0, ErrByteSize
+	}
+	n, err := strconv.Atoi(string(src[:5]))
+	if err != nil {
+		return 0, ErrNotNumeric
+	}
+	return n, nil
+}
+
+func TestRegister(t *testing.T) {
+	// Registration outlives the test, tolerate a previous run with -count
+	err := Register("test5", ascii5{})
+	if err != nil && err != ErrRegistered {
+		t.Fatalf("Unexpected error registering codec - %s", err)
+	}
+
+	t.Run("Encode and Decode", func(t *testing.T) {
+		b, err := Encode("test5", 1500)
+		if err != nil || string(b) != "01500" {
+			t.Fatalf("Unexpected result from Encode, got %q, %v", b, err)
+		}
+		n, err := Decode("test5", &b)
+		if err != nil || n != 1500 {
+			t.Errorf("Unexpected result from Decode, got %d, %v", n, err)
+		}
+
+		short := b[:4]
+		_, err = Decode("test5", &short)
+		if !errors.Is(err, ErrByteSize) {
+			t.Errorf("Expected ErrByteSize decoding a short MLI, got %v", err)
+		}
+		_, err = Encode("test5", 100000)
+		if err != ErrTooLarge {
+			t.Errorf("Expected codec error from Encode, got %v", err)
+		}
+	})
+
+	t.Run("New", func(t *testing.T) {
+		c, err := New("test5")
+		if err != nil || c.Size() != 5 {
+			t.Fatalf("Unexpected result from New, got %v, %v", c, err)
+		}
+		if codecKey(c) != "test5" {
+			t.Errorf("Unexpected codec key, got %q", codecKey(c))
+		}
+	})
+
+	t.Run("Read Frame", func(t *testing.T) {
+		msg, err := ReadFrame(bytes.NewReader([]byte("00005hello")), "test5")
+		if err != nil || string(msg) != "hello" {
+			t.Errorf("Unexpected result from ReadFrame, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		err := Register("test5", ascii5{})
+		if err != ErrRegistered {
+			t.Errorf("Expected ErrRegistered re-registering a name, got %v", err)
+		}
+		err = Register(MLI2I, ascii5{})
+		if err != ErrRegistered {
+			t.Errorf("Expected ErrRegistered replacing a built-in, got %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if err := Register("", ascii5{}); err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType for an empty name, got %v", err)
+		}
+		if err := Register("nil", nil); err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType for a nil codec, got %v", err)
+		}
+	})
+}
+
+// countingCodec wraps a codec, counting the MLIs it decodes
+type countingCodec struct {
+	Codec
+	decoded *int32
+}
+
+func (c countingCodec) Decode(src []byte) (int, error) {
+	atomic.AddInt32(c.decoded, 1)
+	return c.Codec.Decode(src)
+}
+
+// counted2I is shared across runs as the registration outlives the test
+var counted2I int32
+
+func TestWrappedCodec(t *testing.T) {
+	inner, err := New(MLI2I)
+	if err != nil {
+		t.Fatalf("Unexpected error creating codec - %s", err)
+	}
+	err = Register("counted2I", countingCodec{Codec: inner, decoded: &counted2I})
+	if err != nil && err != ErrRegistered {
+		t.Fatalf("Unexpected error registering codec - %s", err)
+	}
+
+	b, err := Encode("counted2I", 1500)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding - %s", err)
+	}
+	expected, _ := Encode(MLI2I, 1500)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Unexpected MLI, got %x expected %x", b, expected)
+	}
+
+	before := atomic.LoadInt32(&counted2I)
+	n, err := Decode("counted2I", &b)
+	if err != nil || n != 1500 {
+		t.Errorf("Unexpected result from Decode, got %d, %v", n, err)
+	}
+	if got := atomic.LoadInt32(&counted2I) - before; got != 1 {
+		t.Errorf("Expected the wrapper to see 1 decode, got %d", got)
+	}
+}
+
+func TestNewEmbedded(t *testing.T) {
+	t.Run("Matches 2EE", func(t *testing.T) {
+		c, err := NewEmbedded(2)
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+		b := make([]byte, c.Size())
+		for _, n := range []int{2, 1502, 65537} {
+			expected, _ := Encode(MLI2EE, n)
+			if err := c.Encode(n, b); err != nil || !bytes.Equal(b, expected) {
+				t.Errorf("Unexpected MLI for %d, got %x, %v expected %x", n, b, err, expected)
+			}
+		}
+	})
+
+	t.Run("4 Byte Header", func(t *testing.T) {
+		c, err := NewEmbedded(4)
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+		b := make([]byte, c.Size())
+		if err := c.Encode(1504, b); err != nil || !bytes.Equal(b, []byte{0x05, 0xdc}) {
+			t.Errorf("Unexpected MLI, got %x, %v expected 05dc", b, err)
+		}
+		n, err := c.Decode(b)
+		if err != nil || n != 1504 {
+			t.Errorf("Unexpected result from Decode, got %d, %v", n, err)
+		}
+		if err := c.Encode(3, b); !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength encoding a length shorter than the header, got %v", err)
+		}
+		var re *RangeError
+		if err := c.Encode(65540, b); !errors.As(err, &re) || re.Key != "2EE(4)" || re.Max != 65539 {
+			t.Errorf("Expected RangeError for 2EE(4) with a Max of 65539, got %v", err)
+		}
+		var se *SizeError
+		if _, err := c.Decode(b[:1]); !errors.As(err, &se) || se.Key != "2EE(4)" {
+			t.Errorf("Expected SizeError for 2EE(4), got %v", err)
+		}
+		if err := c.Encode(65539, b); err != nil || !bytes.Equal(b, []byte{0xff, 0xff}) {
+			t.Errorf("Unexpected MLI for the maximum length, got %x, %v", b, err)
+		}
+	})
+
+	t.Run("Registered", func(t *testing.T) {
+		c, err := NewEmbedded(5)
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+		err = Register("2EE5", c)
+		if err != nil && err != ErrRegistered {
+			t.Fatalf("Unexpected error registering codec - %s", err)
+		}
+		b, err := Encode("2EE5", 1505)
+		if err != nil {
+			t.Fatalf("Unexpected error encoding - %s", err)
+		}
+		n, err := DecodeBytes("2EE5", b)
+		if err != nil || n != 1505 {
+			t.Errorf("Unexpected result from DecodeBytes, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Negative Header", func(t *testing.T) {
+		_, err := NewEmbedded(-1)
+		if !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength, got %v", err)
+		}
+	})
+}
+
+func TestAdjust(t *testing.T) {
+	tc := []struct {
+		name   string
+		key    string
+		adjust int
+		length int
+		mli    []byte
+	}{
+		{"Plus", MLI2E, 4, 1500, []byte{0x05, 0xe0}},
+		{"Minus", MLI2E, -3, 1500, []byte{0x05, 0xd9}},
+		{"Inclusive", MLI2I, 4, 1500, []byte{0x05, 0xe2}},
+		{"Decimal", MLIA4E, 10, 1500, []byte("1510")},
+		{"Zero", MLI4E, 0, 1500, []byte{0x00, 0x00, 0x05, 0xdc}},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			codec, err := New(c.key, Adjust(c.adjust))
+			if err != nil {
+				t.Fatalf("Unexpected error creating codec - %s", err)
+			}
+			b := make([]byte, codec.Size())
+			if err := codec.Encode(c.length, b); err != nil || !bytes.Equal(b, c.mli) {
+				t.Errorf("Unexpected MLI, got %x, %v expected %x", b, err, c.mli)
+			}
+			n, err := codec.Decode(c.mli)
+			if err != nil || n != c.length {
+				t.Errorf("Unexpected result from Decode, got %d, %v expected %d", n, err, c.length)
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		c, err := New(MLI2E, Adjust(4))
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+		b := make([]byte, c.Size())
+		if _, err := c.Decode([]byte{0x00, 0x03}); !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength decoding an MLI below the adjustment, got %v", err)
+		}
+		if err := c.Encode(-1, b); !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength encoding a negative length, got %v", err)
+		}
+		err = c.Encode(65532, b)
+		var re *RangeError
+		if !errors.As(err, &re) || re.Length != 65532 || re.Max != 65531 {
+			t.Errorf("Expected RangeError in terms of the message length, got %v", err)
+		}
+
+		big, err := New(MLI1E, Adjust(300))
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+		err = big.Encode(1, b)
+		if !errors.As(err, &re) || re.Max != 0 {
+			t.Errorf("Expected RangeError with a Max of 0 for an adjustment beyond the MLI type, got %v", err)
+		}
+
+		m, err := New(MLI2E, Adjust(-4))
+		if err != nil {
+			t.Fatalf("Unexpected error creating codec - %s", err)
+		}
+		if err := m.Encode(3, b); !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength encoding a length below the adjustment, got %v", err)
+		}
+	})
+}
+
+func TestLowerHex(t *testing.T) {
+	c, err := New(MLIH4E, LowerHex())
+	if err != nil {
+		t.Fatalf("Unexpected error creating codec - %s", err)
+	}
+	b := make([]byte, c.Size())
+	if err := c.Encode(1500, b); err != nil || string(b) != "05dc" {
+		t.Errorf("Unexpected MLI, got %q, %v expected 05dc", b, err)
+	}
+	for _, mli := range []string{"05dc", "05DC", "05Dc"} {
+		n, err := c.Decode([]byte(mli))
+		if err != nil || n != 1500 {
+			t.Errorf("Unexpected result decoding %q, got %d, %v", mli, n, err)
+		}
+	}
+	if err := c.Encode(65536, b); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+
+	upper, _ := New(MLIH4E)
+	if err := upper.Encode(1500, b); err != nil || string(b) != "05DC" {
+		t.Errorf("Unexpected default MLI, got %q, %v expected 05DC", b, err)
+	}
+	for _, opts := range [][]CodecOption{{Adjust(4), LowerHex()}, {LowerHex(), Adjust(4)}} {
+		adjusted, _ := New(MLIH4E, opts...)
+		if err := adjusted.Encode(1496, b); err != nil || string(b) != "05dc" {
+			t.Errorf("Expected LowerHex to apply alongside Adjust, got %q, %v expected 05dc", b, err)
+		}
+	}
+	other, _ := New(MLI2E, LowerHex())
+	if err := other.Encode(1500, b[:Size2E]); err != nil || !bytes.Equal(b[:Size2E], []byte{0x05, 0xdc}) {
+		t.Errorf("Expected LowerHex to have no effect on 2E, got %x, %v", b[:Size2E], err)
+	}
+}
@@ -0,0 +1,152 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the framing settings for a connection, typically loaded from the environment with ConfigFromEnv.
+type Config struct {
+	// Key is the MLI type
+	Key string
+
+	// MaxMessageSize is the largest message accepted, zero means no limit beyond the MLI type
+	MaxMessageSize int
+
+	// ReadTimeout bounds the time taken to read a message, zero means no timeout
+	ReadTimeout time.Duration
+
+	// WriteTimeout bounds the time taken to write a message, zero means no timeout
+	WriteTimeout time.Duration
+
+	// Strict accepts only the canonical form of decimal MLIs, such as A4E. Otherwise decimal MLIs padded with leading
+	// spaces are also accepted, see SpacePadded
+	Strict bool
+}
+
+// Environment variable suffixes read by ConfigFromEnv
+const (
+	EnvMLIType        = "MLI_TYPE"
+	EnvMaxMessageSize = "MAX_MESSAGE_SIZE"
+	EnvReadTimeout    = "READ_TIMEOUT"
+	EnvWriteTimeout   = "WRITE_TIMEOUT"
+	EnvStrict         = "STRICT"
+)
+
+// ConfigFromEnv reads a Config from environment variables named with prefix, an underscore and the suffixes above.
+// Timeouts use time.ParseDuration syntax and Strict accepts the values understood by strconv.ParseBool. The MLI type
+// is required, other variables are optional.
+//
+//	// PARTNER_MLI_TYPE=2I PARTNER_MAX_MESSAGE_SIZE=8192 PARTNER_READ_TIMEOUT=30s PARTNER_STRICT=true
+//	cfg, err := simplemli.ConfigFromEnv("PARTNER")
+//	if err != nil {
+//		// Do something
+//	}
+//
+// An empty prefix reads the unprefixed names, e.g. MLI_TYPE.
+func ConfigFromEnv(prefix string) (Config, error) {
+	name := func(suffix string) string {
+		if prefix == "" {
+			return suffix
+		}
+		return prefix + "_" + suffix
+	}
+
+	var cfg Config
+	cfg.Key = os.Getenv(name(EnvMLIType))
+	if cfg.Key == "" {
+		return cfg, fmt.Errorf("%s is not set", name(EnvMLIType))
+	}
+	if _, err := Size(cfg.Key); err != nil {
+		return cfg, fmt.Errorf("invalid %s %q - %w", name(EnvMLIType), cfg.Key, err)
+	}
+
+	if v := os.Getenv(name(EnvMaxMessageSize)); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", name(EnvMaxMessageSize), v)
+		}
+		cfg.MaxMessageSize = n
+	}
+
+	for _, d := range []struct {
+		suffix string
+		dst    *time.Duration
+	}{
+		{EnvReadTimeout, &cfg.ReadTimeout},
+		{EnvWriteTimeout, &cfg.WriteTimeout},
+	} {
+		v := os.Getenv(name(d.suffix))
+		if v == "" {
+			continue
+		}
+		t, err := time.ParseDuration(v)
+		if err != nil || t < 0 {
+			return cfg, fmt.Errorf("invalid %s %q", name(d.suffix), v)
+		}
+		*d.dst = t
+	}
+
+	if v := os.Getenv(name(EnvStrict)); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s %q", name(EnvStrict), v)
+		}
+		cfg.Strict = b
+	}
+
+	return cfg, nil
+}
+
+// Codec returns the Codec for the configured MLI type.
+func (c Config) Codec() (Codec, error) {
+	return New(c.Key)
+}
+
+// DecodeOptions returns the options for DecodeBytes and ReadFrame matching the configured limit and validation.
+func (c Config) DecodeOptions() []DecodeOption {
+	var opts []DecodeOption
+	if c.MaxMessageSize > 0 {
+		opts = append(opts, MaxLength(c.MaxMessageSize))
+	}
+	if !c.Strict {
+		opts = append(opts, SpacePadded())
+	}
+	return opts
+}
+
+// Options returns the Reader, Writer and MessageConn options for the configured limits, timeouts and validation.
+func (c Config) Options() []Option {
+	var opts []Option
+	if c.MaxMessageSize > 0 {
+		opts = append(opts, WithMaxLength(c.MaxMessageSize))
+	}
+	if !c.Strict {
+		opts = append(opts, WithSpacePadded())
+	}
+	if c.ReadTimeout > 0 {
+		opts = append(opts, WithReadTimeout(c.ReadTimeout))
+	}
+	if c.WriteTimeout > 0 {
+		opts = append(opts, WithWriteTimeout(c.WriteTimeout))
+	}
+	return opts
+}
@@ -0,0 +1,110 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("All Set", func(t *testing.T) {
+		t.Setenv("PARTNER_MLI_TYPE", "4E")
+		t.Setenv("PARTNER_MAX_MESSAGE_SIZE", "8192")
+		t.Setenv("PARTNER_READ_TIMEOUT", "30s")
+		t.Setenv("PARTNER_WRITE_TIMEOUT", "500ms")
+		t.Setenv("PARTNER_STRICT", "true")
+
+		cfg, err := ConfigFromEnv("PARTNER")
+		if err != nil {
+			t.Fatalf("Unexpected error - %s", err)
+		}
+		expected := Config{
+			Key:            MLI4E,
+			MaxMessageSize: 8192,
+			ReadTimeout:    30 * time.Second,
+			WriteTimeout:   500 * time.Millisecond,
+			Strict:         true,
+		}
+		if cfg != expected {
+			t.Errorf("Unexpected config, got %+v expected %+v", cfg, expected)
+		}
+
+		if n := len(cfg.Options()); n != 3 {
+			t.Errorf("Expected 3 options from config, got %d", n)
+		}
+		if n := len(cfg.DecodeOptions()); n != 1 {
+			t.Errorf("Expected 1 decode option from config, got %d", n)
+		}
+
+		c, err := cfg.Codec()
+		if err != nil || c.Size() != Size4E {
+			t.Errorf("Unexpected codec from config, got %v, %v", c, err)
+		}
+	})
+
+	t.Run("No Prefix", func(t *testing.T) {
+		t.Setenv("MLI_TYPE", "2I")
+		cfg, err := ConfigFromEnv("")
+		if err != nil || cfg != (Config{Key: MLI2I}) {
+			t.Errorf("Unexpected config, got %+v, %v", cfg, err)
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		padded := []byte{' ', ' ', '1', '2', '0', '8', '0', '0', '0', '0', '0', '0', '0', '0', '0', '0'}
+		for _, strict := range []bool{false, true} {
+			cfg := Config{Key: MLIA4E, MaxMessageSize: 100, Strict: strict}
+
+			_, err := DecodeBytes(cfg.Key, padded[:SizeA4E], cfg.DecodeOptions()...)
+			if (err == nil) == strict {
+				t.Errorf("Unexpected result decoding a space padded MLI with Strict %t, got %v", strict, err)
+			}
+
+			r := NewReader(bytes.NewReader(padded), cfg.Key, cfg.Options()...)
+			msg, err := r.ReadMessage()
+			if strict && !errors.Is(err, ErrNotNumeric) {
+				t.Errorf("Expected ErrNotNumeric reading a space padded MLI, got %q, %v", msg, err)
+			}
+			if !strict && (err != nil || string(msg) != "080000000000") {
+				t.Errorf("Unexpected message reading a space padded MLI, got %q, %v", msg, err)
+			}
+		}
+	})
+
+	invalid := map[string][2]string{
+		"Missing Type":    {"", ""},
+		"Invalid Type":    {"X_MLI_TYPE", "3Q"},
+		"Invalid Size":    {"X_MAX_MESSAGE_SIZE", "big"},
+		"Negative Size":   {"X_MAX_MESSAGE_SIZE", "-1"},
+		"Invalid Timeout": {"X_READ_TIMEOUT", "30"},
+		"Invalid Strict":  {"X_STRICT", "maybe"},
+	}
+	for name, env := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if name != "Missing Type" {
+				t.Setenv("X_MLI_TYPE", "2I")
+				t.Setenv(env[0], env[1])
+			}
+			_, err := ConfigFromEnv("X")
+			if err == nil {
+				t.Errorf("Expected error - got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// aLongTimeAgo is a deadline in the past, used to interrupt a blocked read or write
+var aLongTimeAgo = time.Unix(1, 0)
+
+// ReadMessageContext is ReadMessage honouring the deadline and cancellation of ctx. The earlier of the ctx deadline
+// and the read timeout is applied as the read deadline, and cancelling ctx interrupts a blocked read, which then
+// returns ctx.Err(). As with a read timeout, a read interrupted part way through a frame leaves the connection out of
+// step with the stream and it should be closed.
+//
+//	msg, err := conn.ReadMessageContext(ctx)
+//	if errors.Is(err, context.Canceled) {
+//		// Shutting down
+//	}
+//
+// ReadMessageContext replaces any deadline set with SetReadDeadline.
+func (c *MessageConn) ReadMessageContext(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stop, err := bindContext(ctx, c.opts.readTimeout, c.Conn.SetReadDeadline)
+	if err != nil {
+		return nil, err
+	}
+	var f Frame
+	err = c.r.readFrameInto(ctx, &f)
+	stop()
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	c.touch()
+	return f.Body, nil
+}
+
+// WriteMessageContext is WriteMessage honouring the deadline and cancellation of ctx. The earlier of the ctx deadline
+// and the write timeout is applied as the write deadline, and cancelling ctx interrupts a blocked write, which then
+// returns ctx.Err(). A frame interrupted part way through returns a *PartialWriteError wrapping ctx.Err(), and can be
+// completed with Resume.
+//
+// WriteMessageContext replaces any deadline set with SetWriteDeadline.
+func (c *MessageConn) WriteMessageContext(ctx context.Context, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	stop, err := bindContext(ctx, c.opts.writeTimeout, c.Conn.SetWriteDeadline)
+	if err != nil {
+		return err
+	}
+	err = c.w.WriteMessage(msg)
+	stop()
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+	c.touch()
+	return nil
+}
+
+// bindContext applies the earlier of the ctx deadline and timeout using setDeadline, and interrupts the operation by
+// setting a past deadline if ctx is cancelled. The returned stop func must be called once the operation completes,
+// it clears any deadline set or interrupt so the next call starts afresh.
+func bindContext(ctx context.Context, timeout time.Duration, setDeadline func(time.Time) error) (func(), error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	set := !deadline.IsZero()
+	if set {
+		err := setDeadline(deadline)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ctx.Done() == nil {
+		return func() {
+			if set {
+				_ = setDeadline(time.Time{})
+			}
+		}, nil
+	}
+	done := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = setDeadline(aLongTimeAgo)
+			interrupted <- true
+		case <-done:
+			interrupted <- false
+		}
+	}()
+	return func() {
+		close(done)
+		if <-interrupted || set {
+			_ = setDeadline(time.Time{})
+		}
+	}, nil
+}
+
+// contextErr returns the ctx error in place of err for an operation interrupted by ctx. Only timeouts, which the
+// interrupt or ctx deadline cause, are replaced, so framing errors and errors returned by ctx itself pass through. The
+// connection deadline can fire fractionally before ctx reports it is done, so a passed ctx deadline is also reported
+// as DeadlineExceeded. A partial write keeps its *PartialWriteError, with the ctx error as the cause, so the caller
+// still knows to Resume
+func contextErr(ctx context.Context, err error) error {
+	if !isTimeout(err) {
+		return err
+	}
+	cerr := ctx.Err()
+	if cerr == nil {
+		if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+			cerr = context.DeadlineExceeded
+		}
+	}
+	if cerr == nil {
+		return err
+	}
+	var pe *PartialWriteError
+	if errors.As(err, &pe) {
+		return &PartialWriteError{Written: pe.Written, Total: pe.Total, Err: cerr}
+	}
+	return cerr
+}
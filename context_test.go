@@ -0,0 +1,203 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMessageConnContext(t *testing.T) {
+	t.Run("Read Cancelled", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I)
+		peer := NewMessageConn(b, MLI2I)
+		defer conn.Close()
+		defer peer.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		_, err := conn.ReadMessageContext(ctx)
+		if err != context.Canceled {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+
+		// The interrupted deadline is cleared for the next read
+		go func() {
+			_ = peer.WriteMessage([]byte("0800"))
+		}()
+		msg, err := conn.ReadMessageContext(context.Background())
+		if err != nil || string(msg) != "0800" {
+			t.Errorf("Unexpected message after cancellation, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Read Deadline", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I, WithReadTimeout(time.Minute))
+		defer conn.Close()
+		defer b.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := conn.ReadMessageContext(ctx)
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("Expected the ctx deadline to override the longer read timeout")
+		}
+	})
+
+	t.Run("Write Cancelled", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I)
+		defer conn.Close()
+		defer b.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		err := conn.WriteMessageContext(ctx, []byte("0800"))
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("Deadline Cleared", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I)
+		peer := NewMessageConn(b, MLI2I)
+		defer conn.Close()
+		defer peer.Close()
+
+		go func() {
+			_ = peer.WriteMessage([]byte("0800"))
+			time.Sleep(100 * time.Millisecond)
+			_ = peer.WriteMessage([]byte("0810"))
+			_ = peer.WriteMessage([]byte("0820"))
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := conn.ReadMessageContext(ctx); err != nil {
+			t.Fatalf("Unexpected error reading - %s", err)
+		}
+
+		// Reads after the ctx deadline has passed are not bound by it
+		msg, err := conn.ReadMessage()
+		if err != nil || string(msg) != "0810" {
+			t.Errorf("Unexpected result from ReadMessage, got %q, %v", msg, err)
+		}
+		msg, err = conn.ReadMessageContext(context.Background())
+		if err != nil || string(msg) != "0820" {
+			t.Errorf("Unexpected result from ReadMessageContext, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Partial Write Cancelled", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I)
+		defer conn.Close()
+		defer b.Close()
+
+		// The peer accepts the MLI and part of the message, then stops reading
+		head := make([]byte, 4)
+		go func() {
+			_, _ = io.ReadFull(b, head)
+		}()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		err := conn.WriteMessageContext(ctx, []byte("08000000"))
+		var pe *PartialWriteError
+		if !errors.As(err, &pe) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected PartialWriteError wrapping context.Canceled, got %v", err)
+		}
+		if pe.Written != 4 || pe.Total != 10 {
+			t.Errorf("Unexpected PartialWriteError fields, got %+v", pe)
+		}
+
+		// The rest of the frame is sent by Resume
+		rest := make([]byte, 6)
+		go func() {
+			_, _ = io.ReadFull(b, rest)
+		}()
+		if err := conn.Resume(); err != nil {
+			t.Errorf("Unexpected error resuming - %s", err)
+		}
+	})
+
+	t.Run("Already Cancelled", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I)
+		defer conn.Close()
+		defer b.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := conn.ReadMessageContext(ctx); err != context.Canceled {
+			t.Errorf("Expected context.Canceled reading, got %v", err)
+		}
+		if err := conn.WriteMessageContext(ctx, []byte("x")); err != context.Canceled {
+			t.Errorf("Expected context.Canceled writing, got %v", err)
+		}
+	})
+
+	t.Run("Errors Kept", func(t *testing.T) {
+		// A read which fails for its own reason as ctx is cancelled keeps its error
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tooLarge := fmt.Errorf("%w - got 10 bytes, limit 4", ErrTooLarge)
+		for _, err := range []error{tooLarge, io.ErrUnexpectedEOF, context.Canceled} {
+			if got := contextErr(ctx, err); got != err {
+				t.Errorf("Expected %v to be kept, got %v", err, got)
+			}
+		}
+		if got := contextErr(ctx, os.ErrDeadlineExceeded); got != context.Canceled {
+			t.Errorf("Expected the interrupt to be reported as context.Canceled, got %v", got)
+		}
+	})
+
+	t.Run("Round Trip", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I)
+		peer := NewMessageConn(b, MLI2I)
+		defer conn.Close()
+		defer peer.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		go func() {
+			msg, err := peer.ReadMessageContext(ctx)
+			if err == nil {
+				_ = peer.WriteMessageContext(ctx, append(msg, '!'))
+			}
+		}()
+		err := conn.WriteMessageContext(ctx, []byte("0800"))
+		if err != nil {
+			t.Fatalf("Unexpected error writing - %s", err)
+		}
+		msg, err := conn.ReadMessageContext(ctx)
+		if err != nil || string(msg) != "0800!" {
+			t.Errorf("Unexpected response, got %q, %v", msg, err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// FingerprintFunc computes the identity of a frame used for duplicate detection. Frames with equal fingerprints are
+// treated as duplicates.
+type FingerprintFunc func(frame []byte) string
+
+// DupDetector flags repeated frames within a sliding window of the most recently seen frames. Some hosts retransmit
+// frames after timeouts, DupDetector lets consumers identify those retransmissions as frames are read.
+//
+//	d := simplemli.NewDupDetector(1000, nil)
+//
+//	if d.Seen(msg) {
+//		// Duplicate frame, do something
+//	}
+//
+// A DupDetector is safe for concurrent use.
+type DupDetector struct {
+	mu          sync.Mutex
+	fingerprint FingerprintFunc
+	seen        map[string]int
+	ring        []string
+	next        int
+	full        bool
+}
+
+// NewDupDetector returns a DupDetector which remembers the fingerprints of the last window frames. If fingerprint is
+// nil the SHA-256 digest of the full frame is used.
+func NewDupDetector(window int, fingerprint FingerprintFunc) *DupDetector {
+	if window < 1 {
+		window = 1
+	}
+	if fingerprint == nil {
+		fingerprint = func(frame []byte) string {
+			sum := sha256.Sum256(frame)
+			return string(sum[:])
+		}
+	}
+	return &DupDetector{
+		fingerprint: fingerprint,
+		seen:        make(map[string]int, window),
+		ring:        make([]string, window),
+	}
+}
+
+// Seen records frame within the window and reports whether a frame with the same fingerprint was already present.
+func (d *DupDetector) Seen(frame []byte) bool {
+	fp := d.fingerprint(frame)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dup := d.seen[fp] > 0
+
+	// Evict the oldest fingerprint once the window is full
+	if d.full {
+		old := d.ring[d.next]
+		d.seen[old]--
+		if d.seen[old] <= 0 {
+			delete(d.seen, old)
+		}
+	}
+
+	d.ring[d.next] = fp
+	d.seen[fp]++
+	d.next++
+	if d.next == len(d.ring) {
+		d.next = 0
+		d.full = true
+	}
+	return dup
+}
+
+// Reset forgets all previously seen frames.
+func (d *DupDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seen = make(map[string]int, len(d.ring))
+	d.ring = make([]string, len(d.ring))
+	d.next = 0
+	d.full = false
+}
@@ -0,0 +1,63 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"testing"
+)
+
+func TestDupDetector(t *testing.T) {
+	t.Run("Detects repeat within window", func(t *testing.T) {
+		d := NewDupDetector(2, nil)
+		if d.Seen([]byte("a")) {
+			t.Errorf("Unexpected duplicate on first frame")
+		}
+		if d.Seen([]byte("b")) {
+			t.Errorf("Unexpected duplicate on unique frame")
+		}
+		if !d.Seen([]byte("a")) {
+			t.Errorf("Expected duplicate for repeated frame within window")
+		}
+	})
+
+	t.Run("Forgets frames outside window", func(t *testing.T) {
+		d := NewDupDetector(2, nil)
+		d.Seen([]byte("a"))
+		d.Seen([]byte("b"))
+		d.Seen([]byte("c"))
+		if d.Seen([]byte("a")) {
+			t.Errorf("Unexpected duplicate for frame outside window")
+		}
+	})
+
+	t.Run("Custom fingerprint", func(t *testing.T) {
+		// Fingerprint on the first 4 bytes only, e.g. an MTI
+		d := NewDupDetector(10, func(frame []byte) string { return string(frame[:4]) })
+		d.Seen([]byte("0200 first"))
+		if !d.Seen([]byte("0200 second")) {
+			t.Errorf("Expected duplicate when fingerprints match")
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		d := NewDupDetector(10, nil)
+		d.Seen([]byte("a"))
+		d.Reset()
+		if d.Seen([]byte("a")) {
+			t.Errorf("Unexpected duplicate after Reset")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrNoDefault reports use of the default MLI type before one has been set with SetDefault.
+var ErrNoDefault = fmt.Errorf("no default MLI type set")
+
+// defaultKey holds the default MLI type as a string
+var defaultKey atomic.Value
+
+// SetDefault sets the MLI type used by EncodeDefault and DecodeDefault, and by the Reader, Writer and MessageConn
+// constructors when given an empty key. Applications speaking a single MLI type can set it once at startup.
+//
+//	err := simplemli.SetDefault(simplemli.MLI2I)
+//	if err != nil {
+//		// Do something
+//	}
+//
+//	r := simplemli.NewReader(conn, "")
+func SetDefault(key string) error {
+	if _, err := Size(key); err != nil {
+		return err
+	}
+	defaultKey.Store(key)
+	return nil
+}
+
+// Default returns the default MLI type, or an empty string if none has been set.
+func Default() string {
+	key, _ := defaultKey.Load().(string)
+	return key
+}
+
+// EncodeDefault encodes length with the default MLI type.
+func EncodeDefault(length int) ([]byte, error) {
+	key := Default()
+	if key == "" {
+		return empty, ErrNoDefault
+	}
+	return Encode(key, length)
+}
+
+// DecodeDefault decodes b with the default MLI type.
+func DecodeDefault(b []byte, opts ...DecodeOption) (int, error) {
+	key := Default()
+	if key == "" {
+		return 0, ErrNoDefault
+	}
+	return DecodeBytes(key, b, opts...)
+}
+
+// orDefault returns key, or the default MLI type if key is empty
+func orDefault(key string) string {
+	if key == "" {
+		return Default()
+	}
+	return key
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	// Restore the unset default for other tests
+	defer defaultKey.Store("")
+
+	t.Run("Unset", func(t *testing.T) {
+		defaultKey.Store("")
+		_, err := EncodeDefault(10)
+		if err != ErrNoDefault {
+			t.Errorf("Expected ErrNoDefault, got %v", err)
+		}
+		b := []byte{0x00, 0x0c}
+		_, err = DecodeDefault(b)
+		if err != ErrNoDefault {
+			t.Errorf("Expected ErrNoDefault, got %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := SetDefault("3Q")
+		if err == nil {
+			t.Errorf("Expected error setting invalid default - got nil")
+		}
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		err := SetDefault(MLI2I)
+		if err != nil || Default() != MLI2I {
+			t.Fatalf("Unexpected result setting default, got %q, %v", Default(), err)
+		}
+
+		b, err := EncodeDefault(10)
+		if err != nil || !bytes.Equal(b, []byte{0x00, 0x0c}) {
+			t.Errorf("Unexpected result from EncodeDefault, got %x, %v", b, err)
+		}
+		n, err := DecodeDefault(b)
+		if err != nil || n != 10 {
+			t.Errorf("Unexpected result from DecodeDefault, got %d, %v", n, err)
+		}
+	})
+}
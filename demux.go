@@ -0,0 +1,115 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// HandlerFunc handles a single inbound message, excluding the MLI.
+type HandlerFunc func(msg []byte)
+
+// route pairs a match predicate with the handler it selects
+type route struct {
+	match   func(msg []byte) bool
+	handler HandlerFunc
+}
+
+// Demux routes inbound messages to registered handlers. Routes are evaluated in the order they were registered and
+// the first matching route handles the message, messages which match no route are passed to the default handler.
+//
+//	d := simplemli.NewDemux()
+//	d.HandlePrefix([]byte("0800"), networkMgmt)
+//	d.HandlePrefix([]byte("0200"), financial)
+//	d.HandleDefault(reject)
+//
+//	err := d.Serve(conn, simplemli.MLI2I)
+//
+// A Demux is safe for concurrent use, routes may be registered while messages are being dispatched.
+type Demux struct {
+	mu     sync.RWMutex
+	routes []route
+	def    HandlerFunc
+}
+
+// NewDemux returns a Demux with no routes. Messages dispatched before a default handler is registered and which do
+// not match a route are dropped.
+func NewDemux() *Demux {
+	return &Demux{}
+}
+
+// Handle registers a handler for messages where match returns true.
+func (d *Demux) Handle(match func(msg []byte) bool, h HandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.routes = append(d.routes, route{match: match, handler: h})
+}
+
+// HandlePrefix registers a handler for messages beginning with prefix, such as an ISO 8583 MTI.
+func (d *Demux) HandlePrefix(prefix []byte, h HandlerFunc) {
+	p := append([]byte{}, prefix...)
+	d.Handle(func(msg []byte) bool {
+		return bytes.HasPrefix(msg, p)
+	}, h)
+}
+
+// HandleDefault registers the handler for messages which match no other route.
+func (d *Demux) HandleDefault(h HandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.def = h
+}
+
+// Dispatch routes a single message to the first matching handler, or the default handler if no route matches.
+func (d *Demux) Dispatch(msg []byte) {
+	d.mu.RLock()
+	h := d.def
+	for _, r := range d.routes {
+		if r.match(msg) {
+			h = r.handler
+			break
+		}
+	}
+	d.mu.RUnlock()
+
+	if h != nil {
+		h(msg)
+	}
+}
+
+// Serve reads MLI-framed messages of type key from r and dispatches each in turn until r returns an error. Handlers
+// are called synchronously, a handler which needs to block should hand the message off to another goroutine. Serve
+// returns nil when r reaches EOF on a frame boundary.
+//
+// Options such as MaxLength are applied to every MLI read, without MaxLength Serve allocates whatever length the MLI
+// describes, so set a limit for untrusted peers.
+//
+//	err := d.Serve(conn, simplemli.MLI2I, simplemli.MaxLength(8192))
+func (d *Demux) Serve(r io.Reader, key string, opts ...DecodeOption) error {
+	for {
+		msg, err := ReadFrame(r, key, opts...)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		d.Dispatch(msg)
+	}
+}
@@ -0,0 +1,79 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDemux(t *testing.T) {
+	got := make(map[string][]string)
+	record := func(name string) HandlerFunc {
+		return func(msg []byte) {
+			got[name] = append(got[name], string(msg))
+		}
+	}
+
+	d := NewDemux()
+	d.HandlePrefix([]byte("0800"), record("network"))
+	d.HandlePrefix([]byte("0200"), record("financial"))
+	d.Handle(func(msg []byte) bool { return len(msg) == 0 }, record("empty"))
+	d.HandleDefault(record("default"))
+
+	var buf bytes.Buffer
+	for _, m := range []string{"0800 echo", "0200 purchase", "", "0100 auth", "0200 refund"} {
+		err := WriteFrame(&buf, MLI2E, []byte(m))
+		if err != nil {
+			t.Fatalf("Unable to write test frame - %s", err)
+		}
+	}
+
+	t.Run("Serve", func(t *testing.T) {
+		err := d.Serve(&buf, MLI2E)
+		if err != nil {
+			t.Errorf("Unexpected error from Serve - %s", err)
+		}
+
+		expected := map[string]int{"network": 1, "financial": 2, "empty": 1, "default": 1}
+		for k, v := range expected {
+			if len(got[k]) != v {
+				t.Errorf("Unexpected number of messages routed to %s, got %d expected %d", k, len(got[k]), v)
+			}
+		}
+	})
+
+	t.Run("Truncated stream", func(t *testing.T) {
+		err := d.Serve(bytes.NewReader([]byte{0x00, 0x05, '0'}), MLI2E)
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF from Serve, got %v", err)
+		}
+	})
+
+	t.Run("Max length", func(t *testing.T) {
+		err := d.Serve(bytes.NewReader([]byte{0xff, 0xff}), MLI2E, MaxLength(100))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge from Serve, got %v", err)
+		}
+	})
+
+	t.Run("No default handler", func(t *testing.T) {
+		d := NewDemux()
+		d.Dispatch([]byte("unrouted"))
+	})
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"errors"
+	"io"
+)
+
+// Code is a stable, machine-readable error category. The numeric values and names of codes never change between
+// releases, so they can be mapped to ISO response codes and alerting rules.
+type Code int
+
+// Error codes returned by ErrorCode
+const (
+	// CodeNone is returned for a nil error
+	CodeNone Code = 0
+
+	// CodeUnknown is returned for errors not raised by framing, such as network failures
+	CodeUnknown Code = 1
+
+	// CodeInvalidType reports an unsupported MLI type, ErrInvalidType
+	CodeInvalidType Code = 100
+
+	// CodeByteSize reports an MLI of the wrong size, ErrByteSize
+	CodeByteSize Code = 101
+
+	// CodeLength reports an invalid length such as a negative value, ErrLength
+	CodeLength Code = 102
+
+	// CodeTooLarge reports a length exceeding the configured or representable maximum, ErrTooLarge
+	CodeTooLarge Code = 103
+
+	// CodeNotNumeric reports a decimal MLI containing invalid digits, ErrNotNumeric
+	CodeNotNumeric Code = 104
+
+	// CodeFrameLength reports a frame whose MLI does not match its message, ErrFrameLength
+	CodeFrameLength Code = 105
+
+	// CodeTruncated reports a stream or buffer ending part way through a frame, io.ErrUnexpectedEOF or ErrIncomplete
+	CodeTruncated Code = 106
+
+	// CodeSequence reports a message without room for its sequence field, ErrSequence
+	CodeSequence Code = 107
+)
+
+// codeNames holds the string form of each code
+var codeNames = map[Code]string{
+	CodeNone:        "none",
+	CodeUnknown:     "unknown",
+	CodeInvalidType: "invalid_type",
+	CodeByteSize:    "byte_size",
+	CodeLength:      "invalid_length",
+	CodeTooLarge:    "too_large",
+	CodeNotNumeric:  "not_numeric",
+	CodeFrameLength: "frame_length",
+	CodeTruncated:   "truncated",
+	CodeSequence:    "sequence",
+}
+
+// String returns the stable name of the code, e.g. "too_large".
+func (c Code) String() string {
+	if s, ok := codeNames[c]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// codeError pairs a sentinel error with its code
+type codeError struct {
+	err  error
+	code Code
+}
+
+// codeErrors maps sentinel errors to their codes, checked in order. Errors defined by the streaming subsystem are
+// registered by that subsystem, so core builds do not depend on it.
+var codeErrors = []codeError{
+	{ErrInvalidType, CodeInvalidType},
+	{ErrByteSize, CodeByteSize},
+	{ErrLength, CodeLength},
+	{ErrTooLarge, CodeTooLarge},
+	{ErrNotNumeric, CodeNotNumeric},
+	{ErrFrameLength, CodeFrameLength},
+	{io.ErrUnexpectedEOF, CodeTruncated},
+	{ErrIncomplete, CodeTruncated},
+}
+
+// ErrorCode returns the category of err, following wrapped errors. Errors not raised by framing return CodeUnknown.
+//
+//	switch simplemli.ErrorCode(err) {
+//	case simplemli.CodeTooLarge:
+//		// Respond with format error
+//	}
+func ErrorCode(err error) Code {
+	if err == nil {
+		return CodeNone
+	}
+	for _, c := range codeErrors {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	decode := func(key string, b []byte) error {
+		_, err := Decode(key, &b)
+		return err
+	}
+	reframe := func(b []byte) error {
+		_, err := Reframe(io.Discard, bytes.NewReader(b), MLI2I, MLI2E)
+		return err
+	}
+
+	tc := []struct {
+		name string
+		err  error
+		code Code
+		str  string
+	}{
+		{"Nil", nil, CodeNone, "none"},
+		{"Other", fmt.Errorf("connection reset"), CodeUnknown, "unknown"},
+		{"Invalid Type", decode("3Q", nil), CodeInvalidType, "invalid_type"},
+		{"Byte Size", decode(MLI2I, []byte{0x00}), CodeByteSize, "byte_size"},
+		{"Length", decode(MLI2I, []byte{0x00, 0x01}), CodeLength, "invalid_length"},
+		{"Too Large", func() error { _, err := EncodeLen(MLI2E, uint32(70000)); return err }(), CodeTooLarge, "too_large"},
+		{"Not Numeric BCD", decode(MLI2BCD2, []byte{0x00, 0x00, 0x1a, 0x00}), CodeNotNumeric, "not_numeric"},
+		{"Not Numeric A4E", decode(MLIA4E, []byte("12a4")), CodeNotNumeric, "not_numeric"},
+		{"Frame Length", func() error { _, err := DecodeFrameBase64(MLI2I, "AAc="); return err }(), CodeFrameLength,
+			"frame_length"},
+		{"Truncated", reframe([]byte{0x00, 0x10, 'a'}), CodeTruncated, "truncated"},
+		{"Incomplete", func() error { _, _, err := Deframe(MLI2I, []byte{0x00}); return err }(), CodeTruncated,
+			"truncated"},
+		{"Wrapped", fmt.Errorf("reading request - %w", ErrByteSize), CodeByteSize, "byte_size"},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			code := ErrorCode(c.err)
+			if code != c.code || code.String() != c.str {
+				t.Errorf("Unexpected code for %v, got %d %s expected %d %s", c.err, code, code, c.code, c.str)
+			}
+		})
+	}
+
+	if s := Code(9999).String(); s != "unknown" {
+		t.Errorf("Unexpected name for undefined code, got %s", s)
+	}
+}
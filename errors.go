@@ -0,0 +1,126 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+)
+
+// SizeError reports MLI bytes which do not match the size of the MLI type, carrying what arrived for diagnostics.
+// SizeError matches ErrByteSize with errors.Is.
+//
+//	var se *simplemli.SizeError
+//	if errors.As(err, &se) {
+//		log.Printf("%s MLI of %d bytes, expected %d", se.Key, se.Got, se.Expected)
+//	}
+type SizeError struct {
+	// Key is the MLI type
+	Key string
+
+	// Expected is the size of the MLI type in bytes
+	Expected int
+
+	// Got is the number of bytes provided
+	Got int
+}
+
+// Error returns a description of the size mismatch.
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("%s - %s mli is %d bytes, got %d", ErrByteSize, e.Key, e.Expected, e.Got)
+}
+
+// Unwrap returns ErrByteSize.
+func (e *SizeError) Unwrap() error {
+	return ErrByteSize
+}
+
+// LengthError reports an invalid length, such as a negative length to encode or an inclusive MLI whose value is
+// smaller than the MLI itself. LengthError matches ErrLength with errors.Is.
+type LengthError struct {
+	// Key is the MLI type
+	Key string
+
+	// Value is the rejected length, for decoding the raw value of the MLI
+	Value int64
+}
+
+// Error returns a description of the invalid length.
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("%s - %s length %d", ErrLength, e.Key, e.Value)
+}
+
+// Unwrap returns ErrLength.
+func (e *LengthError) Unwrap() error {
+	return ErrLength
+}
+
+// RangeError reports a message length larger than the MLI type can represent, which would otherwise be truncated into
+// a corrupt MLI. RangeError matches ErrTooLarge with errors.Is.
+type RangeError struct {
+	// Key is the MLI type
+	Key string
+
+	// Length is the rejected message length
+	Length int
+
+	// Max is the largest message length the MLI type can represent
+	Max int
+}
+
+// Error returns a description of the out of range length.
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("%s - %d bytes exceeds %s limit of %d", ErrTooLarge, e.Length, e.Key, e.Max)
+}
+
+// Unwrap returns ErrTooLarge.
+func (e *RangeError) Unwrap() error {
+	return ErrTooLarge
+}
+
+// NumericError reports a decimal MLI, such as 2BCD2 or A4E, which could not be parsed. NumericError matches
+// ErrNotNumeric with errors.Is and unwraps to the underlying cause, ErrInvalidBCD, ErrInvalidDigit, ErrInvalidHex or
+// ErrInvalidEBCDIC for the built-in types.
+type NumericError struct {
+	// Key is the MLI type
+	Key string
+
+	// MLI is the raw indicator which failed to parse
+	MLI []byte
+
+	// Offset is the index of the offending byte within MLI
+	Offset int
+
+	// Err is the underlying cause
+	Err error
+}
+
+// Error returns a description of the parse failure, naming the offending byte.
+func (e *NumericError) Error() string {
+	if e.Offset >= 0 && e.Offset < len(e.MLI) {
+		return fmt.Sprintf("%s - %s mli %x - %s, byte %#02x at offset %d", ErrNotNumeric, e.Key, e.MLI, e.Err,
+			e.MLI[e.Offset], e.Offset)
+	}
+	return fmt.Sprintf("%s - %s mli %x - %s", ErrNotNumeric, e.Key, e.MLI, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *NumericError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrNotNumeric.
+func (e *NumericError) Is(target error) bool {
+	return target == ErrNotNumeric
+}
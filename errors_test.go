@@ -0,0 +1,293 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSizeError(t *testing.T) {
+	_, err := DecodeBytes(MLI4E, []byte{0x00, 0x01})
+	var se *SizeError
+	if !errors.As(err, &se) {
+		t.Fatalf("Expected SizeError, got %v", err)
+	}
+	if se.Key != MLI4E || se.Expected != Size4E || se.Got != 2 {
+		t.Errorf("Unexpected SizeError fields, got %+v", se)
+	}
+	if !errors.Is(err, ErrByteSize) || ErrorCode(err) != CodeByteSize {
+		t.Errorf("Expected SizeError to match ErrByteSize, got %v", err)
+	}
+
+	c, _ := New(MLI2I)
+	err = c.Encode(1, make([]byte, 1))
+	if !errors.As(err, &se) || se.Key != MLI2I || se.Got != 1 {
+		t.Errorf("Unexpected error encoding to a short buffer, got %v", err)
+	}
+}
+
+func TestLengthError(t *testing.T) {
+	tc := []struct {
+		name  string
+		err   error
+		key   string
+		value int64
+	}{
+		{"Decode 2I", func() error { _, err := DecodeBytes(MLI2I, []byte{0x00, 0x01}); return err }(), MLI2I, 1},
+		{"Decode 4I", func() error { _, err := DecodeBytes(MLI4I, []byte{0x00, 0x00, 0x00, 0x03}); return err }(),
+			MLI4I, 3},
+		{"Decode 2BCD2", func() error { _, err := DecodeBytes(MLI2BCD2, []byte{0x00, 0x00, 0x00, 0x02}); return err }(),
+			MLI2BCD2, 2},
+		{"Encode", func() error { _, err := Encode(MLI2E, -5); return err }(), MLI2E, -5},
+		{"Decode Len", func() error { _, err := DecodeLen[uint8](MLI2E, []byte{0x01, 0x2c}); return err }(), MLI2E, 300},
+		{"Encode 2EE", func() error { _, err := Encode(MLI2EE, 1); return err }(), MLI2EE, 1},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var le *LengthError
+			if !errors.As(c.err, &le) {
+				t.Fatalf("Expected LengthError, got %v", c.err)
+			}
+			if le.Key != c.key || le.Value != c.value {
+				t.Errorf("Unexpected LengthError fields, got %+v expected %s %d", le, c.key, c.value)
+			}
+			if !errors.Is(c.err, ErrLength) || ErrorCode(c.err) != CodeLength {
+				t.Errorf("Expected LengthError to match ErrLength, got %v", c.err)
+			}
+		})
+	}
+}
+
+func TestRangeError(t *testing.T) {
+	tc := []struct {
+		name   string
+		key    string
+		length int
+		max    int
+	}{
+		{"2I", MLI2I, 65534, 65533},
+		{"2E", MLI2E, 65536, 65535},
+		{"2EE", MLI2EE, 65538, 65537},
+		{"2BCD2", MLI2BCD2, 9996, 9995},
+		{"A4E", MLIA4E, 10000, 9999},
+		{"2IL", MLI2IL, 65534, 65533},
+		{"2EL", MLI2EL, 65536, 65535},
+		{"1I", MLI1I, 255, 254},
+		{"1E", MLI1E, 256, 255},
+		{"3I", MLI3I, 16777213, 16777212},
+		{"3E", MLI3E, 16777216, 16777215},
+		{"A2E", MLIA2E, 100, 99},
+		{"A6E", MLIA6E, 1000000, 999999},
+		{"A6I", MLIA6I, 999994, 999993},
+		{"H4E", MLIH4E, 65536, 65535},
+		{"E4E", MLIE4E, 10000, 9999},
+		{"BCD2", MLIBCD2, 10000, 9999},
+		{"BCD4", MLIBCD4, 100000000, 99999999},
+		{"BCD4I", MLIBCD4I, 99999996, 99999995},
+		{"2BCD2I", MLI2BCD2I, 9996, 9995},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Encode(c.key, c.max); err != nil {
+				t.Fatalf("Unexpected error encoding the maximum length %d - %s", c.max, err)
+			}
+			b, err := Encode(c.key, c.length)
+			var re *RangeError
+			if !errors.As(err, &re) {
+				t.Fatalf("Expected RangeError, got %v", err)
+			}
+			if re.Key != c.key || re.Length != c.length || re.Max != c.max {
+				t.Errorf("Unexpected RangeError fields, got %+v", re)
+			}
+			if !errors.Is(err, ErrTooLarge) || ErrorCode(err) != CodeTooLarge {
+				t.Errorf("Expected RangeError to match ErrTooLarge, got %v", err)
+			}
+			if len(b) != 0 {
+				t.Errorf("Expected no MLI on error, got %x", b)
+			}
+			if dst, err := EncodeAppend([]byte("x"), c.key, c.length); err == nil || string(dst) != "x" {
+				t.Errorf("Expected EncodeAppend to reject %d and leave dst unchanged, got %x %v", c.length, dst, err)
+			}
+		})
+	}
+
+	t.Run("4 Byte", func(t *testing.T) {
+		if strconv.IntSize < 64 {
+			t.Skip("int cannot exceed the 4-byte MLI range")
+		}
+		shift := 32
+		n := 1 << shift
+		for _, key := range []string{MLI4I, MLI4E, MLI4IL, MLI4EL} {
+			if _, err := Encode(key, n); !errors.Is(err, ErrTooLarge) {
+				t.Errorf("Expected %s to reject %d, got %v", key, n, err)
+			}
+		}
+	})
+}
+
+func TestNumericError(t *testing.T) {
+	tc := []struct {
+		key    string
+		mli    []byte
+		offset int
+		err    error
+	}{
+		{MLI2BCD2, []byte{0x00, 0x00, 0x1a, 0x00}, 2, ErrInvalidBCD},
+		{MLIA4E, []byte("12a4"), 2, ErrInvalidDigit},
+		{MLIH4E, []byte("05DG"), 3, ErrInvalidHex},
+		{MLIE4E, []byte{0xf1, 0x35, 0xf0, 0xf0}, 1, ErrInvalidEBCDIC},
+		{MLIBCD2, []byte{0x15, 0x0c}, 1, ErrInvalidBCD},
+		{MLIBCD4, []byte{0x00, 0xa0, 0x15, 0x00}, 1, ErrInvalidBCD},
+	}
+	for _, c := range tc {
+		t.Run(c.key, func(t *testing.T) {
+			_, err := DecodeBytes(c.key, c.mli)
+			var ne *NumericError
+			if !errors.As(err, &ne) {
+				t.Fatalf("Expected NumericError, got %v", err)
+			}
+			if ne.Key != c.key || !bytes.Equal(ne.MLI, c.mli) || ne.Offset != c.offset {
+				t.Errorf("Unexpected NumericError fields, got %+v", ne)
+			}
+			if !errors.Is(err, ErrNotNumeric) || ErrorCode(err) != CodeNotNumeric {
+				t.Errorf("Expected NumericError to match ErrNotNumeric, got %v", err)
+			}
+
+			// The cause is preserved in the chain
+			if !errors.Is(err, c.err) {
+				t.Errorf("Expected %v to be wrapped, got %v", c.err, err)
+			}
+			want := fmt.Sprintf("byte %#02x at offset %d", c.mli[c.offset], c.offset)
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to name the offending byte %q, got %q", want, err)
+			}
+		})
+	}
+}
+
+func TestInvalidDigit(t *testing.T) {
+	tc := map[string]struct {
+		mli    string
+		padded bool
+		want   int
+		offset int
+	}{
+		"Digits":          {"1500", false, 1500, -1},
+		"Zero":            {"0000", false, 0, -1},
+		"Plus Sign":       {"+150", false, 0, 0},
+		"Minus Sign":      {"-001", false, 0, 0},
+		"Leading Space":   {" 150", false, 0, 0},
+		"Trailing Space":  {"150 ", false, 0, 3},
+		"Padded":          {"  42", true, 42, -1},
+		"Padded Blank":    {"    ", true, 0, -1},
+		"Padded Interior": {" 4 2", true, 0, 2},
+		"Padded Sign":     {" -42", true, 0, 1},
+	}
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			var opts []DecodeOption
+			if c.padded {
+				opts = append(opts, SpacePadded())
+			}
+			n, err := DecodeBytes(MLIA4E, []byte(c.mli), opts...)
+			if c.offset < 0 {
+				if err != nil || n != c.want {
+					t.Errorf("Decode of %q returned %d, %v expected %d", c.mli, n, err, c.want)
+				}
+				return
+			}
+			var ne *NumericError
+			if !errors.As(err, &ne) || !errors.Is(err, ErrInvalidDigit) {
+				t.Fatalf("Expected ErrInvalidDigit decoding %q, got %v", c.mli, err)
+			}
+			if ne.Offset != c.offset {
+				t.Errorf("Expected offending byte at offset %d, got %d", c.offset, ne.Offset)
+			}
+		})
+	}
+
+	t.Run("Padded A2E", func(t *testing.T) {
+		n, err := DecodeBytes(MLIA2E, []byte(" 7"), SpacePadded())
+		if err != nil || n != 7 {
+			t.Errorf("Unexpected result decoding padded A2E, got %d, %v", n, err)
+		}
+		_, err = DecodeBytes(MLIA2E, []byte("+7"))
+		if !errors.Is(err, ErrInvalidDigit) {
+			t.Errorf("Expected ErrInvalidDigit, got %v", err)
+		}
+	})
+
+	t.Run("Padded A6I", func(t *testing.T) {
+		n, err := DecodeBytes(MLIA6I, []byte("  1506"), SpacePadded())
+		if err != nil || n != 1500 {
+			t.Errorf("Unexpected result decoding padded A6I, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Padded E4E", func(t *testing.T) {
+		n, err := DecodeBytes(MLIE4E, []byte{0x40, 0x40, 0xf4, 0xf2}, SpacePadded())
+		if err != nil || n != 42 {
+			t.Errorf("Unexpected result decoding padded E4E, got %d, %v", n, err)
+		}
+		_, err = DecodeBytes(MLIE4E, []byte("  42"), SpacePadded())
+		if !errors.Is(err, ErrInvalidEBCDIC) {
+			t.Errorf("Expected ErrInvalidEBCDIC decoding ASCII, got %v", err)
+		}
+	})
+
+	t.Run("Padded Other Types", func(t *testing.T) {
+		n, err := DecodeBytes(MLI2E, []byte{0x00, 0x20}, SpacePadded())
+		if err != nil || n != 32 {
+			t.Errorf("Expected SpacePadded to have no effect on 2E, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Padded Max Length", func(t *testing.T) {
+		_, err := DecodeBytes(MLIA4E, []byte(" 500"), SpacePadded(), MaxLength(100))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+	})
+}
+
+func TestInvalidBCD(t *testing.T) {
+	tc := map[string][]byte{
+		"High Nibble": {0x00, 0x00, 0xa0, 0x12},
+		"Low Nibble":  {0x00, 0x00, 0x0a, 0x12},
+		"Second Byte": {0x00, 0x00, 0x00, 0x1f},
+		"All Nibbles": {0x00, 0x00, 0xff, 0xff},
+	}
+	for name, mli := range tc {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeBytes(MLI2BCD2, mli)
+			if !errors.Is(err, ErrInvalidBCD) || !errors.Is(err, ErrNotNumeric) {
+				t.Errorf("Expected ErrInvalidBCD decoding %x, got %v", mli, err)
+			}
+		})
+	}
+
+	// Every valid BCD value decodes to its decimal value
+	for n := Size2BCD2; n <= 9999; n++ {
+		mli := []byte{0x00, 0x00, byte(n/1000)<<4 | byte(n/100%10), byte(n/10%10)<<4 | byte(n%10)}
+		got, err := DecodeBytes(MLI2BCD2, mli)
+		if err != nil || got != n-Size2BCD2 {
+			t.Fatalf("Decode of %x returned %d, %v expected %d", mli, got, err, n-Size2BCD2)
+		}
+	}
+}
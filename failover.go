@@ -0,0 +1,132 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoEndpoint reports that none of a Failover's addresses could be connected to.
+var ErrNoEndpoint = fmt.Errorf("unable to connect to any endpoint")
+
+// Failover dials an ordered list of endpoints, such as a host's primary and secondary switch sites, connecting to
+// the first which accepts a connection. When connected to any endpoint but the first, the more preferred endpoints are
+// probed every ProbeInterval and once one accepts a connection the Failover fails back by closing the current
+// connection, so the application's next Dial returns to the preferred site.
+//
+//	f := &simplemli.Failover{
+//		Addresses:     []string{"primary:9100", "secondary:9100"},
+//		Key:           simplemli.MLI2I,
+//		DialTimeout:   5 * time.Second,
+//		ProbeInterval: time.Minute,
+//	}
+//	conn, err := f.Dial()
+type Failover struct {
+	// Network is the network to dial, defaulting to "tcp"
+	Network string
+
+	// Addresses lists the endpoints in order of preference
+	Addresses []string
+
+	// Key is the MLI type of the connection
+	Key string
+
+	// Options configure the MessageConn and its socket
+	Options []Option
+
+	// DialTimeout limits each connection attempt, overriding WithDialTimeout, zero means no timeout
+	DialTimeout time.Duration
+
+	// ProbeInterval is the interval between fail-back probes, zero disables fail-back
+	ProbeInterval time.Duration
+
+	// OnFailback, if not nil, is called instead of closing the connection when a more preferred endpoint becomes
+	// available, allowing the application to drain in-flight messages before reconnecting
+	OnFailback func(conn *MessageConn, addr string)
+}
+
+// network returns the configured network or the default
+func (f *Failover) network() string {
+	if f.Network == "" {
+		return "tcp"
+	}
+	return f.Network
+}
+
+// dialOptions returns the options used to dial each endpoint, with DialTimeout taking precedence over WithDialTimeout
+func (f *Failover) dialOptions() options {
+	o := newOptions(f.Options)
+	if f.DialTimeout > 0 {
+		o.dialTimeout = f.DialTimeout
+	}
+	return o
+}
+
+// Dial connects to the most preferred endpoint which accepts a connection. If every endpoint fails, Dial returns
+// ErrNoEndpoint along with the error from each attempt.
+func (f *Failover) Dial() (*MessageConn, error) {
+	o := f.dialOptions()
+	var failures []string
+	for i, addr := range f.Addresses {
+		conn, err := dialTuned(context.Background(), f.network(), addr, o)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", addr, err))
+			continue
+		}
+
+		mc := NewMessageConn(conn, f.Key, f.Options...)
+		if i > 0 && f.ProbeInterval > 0 {
+			go f.probe(mc, i)
+		}
+		return mc, nil
+	}
+	return nil, fmt.Errorf("%w - %s", ErrNoEndpoint, strings.Join(failures, "; "))
+}
+
+// probe checks the endpoints preferred over index until one accepts a connection or mc is closed. Probes are dialled
+// the same way as Dial, so they honour WithDialTimeout and the other dial options.
+func (f *Failover) probe(mc *MessageConn, index int) {
+	o := f.dialOptions()
+	t := time.NewTicker(f.ProbeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-mc.closed:
+			return
+		}
+
+		for _, addr := range f.Addresses[:index] {
+			conn, err := dialTuned(context.Background(), f.network(), addr, o)
+			if err != nil {
+				continue
+			}
+			conn.Close()
+
+			if f.OnFailback != nil {
+				f.OnFailback(mc, addr)
+				return
+			}
+			mc.opts.logf("simplemli: %s available, closing connection to %s to fail back", addr, mc.RemoteAddr())
+			_ = mc.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// acceptAll accepts connections on ln until it is closed
+func acceptAll(ln net.Listener) {
+	var conns []net.Conn
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			break
+		}
+		conns = append(conns, conn)
+	}
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+func TestFailover(t *testing.T) {
+	// Reserve an address for the primary which refuses connections until it is listened on
+	reserved, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	primary := reserved.Addr().String()
+	reserved.Close()
+
+	secondary, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	defer secondary.Close()
+	go acceptAll(secondary)
+
+	t.Run("Fail Over and Back", func(t *testing.T) {
+		failback := make(chan string, 1)
+		f := &Failover{
+			Addresses:     []string{primary, secondary.Addr().String()},
+			Key:           MLI2I,
+			DialTimeout:   time.Second,
+			ProbeInterval: 10 * time.Millisecond,
+			OnFailback:    func(_ *MessageConn, addr string) { failback <- addr },
+		}
+		conn, err := f.Dial()
+		if err != nil {
+			t.Fatalf("Unexpected error dialing - %s", err)
+		}
+		defer conn.Close()
+		if conn.RemoteAddr().String() != secondary.Addr().String() {
+			t.Errorf("Expected connection to secondary, got %s", conn.RemoteAddr())
+		}
+
+		ln, err := net.Listen("tcp", primary)
+		if err != nil {
+			t.Skipf("Unable to listen on reserved primary address - %s", err)
+		}
+		defer ln.Close()
+		go acceptAll(ln)
+
+		select {
+		case addr := <-failback:
+			if addr != primary {
+				t.Errorf("Unexpected fail-back address, got %s", addr)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected fail-back once the primary was available")
+		}
+	})
+
+	t.Run("No Endpoint", func(t *testing.T) {
+		f := &Failover{Addresses: []string{primary, primary}, Key: MLI2I}
+		if ln, err := net.Listen("tcp", primary); err == nil {
+			ln.Close()
+		}
+		_, err := f.Dial()
+		if !errors.Is(err, ErrNoEndpoint) {
+			t.Errorf("Expected ErrNoEndpoint, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"sync"
+)
+
+// gate blocks callers while paused
+type gate struct {
+	mu sync.Mutex
+
+	// ch is open while paused and closed on resume
+	ch chan struct{}
+}
+
+// pause closes the gate
+func (g *gate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ch == nil {
+		g.ch = make(chan struct{})
+	}
+}
+
+// resume opens the gate, releasing any waiting callers
+func (g *gate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ch != nil {
+		close(g.ch)
+		g.ch = nil
+	}
+}
+
+// paused reports whether the gate is closed
+func (g *gate) paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.ch != nil
+}
+
+// wait blocks while the gate is closed or until stop is closed, and reports false if it returned because of stop
+func (g *gate) wait(stop <-chan struct{}) bool {
+	g.mu.Lock()
+	ch := g.ch
+	g.mu.Unlock()
+	if ch == nil {
+		return true
+	}
+	select {
+	case <-ch:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// Pause stops the Reader pulling frames from the underlying reader. Reads started after Pause block until Resume is
+// called, and a read-ahead goroutine stops after its current read, so unread data stays in the socket and TCP flow
+// control pushes back on the sender rather than frames accumulating in the process. A read already in progress is not
+// interrupted. Pause and Resume are safe to call concurrently with reads. Closing a paused MessageConn releases blocked
+// reads with net.ErrClosed.
+//
+//	if len(queue) == cap(queue) {
+//		r.Pause()
+//	}
+func (r *Reader) Pause() {
+	r.gate.pause()
+}
+
+// Resume releases reads blocked by Pause.
+func (r *Reader) Resume() {
+	r.gate.resume()
+}
+
+// Paused reports whether the Reader is paused.
+func (r *Reader) Paused() bool {
+	return r.gate.paused()
+}
+
+// PauseReads pauses the MessageConn's Reader, see Reader.Pause. Writes are not affected.
+func (c *MessageConn) PauseReads() {
+	c.r.Pause()
+}
+
+// ResumeReads releases reads blocked by PauseReads.
+func (c *MessageConn) ResumeReads() {
+	c.r.Resume()
+}
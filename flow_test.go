@@ -0,0 +1,129 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	t.Run("Reader", func(t *testing.T) {
+		r := NewReader(bytes.NewReader([]byte{0x00, 0x01, 'a'}), MLI2E)
+		r.Pause()
+		if !r.Paused() {
+			t.Errorf("Expected reader to report paused")
+		}
+
+		done := make(chan []byte, 1)
+		go func() {
+			msg, _ := r.ReadMessage()
+			done <- msg
+		}()
+		select {
+		case <-done:
+			t.Fatalf("Expected read to block while paused")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		r.Resume()
+		select {
+		case msg := <-done:
+			if string(msg) != "a" {
+				t.Errorf("Unexpected message after resume, got %q", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Read did not resume")
+		}
+		if r.Paused() {
+			t.Errorf("Expected reader to report resumed")
+		}
+	})
+
+	t.Run("Closed While Paused", func(t *testing.T) {
+		for name, opts := range map[string][]Option{
+			"Direct":     nil,
+			"Read Ahead": {WithReadAhead(4)},
+		} {
+			t.Run(name, func(t *testing.T) {
+				a, b := net.Pipe()
+				defer b.Close()
+				conn := NewMessageConn(a, MLI2I, opts...)
+				conn.PauseReads()
+
+				done := make(chan error, 1)
+				go func() {
+					_, err := conn.ReadMessage()
+					done <- err
+				}()
+				time.Sleep(20 * time.Millisecond)
+				_ = conn.Close()
+
+				select {
+				case err := <-done:
+					if !errors.Is(err, net.ErrClosed) {
+						t.Errorf("Unexpected error reading after close, got %v expected %v", err, net.ErrClosed)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Read did not return after close")
+				}
+			})
+		}
+	})
+
+	t.Run("Read Ahead", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I, WithReadAhead(4))
+		peer := NewMessageConn(b, MLI2I)
+		defer conn.Close()
+		defer peer.Close()
+
+		conn.PauseReads()
+		written := make(chan error, 3)
+		go func() {
+			for _, msg := range []string{"1", "2", "3"} {
+				written <- peer.WriteMessage([]byte(msg))
+			}
+		}()
+
+		// The read-ahead goroutine may already be waiting in a read, which completes before it pauses
+		time.Sleep(50 * time.Millisecond)
+		if n := len(written); n > 1 {
+			t.Fatalf("Expected writes to block while reads are paused, %d completed", n)
+		}
+
+		conn.ResumeReads()
+		for i := 0; i < 3; i++ {
+			select {
+			case err := <-written:
+				if err != nil {
+					t.Errorf("Unexpected error writing - %s", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Writes did not complete after resume")
+			}
+		}
+		for _, expected := range []string{"1", "2", "3"} {
+			msg, err := conn.ReadMessage()
+			if err != nil || string(msg) != expected {
+				t.Errorf("Unexpected message, got %q, %v expected %q", msg, err, expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,356 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// mliBounds returns the smallest and largest message lengths representable by the MLI type key, following the
+// conventions of Encode so the 2EE bounds include the 2-byte embedded header. Registered types have no known bounds
+func mliBounds(key string) (minLen, maxLen int, err error) {
+	switch key {
+	case MLI2I:
+		return 0, math.MaxUint16 - Size2I, nil
+	case MLI2E:
+		return 0, math.MaxUint16, nil
+	case MLI4I:
+		return 0, capInt(math.MaxUint32 - Size4I), nil
+	case MLI4E:
+		return 0, capInt(math.MaxUint32), nil
+	case MLI2EE:
+		return Size2EE, math.MaxUint16 + Size2EE, nil
+	case MLI2BCD2, MLI2BCD2I:
+		return 0, 9999 - Size2BCD2, nil
+	case MLIA4E:
+		return 0, 9999, nil
+	case MLI2IL:
+		return 0, math.MaxUint16 - Size2IL, nil
+	case MLI2EL:
+		return 0, math.MaxUint16, nil
+	case MLI4IL:
+		return 0, capInt(math.MaxUint32 - Size4IL), nil
+	case MLI4EL:
+		return 0, capInt(math.MaxUint32), nil
+	case MLI1I:
+		return 0, math.MaxUint8 - Size1I, nil
+	case MLI1E:
+		return 0, math.MaxUint8, nil
+	case MLI3I:
+		return 0, maxUint24 - Size3I, nil
+	case MLI3E:
+		return 0, maxUint24, nil
+	case MLI8I, MLI8E:
+		return 0, math.MaxInt, nil
+	case MLIA2E:
+		return 0, 99, nil
+	case MLIA6E:
+		return 0, 999999, nil
+	case MLIA6I:
+		return 0, 999999 - SizeA6I, nil
+	case MLIH4E:
+		return 0, math.MaxUint16, nil
+	case MLIE4E, MLIBCD2:
+		return 0, 9999, nil
+	case MLIBCD4:
+		return 0, 99999999, nil
+	case MLIBCD4I:
+		return 0, 99999999 - SizeBCD4I, nil
+	default:
+		if _, ok := registered(key); ok {
+			return 0, math.MaxInt, nil
+		}
+		return 0, 0, ErrInvalidType
+	}
+}
+
+// capInt caps n to the largest int on the current platform
+func capInt(n uint64) int {
+	if n > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(n)
+}
+
+// ReadFrame reads a single MLI and the message it describes from r, returning the message without the MLI. Both are
+// read with io.ReadFull, so short reads from a TCP connection are handled. A stream ending part way through a frame
+// returns io.ErrUnexpectedEOF.
+//
+//	msg, err := simplemli.ReadFrame(conn, simplemli.MLI2I)
+//	if err != nil {
+//		// Do something
+//	}
+//
+// Without MaxLength, ReadFrame allocates whatever length the MLI describes, so set a limit for untrusted peers.
+func ReadFrame(r io.Reader, key string, opts ...DecodeOption) ([]byte, error) {
+	size, err := Size(key)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, size)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := DecodeBytes(key, b, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// EncodeFrame returns msg framed with an MLI of type key. The MLI and message share a single allocation, so the frame
+// is ready to hand to conn.Write without a second copy.
+//
+//	frame, err := simplemli.EncodeFrame(simplemli.MLI2I, msg)
+//	if err != nil {
+//		// Do something
+//	}
+//	_, err = conn.Write(frame)
+func EncodeFrame(key string, msg []byte) ([]byte, error) {
+	size, err := Size(key)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := EncodeAppend(make([]byte, 0, size+len(msg)), key, len(msg))
+	if err != nil {
+		return nil, err
+	}
+	return append(b, msg...), nil
+}
+
+// ErrIncomplete reports a buffer which does not yet hold a complete frame, more data must be read before retrying.
+var ErrIncomplete = fmt.Errorf("incomplete frame")
+
+// Deframe splits the frame at the start of buf, returning the message and the bytes which follow it. If buf does not
+// hold a complete frame Deframe returns ErrIncomplete, any other error means buf does not start with a valid MLI. The
+// message is capped at its length, so appending to it cannot overwrite rest.
+//
+//	for {
+//		msg, rest, err := simplemli.Deframe(simplemli.MLI2I, buf)
+//		if err == simplemli.ErrIncomplete {
+//			break // read more
+//		}
+//		if err != nil {
+//			// Do something
+//		}
+//		handle(msg)
+//		buf = rest
+//	}
+func Deframe(key string, buf []byte, opts ...DecodeOption) (msg, rest []byte, err error) {
+	size, err := Size(key)
+	if err != nil {
+		return nil, buf, err
+	}
+	if len(buf) < size {
+		return nil, buf, ErrIncomplete
+	}
+
+	n, err := DecodeBytes(key, buf[:size], opts...)
+	if err != nil {
+		return nil, buf, err
+	}
+	if n > len(buf)-size {
+		return nil, buf, ErrIncomplete
+	}
+	end := size + n
+	return buf[size:end:end], buf[end:], nil
+}
+
+// PartialWriteError reports a frame which was only partly written, typically because a write deadline expired part
+// way through. A Writer returns the same PartialWriteError for every write until the rest of the frame is written with
+// Resume, so a new MLI is never interleaved into the half-sent frame.
+type PartialWriteError struct {
+	// Written is the number of bytes of the frame, including the MLI, which were sent
+	Written int
+
+	// Total is the size of the frame including the MLI
+	Total int
+
+	// Err is the error returned by the underlying writer
+	Err error
+}
+
+// Error returns a description of the partial write.
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("partial write, %d of %d bytes written - %s", e.Written, e.Total, e.Err)
+}
+
+// Unwrap returns the underlying write error, so errors.Is and errors.As can check for timeouts.
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+// WriteFrame encodes an MLI for msg and writes the MLI and message to w with a single write. A write which sends only
+// part of the frame returns a *PartialWriteError, as the stream can no longer be framed.
+//
+//	err := simplemli.WriteFrame(conn, simplemli.MLI2I, msg)
+//	if err != nil {
+//		// Do something
+//	}
+func WriteFrame(w io.Writer, key string, msg []byte) error {
+	frame, err := EncodeFrame(key, msg)
+	if err != nil {
+		return err
+	}
+
+	n, err := w.Write(frame)
+	if n < len(frame) && err == nil {
+		err = io.ErrShortWrite
+	}
+	if n > 0 && n < len(frame) {
+		return &PartialWriteError{Written: n, Total: len(frame), Err: err}
+	}
+	return err
+}
+
+// Reframe reads MLI-framed messages of type from until src reaches EOF and writes each message to dst framed with an
+// MLI of type to. Reframe returns the number of messages converted, stopping at the first error.
+//
+//	n, err := simplemli.Reframe(dst, src, simplemli.MLI2I, simplemli.MLI4E)
+//	if err != nil {
+//		// Do something
+//	}
+//
+// Reading from src ending part way through a frame returns io.ErrUnexpectedEOF.
+func Reframe(dst io.Writer, src io.Reader, from, to string) (int, error) {
+	// Validate the output type before consuming any input
+	_, err := Size(to)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for {
+		msg, err := ReadFrame(src, from)
+		if err != nil {
+			if err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+
+		err = WriteFrame(dst, to, msg)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// summaryBytes is the number of leading body bytes included in Frame summaries. For ISO 8583 this covers the 4-byte
+// MTI and 8-byte primary bitmap without reaching cardholder data.
+const summaryBytes = 12
+
+// Frame is a single MLI-framed message along with metadata describing how it was received. Frames are returned by
+// Reader.ReadFrame and MessageConn.ReadFrame.
+type Frame struct {
+	// Key is the MLI type
+	Key string
+
+	// MLI is the raw message length indicator
+	MLI []byte
+
+	// Body is the message following the MLI
+	Body []byte
+
+	// Length is the message length declared by the MLI, less any sequence field removed by WithSequence
+	Length int
+
+	// Received is the time the frame was read, zero for frames which were not read from a connection
+	Received time.Time
+
+	// Tag identifies the connection the frame was read from, as set by WithTag
+	Tag string
+}
+
+// Clone returns a deep copy of the frame whose MLI and Body do not alias f, sharing a single new allocation.
+func (f Frame) Clone() Frame {
+	c := f
+	if f.MLI == nil && f.Body == nil {
+		return c
+	}
+	buf := make([]byte, len(f.MLI)+len(f.Body))
+	n := copy(buf, f.MLI)
+	copy(buf[n:], f.Body)
+	c.MLI = buf[:n:n]
+	c.Body = buf[n:]
+	return c
+}
+
+// Reset clears the frame for reuse while keeping the capacity of MLI and Body, so a pooled frame can be refilled by
+// Reader.ReadFrameInto without allocating. After Reset, any slices previously taken from MLI or Body still alias the
+// frame's buffers and will be overwritten when it is refilled, use Clone to retain data beyond the frame's reuse.
+func (f *Frame) Reset() {
+	*f = Frame{MLI: f.MLI[:0], Body: f.Body[:0]}
+}
+
+// String returns a concise summary of the frame containing the MLI type, the body length and the first bytes of the
+// body in hex. The summary never includes more than the first 12 bytes of the body, so frames can be logged with %v
+// without exposing card numbers or other sensitive fields.
+//
+//	2I len=1500 mli=05de body=30313030f23c448108e08000...
+func (f Frame) String() string {
+	return fmt.Sprintf("%s len=%d mli=%x body=%s", f.Key, len(f.Body), f.MLI, summarize(f.Body))
+}
+
+// GoString returns a Go-syntax representation of the frame for %#v, truncating the body like String.
+func (f Frame) GoString() string {
+	return fmt.Sprintf("simplemli.Frame{Key:%q, MLI:%#v, Body:%s}", f.Key, f.MLI, goSummarize(f.Body))
+}
+
+// summarize returns the leading bytes of b in hex, followed by an ellipsis if b was truncated
+func summarize(b []byte) string {
+	if len(b) > summaryBytes {
+		return fmt.Sprintf("%x...", b[:summaryBytes])
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// goSummarize returns the leading bytes of b as a Go byte slice literal, noting the full length if b was truncated
+func goSummarize(b []byte) string {
+	if b == nil {
+		return "[]byte(nil)"
+	}
+	n := len(b)
+	if n > summaryBytes {
+		b = b[:summaryBytes]
+	}
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("0x%02x", c)
+	}
+	s := "[]byte{" + strings.Join(parts, ", ")
+	if n > summaryBytes {
+		s += fmt.Sprintf(", /* %d more bytes */", n-summaryBytes)
+	}
+	return s + "}"
+}
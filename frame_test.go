@@ -0,0 +1,306 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReframe(t *testing.T) {
+	t.Run("2I to 2BCD2", func(t *testing.T) {
+		src, _ := hex.DecodeString("00036100046263" + "0002")
+		var dst bytes.Buffer
+		n, err := Reframe(&dst, bytes.NewReader(src), MLI2I, MLI2BCD2)
+		if err != nil {
+			t.Errorf("Unexpected error from Reframe - %s", err)
+		}
+		if n != 3 {
+			t.Errorf("Unexpected number of messages converted, got %d expected 3", n)
+		}
+		expected := "0000000561" + "000000066263" + "00000004"
+		if hex.EncodeToString(dst.Bytes()) != expected {
+			t.Errorf("Unexpected reframed output, got %x expected %s", dst.Bytes(), expected)
+		}
+	})
+
+	t.Run("Truncated input", func(t *testing.T) {
+		var dst bytes.Buffer
+		n, err := Reframe(&dst, bytes.NewReader([]byte{0x00, 0x05, 'a'}), MLI2I, MLI4E)
+		if err != io.ErrUnexpectedEOF || n != 0 {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Invalid output type", func(t *testing.T) {
+		var dst bytes.Buffer
+		_, err := Reframe(&dst, bytes.NewReader([]byte{0x00, 0x02}), MLI2I, "Invalid")
+		if err == nil {
+			t.Errorf("Expected error reframing to an invalid MLI type - got nil")
+		}
+	})
+}
+
+func TestEncodeFrame(t *testing.T) {
+	msg := []byte("This is a message")
+	for _, k := range []string{MLI2I, MLI2E, MLI4I, MLI4E, MLI2EE, MLI2BCD2, MLIA4E} {
+		t.Run(k, func(t *testing.T) {
+			mli, _ := Encode(k, len(msg))
+			frame, err := EncodeFrame(k, msg)
+			if err != nil || !bytes.Equal(frame, append(mli, msg...)) {
+				t.Errorf("Unexpected result from EncodeFrame, got %x, %v", frame, err)
+			}
+			if cap(frame) != len(frame) {
+				t.Errorf("Expected a frame sized exactly, got len %d cap %d", len(frame), cap(frame))
+			}
+		})
+	}
+
+	t.Run("Single Allocation", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = EncodeFrame(MLI2I, msg)
+		})
+		if allocs != 1 {
+			t.Errorf("Expected 1 allocation, got %v", allocs)
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		if _, err := EncodeFrame("bad", msg); err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+	})
+}
+
+func TestDeframe(t *testing.T) {
+	buf := []byte{0x00, 0x05, 'h', 'e', 'l', 'l', 'o', 0x00, 0x02, 'h', 'i', 0x00}
+
+	var msgs []string
+	for {
+		msg, rest, err := Deframe(MLI2E, buf)
+		if err == ErrIncomplete {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error from Deframe - %s", err)
+		}
+		if cap(msg) != len(msg) {
+			t.Errorf("Expected message capped at its length, got len %d cap %d", len(msg), cap(msg))
+		}
+		msgs = append(msgs, string(msg))
+		buf = rest
+	}
+	if strings.Join(msgs, ",") != "hello,hi" {
+		t.Errorf("Unexpected messages, got %q", msgs)
+	}
+	if !bytes.Equal(buf, []byte{0x00}) {
+		t.Errorf("Unexpected remainder, got %x", buf)
+	}
+
+	t.Run("Incomplete Body", func(t *testing.T) {
+		buf := []byte{0x00, 0x05, 'h', 'e'}
+		msg, rest, err := Deframe(MLI2E, buf)
+		if err != ErrIncomplete || msg != nil || !bytes.Equal(rest, buf) {
+			t.Errorf("Expected ErrIncomplete with buf unconsumed, got %q, %x, %v", msg, rest, err)
+		}
+	})
+
+	t.Run("Max Length", func(t *testing.T) {
+		_, _, err := Deframe(MLI2E, []byte{0x00, 0x05, 'h', 'e'}, MaxLength(4))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge for an incomplete oversized frame, got %v", err)
+		}
+	})
+
+	t.Run("Invalid MLI", func(t *testing.T) {
+		_, _, err := Deframe(MLI2I, []byte{0x00, 0x01, 'a'})
+		if !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength, got %v", err)
+		}
+		_, _, err = Deframe("bad", []byte{0x00, 0x01})
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+	})
+}
+
+func TestReadFrame(t *testing.T) {
+	t.Run("Short Reads", func(t *testing.T) {
+		r := iotest.OneByteReader(bytes.NewReader([]byte{0x00, 0x07, 'h', 'e', 'l', 'l', 'o', 0x00, 0x02}))
+		msg, err := ReadFrame(r, MLI2I)
+		if err != nil || string(msg) != "hello" {
+			t.Errorf("Unexpected result from ReadFrame, got %q, %v", msg, err)
+		}
+		msg, err = ReadFrame(r, MLI2I)
+		if err != nil || len(msg) != 0 {
+			t.Errorf("Unexpected result reading an empty message, got %q, %v", msg, err)
+		}
+		_, err = ReadFrame(r, MLI2I)
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF at the end of the stream, got %v", err)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := ReadFrame(bytes.NewReader([]byte{0x00, 0x07, 'h'}), MLI2I)
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+		_, err = ReadFrame(bytes.NewReader([]byte{0x00}), MLI2I)
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF for a partial MLI, got %v", err)
+		}
+	})
+
+	t.Run("Max Length", func(t *testing.T) {
+		// The 4GB message is rejected from its MLI, before any allocation
+		r := bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 'a'})
+		_, err := ReadFrame(r, MLI4E, MaxLength(1024))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+		if r.Len() != 1 {
+			t.Errorf("Expected only the MLI to be read, %d bytes remain", r.Len())
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := ReadFrame(bytes.NewReader(nil), "bad")
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+	})
+}
+
+// limitWriter accepts at most limit bytes per call without reporting an error
+type limitWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > l.limit {
+		p = p[:l.limit]
+	}
+	return l.Buffer.Write(p)
+}
+
+func TestWriteFrame(t *testing.T) {
+	t.Run("Single Write", func(t *testing.T) {
+		lw := &limitWriter{limit: 1 << 10}
+		err := WriteFrame(lw, MLI2I, []byte("hello"))
+		if err != nil {
+			t.Fatalf("Unexpected error from WriteFrame - %s", err)
+		}
+		msg, err := ReadFrame(&lw.Buffer, MLI2I)
+		if err != nil || string(msg) != "hello" {
+			t.Errorf("Unexpected frame written, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Short Write", func(t *testing.T) {
+		lw := &limitWriter{limit: 3}
+		err := WriteFrame(lw, MLI2I, []byte("hello"))
+		var pe *PartialWriteError
+		if !errors.As(err, &pe) || pe.Written != 3 || pe.Total != 7 {
+			t.Fatalf("Expected PartialWriteError for 3 of 7 bytes, got %v", err)
+		}
+		if !errors.Is(err, io.ErrShortWrite) {
+			t.Errorf("Expected io.ErrShortWrite from a writer reporting no error, got %v", pe.Err)
+		}
+	})
+
+	t.Run("Write Error", func(t *testing.T) {
+		err := WriteFrame(&limitWriter{limit: 0}, MLI2I, []byte("hello"))
+		if err != io.ErrShortWrite {
+			t.Errorf("Expected io.ErrShortWrite when nothing was written, got %v", err)
+		}
+		_, w := io.Pipe()
+		_ = w.Close()
+		err = WriteFrame(w, MLI2I, []byte("hello"))
+		if err != io.ErrClosedPipe {
+			t.Errorf("Expected the writer error unchanged, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		err := WriteFrame(io.Discard, "bad", nil)
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+	})
+}
+
+func TestFrameString(t *testing.T) {
+	pan := "4111111111111111"
+	long := Frame{Key: MLI2I, MLI: []byte{0x00, 0x20}, Body: []byte("0100\xf2\x3c\x44\x81\x08\xe0\x80\x00" + pan + "99")}
+	short := Frame{Key: MLI2E, MLI: []byte{0x00, 0x02}, Body: []byte{0x08, 0x00}}
+
+	t.Run("String", func(t *testing.T) {
+		s := fmt.Sprintf("%v", long)
+		expected := "2I len=30 mli=0020 body=30313030f23c448108e08000..."
+		if s != expected {
+			t.Errorf("Unexpected summary, got %q expected %q", s, expected)
+		}
+		if s := short.String(); s != "2E len=2 mli=0002 body=0800" {
+			t.Errorf("Unexpected summary, got %q", s)
+		}
+	})
+
+	t.Run("GoString", func(t *testing.T) {
+		s := fmt.Sprintf("%#v", long)
+		if !strings.HasPrefix(s, `simplemli.Frame{Key:"2I", MLI:[]byte{0x0, 0x20}, Body:[]byte{0x30,`) {
+			t.Errorf("Unexpected Go syntax, got %s", s)
+		}
+		if !strings.HasSuffix(s, "/* 18 more bytes */}}") {
+			t.Errorf("Expected truncated body, got %s", s)
+		}
+		if s := fmt.Sprintf("%#v", Frame{}); s != `simplemli.Frame{Key:"", MLI:[]byte(nil), Body:[]byte(nil)}` {
+			t.Errorf("Unexpected Go syntax for empty frame, got %s", s)
+		}
+	})
+
+	t.Run("PAN Safe", func(t *testing.T) {
+		for _, s := range []string{long.String(), long.GoString()} {
+			if strings.Contains(s, fmt.Sprintf("%x", pan[:6])) || strings.Contains(s, pan[:6]) {
+				t.Errorf("Summary exposes card number, got %s", s)
+			}
+		}
+	})
+}
+
+func TestFrameClone(t *testing.T) {
+	f := Frame{Key: MLI2I, MLI: []byte{0x00, 0x05}, Body: []byte("abc"), Length: 3, Tag: "a"}
+	c := f.Clone()
+	f.MLI[1] = 0xff
+	f.Body[0] = 'x'
+	if c.MLI[1] != 0x05 || string(c.Body) != "abc" {
+		t.Errorf("Clone aliases the original frame, got %+v", c)
+	}
+	if c.Key != MLI2I || c.Length != 3 || c.Tag != "a" {
+		t.Errorf("Clone did not copy metadata, got %+v", c)
+	}
+	if cap(c.MLI) != len(c.MLI) {
+		t.Errorf("Appending to the cloned MLI would overwrite the body")
+	}
+	if e := (Frame{}).Clone(); e.MLI != nil || e.Body != nil {
+		t.Errorf("Clone of an empty frame should not allocate, got %+v", e)
+	}
+}
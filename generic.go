@@ -0,0 +1,63 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+)
+
+// Integer is the set of integer types accepted by EncodeLen and returned by DecodeLen.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// EncodeLen is Encode for any integer length type. The length is checked against the range the MLI type can
+// represent, lengths which are too large return ErrTooLarge and negative lengths return ErrLength, so callers holding
+// a uint16, uint32 or int64 length need no casts or manual range checks.
+//
+//	var n uint32 = 1500
+//	mli, err := simplemli.EncodeLen(simplemli.MLI4E, n)
+func EncodeLen[T Integer](key string, n T) ([]byte, error) {
+	minLen, maxLen, err := mliBounds(key)
+	if err != nil {
+		return empty, err
+	}
+	if n < 0 {
+		return empty, &LengthError{Key: key, Value: int64(n)}
+	}
+	if uint64(n) > uint64(maxLen) {
+		return empty, fmt.Errorf("%w - %d bytes exceeds %s limit of %d", ErrTooLarge, uint64(n), key, maxLen)
+	}
+	if int(n) < minLen {
+		return empty, &LengthError{Key: key, Value: int64(n)}
+	}
+	return Encode(key, int(n))
+}
+
+// DecodeLen is DecodeBytes returning the length as any integer type. Lengths which cannot be represented by T return
+// ErrLength rather than silently wrapping.
+//
+//	n, err := simplemli.DecodeLen[uint16](simplemli.MLI2E, mli)
+func DecodeLen[T Integer](key string, b []byte) (T, error) {
+	n, err := DecodeBytes(key, b)
+	if err != nil {
+		return 0, err
+	}
+	v := T(n)
+	if v < 0 || uint64(v) != uint64(n) {
+		return 0, &LengthError{Key: key, Value: int64(n)}
+	}
+	return v, nil
+}
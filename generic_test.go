@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncodeLen(t *testing.T) {
+	expected, _ := Encode(MLI2I, 1500)
+
+	t.Run("Integer Types", func(t *testing.T) {
+		results := [][]byte{}
+		for _, f := range []func() ([]byte, error){
+			func() ([]byte, error) { return EncodeLen(MLI2I, uint16(1500)) },
+			func() ([]byte, error) { return EncodeLen(MLI2I, uint32(1500)) },
+			func() ([]byte, error) { return EncodeLen(MLI2I, int64(1500)) },
+			func() ([]byte, error) { return EncodeLen(MLI2I, 1500) },
+		} {
+			b, err := f()
+			if err != nil {
+				t.Errorf("Unexpected error - %s", err)
+			}
+			results = append(results, b)
+		}
+		for _, b := range results {
+			if !bytes.Equal(b, expected) {
+				t.Errorf("Unexpected MLI, got %x expected %x", b, expected)
+			}
+		}
+	})
+
+	t.Run("Too Large", func(t *testing.T) {
+		_, err := EncodeLen(MLI2I, uint32(70000))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+		_, err = EncodeLen(MLIA4E, int64(10000))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+		_, err = EncodeLen(MLI4E, uint64(1)<<40)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Too Small", func(t *testing.T) {
+		_, err := EncodeLen(MLI2E, int8(-1))
+		if !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength, got %v", err)
+		}
+		_, err = EncodeLen(MLI2EE, uint8(1))
+		if !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength for 2EE length without header, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := EncodeLen("3Q", 1)
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+	})
+}
+
+func TestDecodeLen(t *testing.T) {
+	mli, _ := Encode(MLI2E, 300)
+
+	n16, err := DecodeLen[uint16](MLI2E, mli)
+	if err != nil || n16 != 300 {
+		t.Errorf("Unexpected result, got %d, %v", n16, err)
+	}
+	n64, err := DecodeLen[int64](MLI2E, mli)
+	if err != nil || n64 != 300 {
+		t.Errorf("Unexpected result, got %d, %v", n64, err)
+	}
+
+	_, err = DecodeLen[uint8](MLI2E, mli)
+	if !errors.Is(err, ErrLength) {
+		t.Errorf("Expected ErrLength decoding 300 into uint8, got %v", err)
+	}
+	_, err = DecodeLen[int8](MLI2E, mli)
+	if !errors.Is(err, ErrLength) {
+		t.Errorf("Expected ErrLength decoding 300 into int8, got %v", err)
+	}
+
+	_, err = DecodeLen[int](MLI2E, mli[:1])
+	if !errors.Is(err, ErrByteSize) {
+		t.Errorf("Expected ErrByteSize, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// ErrHandshake reports a capability frame which could not be parsed.
+var ErrHandshake = fmt.Errorf("invalid capability frame")
+
+// capabilityMagic identifies a capability frame, followed by a version byte, the 4-byte maximum message size and the
+// 4-byte heartbeat interval in milliseconds
+var capabilityMagic = []byte("MLCP")
+
+// capabilityVersion is the capability frame format version
+const capabilityVersion = 1
+
+// capabilityFrameSize is the size of a capability frame body
+const capabilityFrameSize = 13
+
+// Capabilities are the connection parameters exchanged by Handshake.
+type Capabilities struct {
+	// MaxMessageSize is the largest message a side will accept, zero for no limit
+	MaxMessageSize int
+
+	// HeartbeatInterval is how often a side sends heartbeats, zero for none
+	HeartbeatInterval time.Duration
+}
+
+// MarshalBinary encodes the capabilities as a capability frame body.
+func (c Capabilities) MarshalBinary() ([]byte, error) {
+	if c.MaxMessageSize < 0 || uint64(c.MaxMessageSize) > 0xffffffff {
+		return nil, fmt.Errorf("%w - maximum message size %d out of range", ErrHandshake, c.MaxMessageSize)
+	}
+	ms := c.HeartbeatInterval.Milliseconds()
+	if ms < 0 || ms > 0xffffffff {
+		return nil, fmt.Errorf("%w - heartbeat interval %s out of range", ErrHandshake, c.HeartbeatInterval)
+	}
+
+	b := make([]byte, capabilityFrameSize)
+	copy(b, capabilityMagic)
+	b[4] = capabilityVersion
+	binary.BigEndian.PutUint32(b[5:], uint32(c.MaxMessageSize))
+	binary.BigEndian.PutUint32(b[9:], uint32(ms))
+	return b, nil
+}
+
+// UnmarshalBinary decodes a capability frame body.
+func (c *Capabilities) UnmarshalBinary(b []byte) error {
+	if len(b) != capabilityFrameSize || !bytes.Equal(b[:4], capabilityMagic) {
+		return fmt.Errorf("%w - got %x", ErrHandshake, b)
+	}
+	if b[4] != capabilityVersion {
+		return fmt.Errorf("%w - unsupported version %d", ErrHandshake, b[4])
+	}
+	c.MaxMessageSize = capInt(uint64(binary.BigEndian.Uint32(b[5:])))
+	c.HeartbeatInterval = time.Duration(binary.BigEndian.Uint32(b[9:])) * time.Millisecond
+	return nil
+}
+
+// agree combines two sets of capabilities, taking the smaller limit where both sides set one
+func agree(a, b Capabilities) Capabilities {
+	smaller := func(x, y int64) int64 {
+		if x == 0 || (y != 0 && y < x) {
+			return y
+		}
+		return x
+	}
+	return Capabilities{
+		MaxMessageSize:    int(smaller(int64(a.MaxMessageSize), int64(b.MaxMessageSize))),
+		HeartbeatInterval: time.Duration(smaller(int64(a.HeartbeatInterval), int64(b.HeartbeatInterval))),
+	}
+}
+
+// Handshake exchanges capability frames with the peer and returns the agreed capabilities: the smaller of the two
+// maximum message sizes and heartbeat intervals, ignoring a side which sets none. Both sides must call Handshake as
+// the first exchange on the connection, before any other reads or writes.
+//
+// After a successful handshake the connection enforces the agreed maximum message size, inbound messages larger than
+// the limit return ErrTooLarge from ReadMessage and outbound messages return ErrTooLarge from WriteMessage without
+// being sent. A smaller limit set with WithMaxLength is kept. The agreed heartbeat interval is only returned, Handshake
+// does not send heartbeats, so the caller must send them at that interval. If the peer's capability frame cannot be
+// read the connection is closed, as the exchange can no longer complete.
+//
+//	caps, err := conn.Handshake(simplemli.Capabilities{MaxMessageSize: 8192, HeartbeatInterval: 30 * time.Second})
+//	if err != nil {
+//		// Do something
+//	}
+func (c *MessageConn) Handshake(local Capabilities) (Capabilities, error) {
+	b, err := local.MarshalBinary()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	// Write concurrently with the read so unbuffered transports cannot deadlock
+	werr := make(chan error, 1)
+	go func() {
+		werr <- c.WriteMessage(b)
+	}()
+
+	msg, err := c.ReadMessage()
+	if err != nil {
+		// The peer may never read our frame, so close the connection to release the write
+		_ = c.Close()
+		<-werr
+		return Capabilities{}, err
+	}
+	err = <-werr
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	var remote Capabilities
+	err = remote.UnmarshalBinary(msg)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	agreed := agree(local, remote)
+	c.r.opts.maxLength = lowerLimit(c.r.opts.maxLength, agreed.MaxMessageSize)
+	c.w.lowerMaxLength(agreed.MaxMessageSize)
+	return agreed, nil
+}
+
+// lowerLimit returns the smaller of the limits, where zero means no limit, so an agreed size never removes or raises a
+// configured limit
+func lowerLimit(limit, n int) int {
+	if n > 0 && (limit == 0 || n < limit) {
+		return n
+	}
+	return limit
+}
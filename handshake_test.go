@@ -0,0 +1,168 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHandshake(t *testing.T) {
+	t.Run("Agree", func(t *testing.T) {
+		a, b := net.Pipe()
+		client := NewMessageConn(a, MLI2I)
+		server := NewMessageConn(b, MLI2I)
+		defer client.Close()
+		defer server.Close()
+
+		type result struct {
+			caps Capabilities
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			caps, err := server.Handshake(Capabilities{MaxMessageSize: 8, HeartbeatInterval: 0})
+			done <- result{caps, err}
+		}()
+		caps, err := client.Handshake(Capabilities{MaxMessageSize: 16, HeartbeatInterval: 30 * time.Second})
+		if err != nil {
+			t.Fatalf("Unexpected error from handshake - %s", err)
+		}
+		r := <-done
+		if r.err != nil || r.caps != caps {
+			t.Fatalf("Sides disagree, got %+v, %v and %+v", r.caps, r.err, caps)
+		}
+		if caps.MaxMessageSize != 8 || caps.HeartbeatInterval != 30*time.Second {
+			t.Errorf("Unexpected agreed capabilities, got %+v", caps)
+		}
+
+		err = client.WriteMessage([]byte("too large"))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge writing beyond the agreed size, got %v", err)
+		}
+
+		go func() {
+			_ = client.WriteMessage([]byte("ok"))
+		}()
+		msg, err := server.ReadMessage()
+		if err != nil || string(msg) != "ok" {
+			t.Errorf("Unexpected message, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Configured Limit", func(t *testing.T) {
+		tc := map[string]struct {
+			local, remote int
+		}{
+			"No Limit":      {0, 0},
+			"Larger Limit":  {32, 64},
+			"Remote Larger": {0, 64},
+		}
+		for name, c := range tc {
+			t.Run(name, func(t *testing.T) {
+				a, b := net.Pipe()
+				client := NewMessageConn(a, MLI2I, WithMaxLength(20))
+				server := NewMessageConn(b, MLI2I)
+				defer client.Close()
+				defer server.Close()
+
+				done := make(chan error, 1)
+				go func() {
+					_, err := server.Handshake(Capabilities{MaxMessageSize: c.remote})
+					done <- err
+				}()
+				if _, err := client.Handshake(Capabilities{MaxMessageSize: c.local}); err != nil {
+					t.Fatalf("Unexpected error from handshake - %s", err)
+				}
+				if err := <-done; err != nil {
+					t.Fatalf("Unexpected error from peer handshake - %s", err)
+				}
+
+				// The configured limit of 20 bytes still applies to inbound messages
+				go func() {
+					_ = server.WriteMessage(make([]byte, 25))
+				}()
+				if _, err := client.ReadMessage(); !errors.Is(err, ErrTooLarge) {
+					t.Errorf("Expected ErrTooLarge reading beyond the configured limit, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("Invalid Frame", func(t *testing.T) {
+		a, b := net.Pipe()
+		client := NewMessageConn(a, MLI2I)
+		peer := NewMessageConn(b, MLI2I)
+		defer client.Close()
+		defer peer.Close()
+
+		go func() {
+			_, _ = peer.ReadMessage()
+			_ = peer.WriteMessage([]byte("0800"))
+		}()
+		_, err := client.Handshake(Capabilities{})
+		if !errors.Is(err, ErrHandshake) {
+			t.Errorf("Expected ErrHandshake, got %v", err)
+		}
+	})
+
+	t.Run("Read Fails", func(t *testing.T) {
+		a, b := net.Pipe()
+		client := NewMessageConn(a, MLI2I)
+		defer client.Close()
+		defer b.Close()
+
+		// The peer sends an invalid 2I MLI and never reads the client's capability frame
+		go func() {
+			_, _ = b.Write([]byte{0x00, 0x01})
+		}()
+		done := make(chan error, 1)
+		go func() {
+			_, err := client.Handshake(Capabilities{})
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("Expected error from Handshake - got nil")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Handshake blocked on its write after the read failed")
+		}
+	})
+
+	t.Run("Marshal", func(t *testing.T) {
+		c := Capabilities{MaxMessageSize: 65535, HeartbeatInterval: 1500 * time.Millisecond}
+		b, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Unexpected error marshaling - %s", err)
+		}
+		var got Capabilities
+		err = got.UnmarshalBinary(b)
+		if err != nil || got != c {
+			t.Errorf("Unexpected round trip, got %+v, %v", got, err)
+		}
+		b[4] = 2
+		if err := got.UnmarshalBinary(b); !errors.Is(err, ErrHandshake) {
+			t.Errorf("Expected ErrHandshake for unsupported version, got %v", err)
+		}
+		if _, err := (Capabilities{MaxMessageSize: -1}).MarshalBinary(); !errors.Is(err, ErrHandshake) {
+			t.Errorf("Expected ErrHandshake for negative size, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// EncodeHex is Encode returning the MLI as a lowercase hex string.
+//
+//	s, err := simplemli.EncodeHex(simplemli.MLI2I, 1500) // "05de"
+func EncodeHex(key string, length int) (string, error) {
+	b, err := Encode(key, length)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DecodeHex is DecodeBytes for an MLI given as a hex string. The string may have a 0x prefix and spaces between bytes,
+// as commonly found in hex dumps.
+//
+//	n, err := simplemli.DecodeHex(simplemli.MLI4E, "00 00 05 dc") // 1500
+func DecodeHex(key, hexStr string) (int, error) {
+	s := strings.Join(strings.Fields(hexStr), "")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex MLI %q - %w", hexStr, err)
+	}
+	return DecodeBytes(key, b)
+}
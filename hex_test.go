@@ -0,0 +1,67 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHex(t *testing.T) {
+	tc := []struct {
+		key string
+		hex string
+	}{
+		{MLI2I, "05de"},
+		{MLI2E, "05dc"},
+		{MLI4E, "000005dc"},
+		{MLI2BCD2, "00001504"},
+		{MLIA4E, "31353030"},
+	}
+
+	for _, c := range tc {
+		t.Run(c.key, func(t *testing.T) {
+			s, err := EncodeHex(c.key, 1500)
+			if err != nil || s != c.hex {
+				t.Errorf("Unexpected result from EncodeHex, got %q, %v expected %q", s, err, c.hex)
+			}
+			n, err := DecodeHex(c.key, c.hex)
+			if err != nil || n != 1500 {
+				t.Errorf("Unexpected result from DecodeHex, got %d, %v", n, err)
+			}
+		})
+	}
+
+	t.Run("Hex Dump Format", func(t *testing.T) {
+		n, err := DecodeHex(MLI4E, "0x00 00 05 DC")
+		if err != nil || n != 1500 {
+			t.Errorf("Unexpected result from DecodeHex, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := EncodeHex("3Q", 1)
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+		_, err = DecodeHex(MLI2I, "zz")
+		if err == nil {
+			t.Errorf("Expected error for invalid hex - got nil")
+		}
+		_, err = DecodeHex(MLI2I, "000005dc")
+		if !errors.Is(err, ErrByteSize) {
+			t.Errorf("Expected ErrByteSize, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+// Package framing contains the flag, usage and stream inspection helpers shared by the simplemli commands and test
+// helpers. Reading and writing frames is left to the public simplemli.ReadFrame, WriteFrame and Deframe.
+package framing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// Size returns the size in bytes of the MLI for the provided key.
+func Size(key string) (int, error) {
+	size, err := simplemli.Size(key)
+	if err != nil {
+		return 0, fmt.Errorf("invalid MLI type %q", key)
+	}
+	return size, nil
+}
+
+// Keys returns the supported MLI types, the built-in types followed by any registered codecs.
+func Keys() []string {
+	types := simplemli.Types()
+	keys := make([]string, len(types))
+	for i, t := range types {
+		keys[i] = t.Key
+	}
+	return keys
+}
+
+// TypeUsage returns the -type flag usage shared by the commands, listing every supported MLI type.
+func TypeUsage() string {
+	return "MLI type (" + strings.Join(Keys(), ", ") + ")"
+}
+
+// Bounds returns the smallest and largest message lengths representable by the MLI type key, as reported by
+// simplemli.Types. Lengths follow the conventions of simplemli.Encode, so the 2EE bounds include the 2-byte embedded
+// header.
+func Bounds(key string) (minLen, maxLen int, err error) {
+	for _, t := range simplemli.Types() {
+		if t.Key == key {
+			return t.MinLength, t.MaxLength, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid MLI type %q", key)
+}
+
+// Frame is a single MLI-framed message found within a buffer.
+type Frame struct {
+	// Offset of the MLI from the start of the buffer
+	Offset int
+
+	// MLI is the raw message length indicator
+	MLI []byte
+
+	// Body is the message following the MLI
+	Body []byte
+}
+
+// BreakError reports the offset at which a buffer stops being validly framed.
+type BreakError struct {
+	Offset int
+	Reason string
+}
+
+// Error returns a description of where and why framing broke.
+func (e *BreakError) Error() string {
+	return fmt.Sprintf("framing breaks at offset %d - %s", e.Offset, e.Reason)
+}
+
+// Split splits buf into MLI-framed messages of type key. Split returns every complete frame found before the first
+// framing inconsistency, which is reported as a *BreakError.
+func Split(buf []byte, key string) ([]Frame, error) {
+	size, err := Size(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []Frame
+	offset := 0
+	for offset < len(buf) {
+		body, rest, err := simplemli.Deframe(key, buf[offset:])
+		if err == simplemli.ErrIncomplete {
+			remaining := len(buf) - offset
+			if remaining < size {
+				return frames, &BreakError{Offset: offset, Reason: fmt.Sprintf("truncated MLI, %d of %d bytes", remaining, size)}
+			}
+			reason := fmt.Sprintf("truncated message, MLI %x declares more than the %d bytes remaining",
+				buf[offset:offset+size], remaining-size)
+			return frames, &BreakError{Offset: offset, Reason: reason}
+		}
+		if err != nil {
+			return frames, &BreakError{Offset: offset, Reason: fmt.Sprintf("invalid MLI %x - %s", buf[offset:offset+size], err)}
+		}
+
+		frames = append(frames, Frame{Offset: offset, MLI: buf[offset : offset+size], Body: body})
+		offset = len(buf) - len(rest)
+	}
+	return frames, nil
+}
+
+// Copy moves a single frame from src to dst, converting the MLI from srcKey to dstKey, and returns the message length.
+// Only the MLI passes through user space; the message is copied with io.CopyN so when src and dst are TCP connections
+// on Linux the kernel splices it between sockets without copying it into the process. The MLI and message are
+// written separately, so Copy must not be used where other goroutines write to dst.
+func Copy(dst io.Writer, src io.Reader, srcKey, dstKey string, maxLen int) (int, error) {
+	size, err := Size(srcKey)
+	if err != nil {
+		return 0, err
+	}
+
+	mli := make([]byte, size)
+	_, err = io.ReadFull(src, mli)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := simplemli.DecodeBytes(srcKey, mli, simplemli.MaxLength(maxLen))
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := simplemli.Encode(dstKey, n)
+	if err != nil {
+		return 0, err
+	}
+	_, err = dst.Write(out)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = io.CopyN(dst, src, int64(n))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
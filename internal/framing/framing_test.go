@@ -0,0 +1,143 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package framing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+type splitCase struct {
+	Name   string
+	Key    string
+	Input  string
+	Frames int
+	Break  int
+}
+
+func TestSplit(t *testing.T) {
+	sc := []splitCase{
+		{Name: "Complete 2E", Key: "2E", Input: "0001aa0002bbcc", Frames: 2, Break: -1},
+		{Name: "Empty", Key: "2E", Input: "", Frames: 0, Break: -1},
+		{Name: "Zero length 2I", Key: "2I", Input: "0000", Frames: 1, Break: -1},
+		{Name: "Truncated MLI", Key: "2E", Input: "0001aa00", Frames: 1, Break: 3},
+		{Name: "Truncated body", Key: "4E", Input: "00000001aa00000005bb", Frames: 1, Break: 5},
+		{Name: "Invalid MLI", Key: "2I", Input: "0001", Frames: 0, Break: 0},
+	}
+
+	for _, c := range sc {
+		t.Run(c.Name, func(t *testing.T) {
+			b, err := hex.DecodeString(c.Input)
+			if err != nil {
+				t.Fatalf("Unable to decode test input - %s", err)
+			}
+
+			frames, err := Split(b, c.Key)
+			if len(frames) != c.Frames {
+				t.Errorf("Unexpected number of frames, got %d expected %d", len(frames), c.Frames)
+			}
+
+			if c.Break < 0 {
+				if err != nil {
+					t.Errorf("Unexpected error splitting frames - %s", err)
+				}
+				return
+			}
+
+			be, ok := err.(*BreakError)
+			if !ok {
+				t.Fatalf("Expected *BreakError, got %v", err)
+			}
+			if be.Offset != c.Break {
+				t.Errorf("Unexpected break offset, got %d expected %d", be.Offset, c.Break)
+			}
+		})
+	}
+
+	t.Run("Invalid type", func(t *testing.T) {
+		_, err := Split([]byte{0x00}, "9Z")
+		if err == nil {
+			t.Errorf("Expected error splitting with invalid MLI type - got nil")
+		}
+	})
+}
+
+func TestBounds(t *testing.T) {
+	for _, key := range Keys() {
+		t.Run(key, func(t *testing.T) {
+			lo, hi, err := Bounds(key)
+			if err != nil {
+				t.Fatalf("Unexpected error from Bounds - %s", err)
+			}
+
+			for _, n := range []int{lo, hi} {
+				mli, err := simplemli.Encode(key, n)
+				if err != nil {
+					t.Fatalf("Unable to encode bound %d - %s", n, err)
+				}
+				got, err := simplemli.Decode(key, &mli)
+				if err != nil || got != n {
+					t.Errorf("Bound %d does not round trip, got %d, %v", n, got, err)
+				}
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, _, err := Bounds("9Z"); err == nil {
+			t.Errorf("Expected error for invalid MLI type - got nil")
+		}
+	})
+}
+
+func TestCopy(t *testing.T) {
+	src := []byte{0x00, 0x07, 'h', 'e', 'l', 'l', 'o', 0x00, 0x02}
+
+	t.Run("Reframes", func(t *testing.T) {
+		r := bytes.NewReader(src)
+		var dst bytes.Buffer
+		for _, expected := range []int{5, 0} {
+			n, err := Copy(&dst, r, "2I", "2BCD2", 0)
+			if err != nil || n != expected {
+				t.Errorf("Unexpected result from Copy, got %d, %v expected %d", n, err, expected)
+			}
+		}
+		if got := hex.EncodeToString(dst.Bytes()); got != "0000000968656c6c6f"+"00000004" {
+			t.Errorf("Unexpected reframed output, got %s", got)
+		}
+		_, err := Copy(&dst, r, "2I", "2BCD2", 0)
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		_, err := Copy(io.Discard, bytes.NewReader(src), "2I", "2E", 4)
+		if !errors.Is(err, simplemli.ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := Copy(io.Discard, bytes.NewReader(src[:4]), "2I", "2E", 0)
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+}
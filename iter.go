@@ -0,0 +1,36 @@
+//go:build go1.23
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"iter"
+)
+
+// AllTypes returns an iterator over the supported MLI types.
+//
+//	for t := range simplemli.AllTypes() {
+//		fmt.Println(t.Key, t.Description)
+//	}
+func AllTypes() iter.Seq[TypeInfo] {
+	return func(yield func(TypeInfo) bool) {
+		for _, t := range Types() {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+//go:build go1.23
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"testing"
+)
+
+func TestAllTypes(t *testing.T) {
+	var keys []string
+	for ti := range AllTypes() {
+		keys = append(keys, ti.Key)
+	}
+	if len(keys) != len(Types()) || keys[0] != MLI2I {
+		t.Errorf("Unexpected types from iterator, got %v", keys)
+	}
+
+	n := 0
+	for range AllTypes() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Iterator did not stop on break")
+	}
+}
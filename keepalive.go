@@ -0,0 +1,180 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrInvalidInterval reports a keepalive interval which is not greater than zero.
+var ErrInvalidInterval = fmt.Errorf("keepalive interval must be greater than zero")
+
+// KeepaliveWriter wraps an io.Writer and automatically writes a caller-supplied keepalive frame whenever no other
+// frame has been written for the configured interval. This keeps idle links from being dropped by firewalls and
+// switches that expire inactive sessions.
+//
+// Each call to Write must contain one or more complete frames (MLI and message), or the remainder of a frame which a
+// timed out Write left partly written. Writes and keepalives are serialized so a keepalive frame is never interleaved
+// with a partially written frame.
+//
+//	ka, err := simplemli.Encode(simplemli.MLI2I, 0) // zero-length MLI used as a keepalive
+//	if err != nil {
+//		// Do something
+//	}
+//
+//	w, err := simplemli.NewKeepaliveWriter(conn, ka, 30*time.Second)
+//	if err != nil {
+//		// Do something
+//	}
+//	defer w.Stop()
+type KeepaliveWriter struct {
+	mu        sync.Mutex
+	w         io.Writer
+	frame     []byte
+	interval  time.Duration
+	timer     *time.Timer
+	lastWrite time.Time
+	err       error
+	stopped   bool
+
+	// owed counts the bytes of a partly written frame still to be written, keepalives wait until it is complete
+	owed int
+
+	// rest holds the unsent part of a keepalive frame interrupted by a timeout, written before the next Write
+	rest []byte
+}
+
+// NewKeepaliveWriter returns a KeepaliveWriter which writes frame to w after each interval of write inactivity. The
+// keepalive frame must be a complete pre-encoded frame, such as a network management message or a zero-length MLI.
+// NewKeepaliveWriter returns ErrInvalidInterval if interval is not greater than zero.
+//
+// Keepalives are sent until Stop is called or a write to w fails with an error other than a timeout. A timeout is
+// returned to the caller but not kept, so the write can be retried once a new deadline is set.
+func NewKeepaliveWriter(w io.Writer, frame []byte, interval time.Duration) (*KeepaliveWriter, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	k := &KeepaliveWriter{
+		w:         w,
+		frame:     frame,
+		interval:  interval,
+		lastWrite: time.Now(),
+	}
+	k.mu.Lock()
+	k.timer = time.AfterFunc(interval, k.keepalive)
+	k.mu.Unlock()
+	return k, nil
+}
+
+// Write writes p to the underlying writer and resets the keepalive interval. If a previous write failed with an error
+// other than a timeout, Write returns that error without writing p.
+func (k *KeepaliveWriter) Write(p []byte) (int, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.err != nil {
+		return 0, k.err
+	}
+	if len(k.rest) > 0 {
+		n, err := k.w.Write(k.rest)
+		k.rest = k.rest[n:]
+		if err != nil {
+			return 0, k.fail(err)
+		}
+	}
+
+	n, err := k.w.Write(p)
+	if n < len(p) && k.owed == 0 {
+		k.owed = len(p) - n
+	} else {
+		k.owed -= n
+		if k.owed < 0 {
+			k.owed = 0
+		}
+	}
+	if err != nil {
+		return n, k.fail(err)
+	}
+
+	k.lastWrite = time.Now()
+	if !k.stopped {
+		k.timer.Reset(k.interval)
+	}
+	return n, nil
+}
+
+// fail keeps err unless it is a timeout, and returns it. The caller must hold the lock
+func (k *KeepaliveWriter) fail(err error) error {
+	if !isTimeout(err) {
+		k.err = err
+	}
+	return err
+}
+
+// Err returns the first error other than a timeout encountered while writing, including errors from keepalive frames.
+func (k *KeepaliveWriter) Err() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.err
+}
+
+// Stop stops sending keepalive frames. Stop does not close the underlying writer, and Write may still be used after
+// Stop is called.
+func (k *KeepaliveWriter) Stop() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.stopped = true
+	k.timer.Stop()
+}
+
+// keepalive is called by the timer to write the keepalive frame if the writer has been idle long enough.
+func (k *KeepaliveWriter) keepalive() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.stopped || k.err != nil {
+		return
+	}
+
+	// A Write may have raced with the timer firing, if so wait out the remainder of the interval
+	if idle := time.Since(k.lastWrite); idle < k.interval {
+		k.timer.Reset(k.interval - idle)
+		return
+	}
+
+	// A partly written frame or keepalive must be completed by the next Write before another keepalive is sent
+	if k.owed > 0 || len(k.rest) > 0 {
+		k.timer.Reset(k.interval)
+		return
+	}
+
+	n, err := k.w.Write(k.frame)
+	if err != nil {
+		if n > 0 {
+			k.rest = append([]byte{}, k.frame[n:]...)
+		}
+		if k.fail(err); k.err != nil {
+			return
+		}
+	} else {
+		k.lastWrite = time.Now()
+	}
+	k.timer.Reset(k.interval)
+}
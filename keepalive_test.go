@@ -0,0 +1,169 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer which is safe for concurrent use
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+	err error
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	if l.err != nil {
+		return 0, l.err
+	}
+	return l.buf.Write(p)
+}
+
+func (l *lockedBuffer) Bytes() []byte {
+	l.Lock()
+	defer l.Unlock()
+	return append([]byte{}, l.buf.Bytes()...)
+}
+
+func TestKeepaliveWriter(t *testing.T) {
+	ka, err := Encode(MLI2I, 0)
+	if err != nil {
+		t.Fatalf("Unable to encode keepalive frame - %s", err)
+	}
+	newWriter := func(t *testing.T, w io.Writer, d time.Duration) *KeepaliveWriter {
+		k, err := NewKeepaliveWriter(w, ka, d)
+		if err != nil {
+			t.Fatalf("Unexpected error creating keepalive writer - %s", err)
+		}
+		return k
+	}
+
+	t.Run("Sends keepalive when idle", func(t *testing.T) {
+		buf := &lockedBuffer{}
+		w := newWriter(t, buf, 10*time.Millisecond)
+		defer w.Stop()
+
+		time.Sleep(55 * time.Millisecond)
+		n := bytes.Count(buf.Bytes(), ka)
+		if n < 2 {
+			t.Errorf("Expected multiple keepalive frames after idle period, got %d", n)
+		}
+	})
+
+	t.Run("Writes pass through", func(t *testing.T) {
+		buf := &lockedBuffer{}
+		w := newWriter(t, buf, time.Hour)
+		defer w.Stop()
+
+		msg := []byte{0x00, 0x07, 'h', 'e', 'l', 'l', 'o'}
+		n, err := w.Write(msg)
+		if err != nil || n != len(msg) {
+			t.Errorf("Unexpected result from Write, got %d, %s", n, err)
+		}
+		if !bytes.Equal(buf.Bytes(), msg) {
+			t.Errorf("Unexpected bytes written, got %x expected %x", buf.Bytes(), msg)
+		}
+	})
+
+	t.Run("Stop halts keepalives", func(t *testing.T) {
+		buf := &lockedBuffer{}
+		w := newWriter(t, buf, 10*time.Millisecond)
+		w.Stop()
+
+		time.Sleep(30 * time.Millisecond)
+		if len(buf.Bytes()) != 0 {
+			t.Errorf("Unexpected keepalive written after Stop, got %x", buf.Bytes())
+		}
+	})
+
+	t.Run("Non-positive interval", func(t *testing.T) {
+		for _, d := range []time.Duration{0, -time.Second} {
+			_, err := NewKeepaliveWriter(&lockedBuffer{}, ka, d)
+			if err != ErrInvalidInterval {
+				t.Errorf("Expected ErrInvalidInterval for interval %s, got %v", d, err)
+			}
+		}
+	})
+
+	t.Run("Timeout is not kept", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I, WithKeepaliveFrame(ka, time.Hour), WithWriteTimeout(20*time.Millisecond))
+		defer conn.Close()
+		defer b.Close()
+
+		// The peer accepts the MLI and part of the message, then stops reading
+		head := make([]byte, 4)
+		go func() {
+			_, _ = io.ReadFull(b, head)
+		}()
+		err := conn.WriteMessage([]byte("08000000"))
+		var pe *PartialWriteError
+		if !errors.As(err, &pe) || pe.Written != 4 {
+			t.Fatalf("Expected PartialWriteError after 4 bytes, got %v", err)
+		}
+
+		rest := make([]byte, 6)
+		go func() {
+			_, _ = io.ReadFull(b, rest)
+		}()
+		if err := conn.Resume(); err != nil {
+			t.Errorf("Unexpected error resuming - %s", err)
+		}
+		if string(rest) != "000000" {
+			t.Errorf("Unexpected remainder of frame, got %q", rest)
+		}
+	})
+
+	t.Run("Writer option", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I, WithKeepaliveFrame(ka, 10*time.Millisecond))
+		peer := NewMessageConn(b, MLI2I)
+		defer peer.Close()
+
+		for i := 0; i < 2; i++ {
+			msg, err := peer.ReadMessage()
+			if err != nil || len(msg) != 0 {
+				t.Errorf("Expected keepalive frame, got %x, %v", msg, err)
+			}
+		}
+		_ = conn.Close()
+	})
+
+	t.Run("Keepalive error is reported", func(t *testing.T) {
+		buf := &lockedBuffer{err: fmt.Errorf("broken pipe")}
+		w := newWriter(t, buf, 5*time.Millisecond)
+		defer w.Stop()
+
+		time.Sleep(25 * time.Millisecond)
+		if w.Err() == nil {
+			t.Errorf("Expected error from failed keepalive - got nil")
+		}
+		_, err := w.Write([]byte{0x00, 0x02})
+		if err == nil {
+			t.Errorf("Expected Write to return keepalive error - got nil")
+		}
+	})
+}
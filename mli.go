@@ -24,6 +24,8 @@ This package provides an easy-to-use Encoder and Decoder for Message Length Indi
 Usage:
 
 	import (
+		"io"
+
 		"github.com/americanexpress/simplemli"
 	)
 
@@ -37,41 +39,46 @@ Usage:
 		}
 
 		// Append the MLI to the message
-		msg := append(mli, msg)
+		frame := append(mli, msg...)
 
 		// Write to TCP Connection
-		_, err = conn.Write(msg)
+		_, err = conn.Write(frame)
 
+		// Or encode and write the MLI and message with a single call
+		err = simplemli.WriteFrame(conn, simplemli.MLI2I, msg)
 
-		// Reading MLI from TCP Connection
+		// Reading MLI from TCP Connection, io.ReadFull handles short reads
 		b := make([]byte, simplemli.Size2I)
-		_, err = conn.Read(&b) // only read the MLI from buffer
+		_, err = io.ReadFull(conn, b) // only read the MLI from buffer
 		if err != nil {
 			// Do something
 		}
 
 		// Decoding Example
-		length, err := simplemli.Decode(simplemli.MLI2I, &b)
+		length, err := simplemli.DecodeBytes(simplemli.MLI2I, b)
 		if err != nil {
 			// Do something
 		}
 
 		// Reading Message from TCP Connection
-		msg := make([]byte, length)
-		_, err = conn.Read(&msg)
+		body := make([]byte, length)
+		_, err = io.ReadFull(conn, body)
+
+		// Or read the MLI and message with a single call
+		body, err = simplemli.ReadFrame(conn, simplemli.MLI2I)
 	}
 
 There are many common ways to encode message lengths and this library attempts to provide the most common MLI types.
+
+Building with the mlicore tag compiles only the encode/decode core, leaving out the streaming and network subsystems
+and their dependencies on net and bufio, for embedded and TinyGo builds.
 */
 package simplemli
 
 import (
-	"bytes"
 	"encoding/binary"
-	"encoding/hex"
 	"fmt"
-	"strconv"
-	"unsafe"
+	"math"
 )
 
 // empty is used as a quick return during errors
@@ -79,13 +86,32 @@ var empty = make([]byte, 0)
 
 // MLI Size in bytes
 const (
-	Size2I    = 2
-	Size2E    = 2
-	Size4I    = 4
-	Size4E    = 4
-	Size2EE   = 2
-	Size2BCD2 = 4
-	SizeA4E   = 4
+	Size2I     = 2
+	Size2E     = 2
+	Size4I     = 4
+	Size4E     = 4
+	Size2EE    = 2
+	Size2BCD2  = 4
+	SizeA4E    = 4
+	Size2IL    = 2
+	Size2EL    = 2
+	Size4IL    = 4
+	Size4EL    = 4
+	Size1I     = 1
+	Size1E     = 1
+	Size3I     = 3
+	Size3E     = 3
+	Size8I     = 8
+	Size8E     = 8
+	SizeA2E    = 2
+	SizeA6E    = 6
+	SizeA6I    = 6
+	SizeH4E    = 4
+	SizeE4E    = 4
+	SizeBCD2   = 2
+	SizeBCD4   = 4
+	SizeBCD4I  = 4
+	Size2BCD2I = 4
 )
 
 // Encoding/Decoding argument keys
@@ -105,153 +131,733 @@ const (
 	// 2-byte network byte order with MLI excluded, additional 2-byte header is included with message
 	MLI2EE = "2EE"
 
-	// 2-byte header with a 2-byte binary-coded decimal with MLI excluded
+	// 2-byte header with a 2-byte binary-coded decimal. The value counts the 4-byte MLI, so a 1500-byte message has a
+	// value of 1504, see MLI2BCD2I.
 	MLI2BCD2 = "2BCD2"
 
 	// 4-byte ASCII string with MLI excluded
 	MLIA4E = "A4E"
+
+	// 2-byte little-endian byte order with MLI included
+	MLI2IL = "2IL"
+
+	// 2-byte little-endian byte order with MLI excluded
+	MLI2EL = "2EL"
+
+	// 4-byte little-endian byte order with MLI included
+	MLI4IL = "4IL"
+
+	// 4-byte little-endian byte order with MLI excluded
+	MLI4EL = "4EL"
+
+	// 1-byte length with MLI included
+	MLI1I = "1I"
+
+	// 1-byte length with MLI excluded
+	MLI1E = "1E"
+
+	// 3-byte network byte order with MLI included
+	MLI3I = "3I"
+
+	// 3-byte network byte order with MLI excluded
+	MLI3E = "3E"
+
+	// 8-byte network byte order with MLI included
+	MLI8I = "8I"
+
+	// 8-byte network byte order with MLI excluded
+	MLI8E = "8E"
+
+	// 2-byte ASCII string with MLI excluded
+	MLIA2E = "A2E"
+
+	// 6-byte ASCII string with MLI excluded
+	MLIA6E = "A6E"
+
+	// 6-byte ASCII string with MLI included
+	MLIA6I = "A6I"
+
+	// 4-byte ASCII hexadecimal string with MLI excluded
+	MLIH4E = "H4E"
+
+	// 4-byte EBCDIC string with MLI excluded
+	MLIE4E = "E4E"
+
+	// 2-byte binary-coded decimal without a header, with MLI excluded
+	MLIBCD2 = "BCD2"
+
+	// 4-byte binary-coded decimal with MLI excluded
+	MLIBCD4 = "BCD4"
+
+	// 4-byte binary-coded decimal with MLI included
+	MLIBCD4I = "BCD4I"
+
+	// 2-byte header with a 2-byte binary-coded decimal with MLI included. 2BCD2I names the inclusive accounting
+	// explicitly and encodes identically to 2BCD2.
+	MLI2BCD2I = "2BCD2I"
 )
 
 // ErrByteSize reports an attempt to decode byte data that does not match the expected size for the desired MLI type.
 var ErrByteSize = fmt.Errorf("input bytes does not match expected size for selected mli type")
 
+// ErrInvalidType reports an MLI type key which is not supported.
+var ErrInvalidType = fmt.Errorf("Invalid MLI type provided")
+
+// ErrNotNumeric reports a decimal MLI, such as 2BCD2 or A4E, containing bytes which are not valid digits.
+var ErrNotNumeric = fmt.Errorf("mli is not numeric")
+
+// ErrInvalidBCD reports a binary-coded decimal MLI, such as 2BCD2, with a nibble outside of 0-9. It is returned within
+// a *NumericError, which also matches ErrNotNumeric.
+var ErrInvalidBCD = fmt.Errorf("mli is not valid binary-coded decimal")
+
+// ErrInvalidDigit reports an A4E MLI with a byte other than an ASCII digit. It is returned within a *NumericError,
+// which also matches ErrNotNumeric.
+var ErrInvalidDigit = fmt.Errorf("mli byte is not an ASCII digit")
+
+// ErrInvalidHex reports an H4E MLI with a byte other than an ASCII hexadecimal digit. It is returned within a
+// *NumericError, which also matches ErrNotNumeric.
+var ErrInvalidHex = fmt.Errorf("mli byte is not an ASCII hexadecimal digit")
+
+// ErrInvalidEBCDIC reports an E4E MLI with a byte other than an EBCDIC digit, 0xF0 to 0xF9. It is returned within a
+// *NumericError, which also matches ErrNotNumeric.
+var ErrInvalidEBCDIC = fmt.Errorf("mli byte is not an EBCDIC digit")
+
 // ErrLength reports an attempt to decode or encode data with an invalid length (i.e., negative numbers).
 var ErrLength = fmt.Errorf("invalid mli length provided")
 
-// Decode accepts a message length in bytes and decodes the value into an integer. The byte slice provided to Decode
-// must be the message length indicator itself and not include message headers or body. If the provided byte size does
-// not match the expected MLI size, Decode will return an error.
+// ErrTooLarge reports a message longer than the configured maximum length or the largest length supported by the
+// platform.
+var ErrTooLarge = fmt.Errorf("message exceeds maximum length")
+
+// Size returns the size in bytes of the MLI type key, for allocating a read buffer when the type is chosen from
+// configuration.
+//
+//	size, err := simplemli.Size(cfg.Key)
+//	if err != nil {
+//		// Do something
+//	}
+//	mli := make([]byte, size)
+func Size(key string) (int, error) {
+	if c, ok := builtin(key); ok {
+		return c.size, nil
+	}
+	if c, ok := registered(key); ok {
+		return c.Size(), nil
+	}
+	return 0, ErrInvalidType
+}
+
+// DecodeBytes accepts a message length in bytes and decodes the value into an integer. The byte slice provided to
+// DecodeBytes must be the message length indicator itself and not include message headers or body. If the provided
+// byte size does not match the expected MLI size, DecodeBytes will return an error. Options such as MaxLength add
+// checks on the decoded length.
 //
-// The return value provided by Decode will exclude the length of the MLI and provide the length of the message itself.
-// For example, a 2I MLI of 1502 will return 1500 when Decoded.
+// The return value provided by DecodeBytes will exclude the length of the MLI and provide the length of the message
+// itself. For example, a 2I MLI of 1502 will return 1500 when Decoded.
 //
-//	length, err := simplemli.Decode(simplemli.MLI2I, &b)
+//	length, err := simplemli.DecodeBytes(simplemli.MLI2I, buf[:simplemli.Size2I])
 //	if err != nil {
 //		// Do something
 //	}
 //
 // Note: 2EE Message Length Indicators are unique in that they contain a 2-byte header which is not accounted for in
 // the message length. When decoding a 2EE MLI of 1500, the return value will include the header length, 1502.
+func DecodeBytes(key string, b []byte, opts ...DecodeOption) (int, error) {
+	if len(opts) == 0 {
+		return decode(key, b)
+	}
+
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var n int
+	var err error
+	if size, included, set, ok := decimalType(key); ok && o.spacePadded {
+		n, err = parseDecimal(key, b, size, included, set, true)
+	} else {
+		n, err = decode(key, b)
+	}
+	if err != nil {
+		return n, err
+	}
+	if o.maxLength > 0 && n > o.maxLength {
+		return 0, fmt.Errorf("%w - got %d bytes, limit %d", ErrTooLarge, n, o.maxLength)
+	}
+	return n, nil
+}
+
+// DecodeOption configures the decoding of an MLI by DecodeBytes, Deframe, ReadFrame and SplitFunc.
+type DecodeOption func(*decodeOptions)
+
+// decodeOptions holds the settings applied by DecodeOption funcs
+type decodeOptions struct {
+	maxLength   int
+	spacePadded bool
+}
+
+// MaxLength rejects MLIs describing messages longer than n bytes with ErrTooLarge, zero disables the check. Readers
+// of untrusted peers should always set a limit, as a 4-byte MLI can describe a message of 4GB.
+//
+//	length, err := simplemli.DecodeBytes(simplemli.MLI4E, mli, simplemli.MaxLength(8192))
+func MaxLength(n int) DecodeOption {
+	return func(o *decodeOptions) {
+		o.maxLength = n
+	}
+}
+
+// SpacePadded accepts decimal MLIs, such as A4E, padded with leading spaces, such as "  42", which some hosts send in
+// place of leading zeros. EBCDIC MLIs are padded with the EBCDIC space, 0x40. Spaces after the first digit are still
+// rejected. The option has no effect on other MLI types.
+//
+//	length, err := simplemli.DecodeBytes(simplemli.MLIA4E, mli, simplemli.SpacePadded())
+func SpacePadded() DecodeOption {
+	return func(o *decodeOptions) {
+		o.spacePadded = true
+	}
+}
+
+// decode decodes the MLI for a built-in or registered type
+func decode(key string, b []byte) (int, error) {
+	if c, ok := builtin(key); ok {
+		return c.decode(b)
+	}
+
+	c, ok := registered(key)
+	if !ok {
+		return 0, ErrInvalidType
+	}
+	if len(b) != c.Size() {
+		return 0, &SizeError{Key: key, Expected: c.Size(), Got: len(b)}
+	}
+	return c.Decode(b)
+}
+
+// Decode is DecodeBytes taking a pointer to the MLI. The slice is never modified, so the pointer serves no purpose.
+//
+// Deprecated: Use DecodeBytes, which accepts sub-slices inline.
 func Decode(key string, b *[]byte) (int, error) {
-	switch key {
-	case MLI2I:
-		// Validate length vs. expected length
-		if len(*b) != Size2I {
-			return 0, ErrByteSize
-		}
+	return DecodeBytes(key, *b)
+}
 
-		// Convert to integer using Network Byte Order
-		n := int(binary.BigEndian.Uint16(*b))
-		// If 0 return right away
-		if n == 0 {
-			return 0, nil
-		}
+// The decode funcs implement DecodeBytes for each built-in type, the MLI must be exactly the type's size
 
-		// Remove MLI length and validate message length is valid
-		n = n - Size2I
-		if n < 0 {
-			return 0, ErrLength
-		}
-		return n, nil
+func decode2I(b []byte) (int, error) {
+	// Validate length vs. expected length
+	if len(b) != Size2I {
+		return 0, &SizeError{Key: MLI2I, Expected: Size2I, Got: len(b)}
+	}
 
-	case MLI2E:
-		// Validate length vs expected length
-		if len(*b) != Size2E {
-			return 0, ErrByteSize
-		}
+	// Convert to integer using Network Byte Order
+	n := int(binary.BigEndian.Uint16(b))
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
 
-		// Convert to integer using Network Byte Order
-		n := int(binary.BigEndian.Uint16(*b))
-		return n, nil
+	// Remove MLI length and validate message length is valid
+	n = n - Size2I
+	if n < 0 {
+		return 0, &LengthError{Key: MLI2I, Value: int64(n + Size2I)}
+	}
+	return n, nil
+}
 
-	case MLI4I:
-		// Validate length vs expected length
-		if len(*b) != Size4I {
-			return 0, ErrByteSize
-		}
+func decode2E(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size2E {
+		return 0, &SizeError{Key: MLI2E, Expected: Size2E, Got: len(b)}
+	}
 
-		// Convert to integer using Network Byte Order
-		n := int(binary.BigEndian.Uint32(*b))
-		// If 0 return right away
-		if n == 0 {
-			return 0, nil
-		}
+	// Convert to integer using Network Byte Order
+	n := int(binary.BigEndian.Uint16(b))
+	return n, nil
+}
 
-		// Remove MLI length and validate message length is valid
-		n = n - Size4I
-		if n < 0 {
-			return 0, ErrLength
-		}
-		return n, nil
+func decode4I(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size4I {
+		return 0, &SizeError{Key: MLI4I, Expected: Size4I, Got: len(b)}
+	}
 
-	case MLI4E:
-		// Validate length vs expected length
-		if len(*b) != Size4E {
-			return 0, ErrByteSize
-		}
+	// Convert using Network Byte Order, int may be 32 bits so the value is checked before conversion
+	n, err := decode4(MLI4I, binary.BigEndian.Uint32(b), Size4I)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) > math.MaxInt {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
 
-		// Convert to integer using Network Byte Order
-		n := int(binary.BigEndian.Uint32(*b))
-		return n, nil
+func decode4E(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size4E {
+		return 0, &SizeError{Key: MLI4E, Expected: Size4E, Got: len(b)}
+	}
 
-	case MLI2EE:
-		// Validate length vs expected length
-		if len(*b) != Size2EE {
-			return 0, ErrByteSize
+	// Convert using Network Byte Order, int may be 32 bits so the value is checked before conversion
+	n, err := decode4(MLI4E, binary.BigEndian.Uint32(b), 0)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) > math.MaxInt {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
+
+func decode2IL(b []byte) (int, error) {
+	// Validate length vs. expected length
+	if len(b) != Size2IL {
+		return 0, &SizeError{Key: MLI2IL, Expected: Size2IL, Got: len(b)}
+	}
+
+	// Convert to integer using little-endian byte order
+	n := int(binary.LittleEndian.Uint16(b))
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Remove MLI length and validate message length is valid
+	n = n - Size2IL
+	if n < 0 {
+		return 0, &LengthError{Key: MLI2IL, Value: int64(n + Size2IL)}
+	}
+	return n, nil
+}
+
+func decode2EL(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size2EL {
+		return 0, &SizeError{Key: MLI2EL, Expected: Size2EL, Got: len(b)}
+	}
+
+	// Convert to integer using little-endian byte order
+	n := int(binary.LittleEndian.Uint16(b))
+	return n, nil
+}
+
+func decode4IL(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size4IL {
+		return 0, &SizeError{Key: MLI4IL, Expected: Size4IL, Got: len(b)}
+	}
+
+	// Convert using little-endian byte order, int may be 32 bits so the value is checked before conversion
+	n, err := decode4(MLI4IL, binary.LittleEndian.Uint32(b), Size4IL)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) > math.MaxInt {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
+
+func decode4EL(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size4EL {
+		return 0, &SizeError{Key: MLI4EL, Expected: Size4EL, Got: len(b)}
+	}
+
+	// Convert using little-endian byte order, int may be 32 bits so the value is checked before conversion
+	n, err := decode4(MLI4EL, binary.LittleEndian.Uint32(b), 0)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) > math.MaxInt {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
+
+func decode1I(b []byte) (int, error) {
+	// Validate length vs. expected length
+	if len(b) != Size1I {
+		return 0, &SizeError{Key: MLI1I, Expected: Size1I, Got: len(b)}
+	}
+
+	n := int(b[0])
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Remove MLI length, a single byte MLI of 1 describes an empty message
+	return n - Size1I, nil
+}
+
+func decode1E(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size1E {
+		return 0, &SizeError{Key: MLI1E, Expected: Size1E, Got: len(b)}
+	}
+	return int(b[0]), nil
+}
+
+func decode3I(b []byte) (int, error) {
+	// Validate length vs. expected length
+	if len(b) != Size3I {
+		return 0, &SizeError{Key: MLI3I, Expected: Size3I, Got: len(b)}
+	}
+
+	// Convert to integer using Network Byte Order
+	n := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Remove MLI length and validate message length is valid
+	n = n - Size3I
+	if n < 0 {
+		return 0, &LengthError{Key: MLI3I, Value: int64(n + Size3I)}
+	}
+	return n, nil
+}
+
+func decode3E(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size3E {
+		return 0, &SizeError{Key: MLI3E, Expected: Size3E, Got: len(b)}
+	}
+
+	// Convert to integer using Network Byte Order
+	n := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	return n, nil
+}
+
+func decode8I(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size8I {
+		return 0, &SizeError{Key: MLI8I, Expected: Size8I, Got: len(b)}
+	}
+
+	// Convert using Network Byte Order, the value is checked before conversion as it may exceed int
+	n, err := decode8(MLI8I, b, Size8I)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
+
+func decode8E(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size8E {
+		return 0, &SizeError{Key: MLI8E, Expected: Size8E, Got: len(b)}
+	}
+
+	// Convert using Network Byte Order, the value is checked before conversion as it may exceed int
+	n, err := decode8(MLI8E, b, 0)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt {
+		return 0, ErrTooLarge
+	}
+	return int(n), nil
+}
+
+func decode2EE(b []byte) (int, error) {
+	return decodeEmbedded(MLI2EE, b, 2) // add 2-byte header length
+}
+
+// decodeEmbedded decodes a 2-byte network byte order MLI which excludes an embedded header of header bytes, reporting
+// errors against the MLI type key
+func decodeEmbedded(key string, b []byte, header int) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size2EE {
+		return 0, &SizeError{Key: key, Expected: Size2EE, Got: len(b)}
+	}
+
+	// Convert to integer using Network Byte Order
+	n := int(binary.BigEndian.Uint16(b)) + header // add embedded header length
+	return n, nil
+}
+
+func decode2BCD2(b []byte) (int, error) {
+	return decodeHeaderBCD(MLI2BCD2, b)
+}
+
+func decode2BCD2I(b []byte) (int, error) {
+	return decodeHeaderBCD(MLI2BCD2I, b)
+}
+
+// decodeHeaderBCD decodes a 2-byte header followed by a 2-byte Binary-Coded Decimal value which counts the MLI
+func decodeHeaderBCD(key string, b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != Size2BCD2 {
+		return 0, &SizeError{Key: key, Expected: Size2BCD2, Got: len(b)}
+	}
+
+	// Convert from Binary-Coded Decimal after the 2-byte header
+	n, err := parseBCD(key, b, 2)
+	if err != nil {
+		return 0, err
+	}
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Remove MLI length and validate message length is valid
+	n = n - Size2BCD2
+	if n < 0 {
+		return 0, &LengthError{Key: key, Value: int64(n + Size2BCD2)}
+	}
+	return n, nil
+}
+
+func decodeBCD2(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != SizeBCD2 {
+		return 0, &SizeError{Key: MLIBCD2, Expected: SizeBCD2, Got: len(b)}
+	}
+	return parseBCD(MLIBCD2, b, 0)
+}
+
+func decodeBCD4(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != SizeBCD4 {
+		return 0, &SizeError{Key: MLIBCD4, Expected: SizeBCD4, Got: len(b)}
+	}
+	return parseBCD(MLIBCD4, b, 0)
+}
+
+func decodeBCD4I(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != SizeBCD4I {
+		return 0, &SizeError{Key: MLIBCD4I, Expected: SizeBCD4I, Got: len(b)}
+	}
+
+	n, err := parseBCD(MLIBCD4I, b, 0)
+	if err != nil {
+		return 0, err
+	}
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Remove MLI length and validate message length is valid
+	n = n - SizeBCD4I
+	if n < 0 {
+		return 0, &LengthError{Key: MLIBCD4I, Value: int64(n + SizeBCD4I)}
+	}
+	return n, nil
+}
+
+// parseBCD decodes the Binary-Coded Decimal digits following header bytes of the MLI b. Every nibble must be a digit
+// as A-F would misframe the rest of the stream.
+func parseBCD(key string, b []byte, header int) (int, error) {
+	n := 0
+	for i, c := range b[header:] {
+		hi, lo := c>>4, c&0x0f
+		if hi > 9 || lo > 9 {
+			return 0, &NumericError{Key: key, MLI: append([]byte(nil), b...), Offset: header + i, Err: ErrInvalidBCD}
 		}
+		n = n*100 + int(hi)*10 + int(lo)
+	}
+	return n, nil
+}
 
-		// Convert to integer using Network Byte Order
-		n := int(binary.BigEndian.Uint16(*b)) + 2 // add 2-byte header length
-		return n, nil
+func decodeA4E(b []byte) (int, error) {
+	return parseDecimal(MLIA4E, b, SizeA4E, 0, asciiDigits, false)
+}
+
+func decodeA2E(b []byte) (int, error) {
+	return parseDecimal(MLIA2E, b, SizeA2E, 0, asciiDigits, false)
+}
+
+func decodeA6E(b []byte) (int, error) {
+	return parseDecimal(MLIA6E, b, SizeA6E, 0, asciiDigits, false)
+}
 
-	case MLI2BCD2:
-		// Validate length vs expected length
-		if len(*b) != Size2BCD2 {
-			return 0, ErrByteSize
+func decodeA6I(b []byte) (int, error) {
+	return parseDecimal(MLIA6I, b, SizeA6I, SizeA6I, asciiDigits, false)
+}
+
+func decodeH4E(b []byte) (int, error) {
+	// Validate length vs expected length
+	if len(b) != SizeH4E {
+		return 0, &SizeError{Key: MLIH4E, Expected: SizeH4E, Got: len(b)}
+	}
+
+	// Convert to integer from ASCII hexadecimal, upper and lowercase digits are accepted
+	n := 0
+	for i, c := range b {
+		var v byte
+		switch {
+		case c >= '0' && c <= '9':
+			v = c - '0'
+		case c >= 'A' && c <= 'F':
+			v = c - 'A' + 10
+		case c >= 'a' && c <= 'f':
+			v = c - 'a' + 10
+		default:
+			return 0, &NumericError{Key: MLIH4E, MLI: append([]byte(nil), b...), Offset: i, Err: ErrInvalidHex}
 		}
+		n = n<<4 | int(v)
+	}
+	return n, nil
+}
 
-		// Convert from hex to integer using Binary-Coded Decimal
-		n, err := strconv.Atoi(hex.EncodeToString((*b)[2:4]))
-		if err != nil {
-			return 0, fmt.Errorf("could not convert hex string to integer - %s", err)
+func decodeE4E(b []byte) (int, error) {
+	return parseDecimal(MLIE4E, b, SizeE4E, 0, ebcdicDigits, false)
+}
+
+// decimalType returns the size, included MLI length and character set of a decimal MLI type
+func decimalType(key string) (size, included int, set digits, ok bool) {
+	switch key {
+	case MLIA4E:
+		return SizeA4E, 0, asciiDigits, true
+	case MLIA2E:
+		return SizeA2E, 0, asciiDigits, true
+	case MLIA6E:
+		return SizeA6E, 0, asciiDigits, true
+	case MLIA6I:
+		return SizeA6I, SizeA6I, asciiDigits, true
+	case MLIE4E:
+		return SizeE4E, 0, ebcdicDigits, true
+	}
+	return 0, 0, digits{}, false
+}
+
+// digits describes the character set of a decimal MLI
+type digits struct {
+	zero  byte
+	space byte
+	err   error
+}
+
+// Character sets of the decimal MLI types
+var (
+	asciiDigits  = digits{zero: '0', space: ' ', err: ErrInvalidDigit}
+	ebcdicDigits = digits{zero: 0xf0, space: 0x40, err: ErrInvalidEBCDIC}
+)
+
+// parseDecimal decodes a decimal MLI of size bytes, every byte must be a digit of the character set or, when padded
+// is set, a leading space. The included MLI length is removed from the result.
+func parseDecimal(key string, b []byte, size, included int, set digits, padded bool) (int, error) {
+	// Validate length vs expected length
+	if len(b) != size {
+		return 0, &SizeError{Key: key, Expected: size, Got: len(b)}
+	}
+
+	// Convert to integer from the character set, digits are checked directly as strconv accepts signs
+	n, leading := 0, padded
+	for i, c := range b {
+		if leading && c == set.space {
+			continue
 		}
-		// If 0 return right away
-		if n == 0 {
-			return 0, nil
+		leading = false
+		if c < set.zero || c > set.zero+9 {
+			return 0, &NumericError{Key: key, MLI: append([]byte(nil), b...), Offset: i, Err: set.err}
 		}
+		n = n*10 + int(c-set.zero)
+	}
+	// If 0 return right away
+	if n == 0 || included == 0 {
+		return n, nil
+	}
 
-		// Remove MLI length and validate message length is valid
-		n = n - Size2BCD2
-		if n < 0 {
-			return 0, ErrLength
+	// Remove MLI length and validate message length is valid
+	n = n - included
+	if n < 0 {
+		return 0, &LengthError{Key: key, Value: int64(n + included)}
+	}
+	return n, nil
+}
+
+// Decode64 is DecodeBytes returning the length as an int64. On 32-bit platforms DecodeBytes returns ErrTooLarge for
+// 4-byte MLIs above 2^31-1, Decode64 returns every 4-byte length exactly on all platforms.
+//
+//	length, err := simplemli.Decode64(simplemli.MLI4E, b[:simplemli.Size4E])
+func Decode64(key string, b []byte) (int64, error) {
+	switch key {
+	case MLI4I:
+		if len(b) != Size4I {
+			return 0, &SizeError{Key: MLI4I, Expected: Size4I, Got: len(b)}
 		}
-		return n, nil
+		return decode4(MLI4I, binary.BigEndian.Uint32(b), Size4I)
 
-	case MLIA4E:
-		// Validate length vs expected length
-		if len(*b) != SizeA4E {
-			return 0, ErrByteSize
+	case MLI4E:
+		if len(b) != Size4E {
+			return 0, &SizeError{Key: MLI4E, Expected: Size4E, Got: len(b)}
+		}
+		return decode4(MLI4E, binary.BigEndian.Uint32(b), 0)
+
+	case MLI4IL:
+		if len(b) != Size4IL {
+			return 0, &SizeError{Key: MLI4IL, Expected: Size4IL, Got: len(b)}
 		}
+		return decode4(MLI4IL, binary.LittleEndian.Uint32(b), Size4IL)
 
-		// Check for edge case of 0 in hex format
-		if bytes.Count(*b, []byte{'0'}) == len(*b) {
-			return 0, nil
+	case MLI4EL:
+		if len(b) != Size4EL {
+			return 0, &SizeError{Key: MLI4EL, Expected: Size4EL, Got: len(b)}
 		}
+		return decode4(MLI4EL, binary.LittleEndian.Uint32(b), 0)
 
-		// Convert to integer from ASCII
-		n, err := strconv.Atoi(unsafeByteToStr(*b))
+	case MLI8I, MLI8E:
+		c, _ := builtin(key)
+		if len(b) != c.size {
+			return 0, &SizeError{Key: key, Expected: c.size, Got: len(b)}
+		}
+		var included uint64
+		if key == MLI8I {
+			included = Size8I
+		}
+		n, err := decode8(key, b, included)
 		if err != nil {
-			return 0, fmt.Errorf("unable to convert string values to integer - %s", err)
+			return 0, err
 		}
-		return n, nil
+		if n > math.MaxInt64 {
+			return 0, ErrTooLarge
+		}
+		return int64(n), nil
 
 	default:
-		return 0, fmt.Errorf("Invalid MLI type provided")
+		n, err := DecodeBytes(key, b)
+		return int64(n), err
 	}
 }
 
-func unsafeByteToStr(b []byte) string {
-	return *(*string)(unsafe.Pointer(&b))
+// decode8 decodes an 8-byte network byte order MLI, removing the included MLI size
+func decode8(key string, b []byte, included uint64) (uint64, error) {
+	n := binary.BigEndian.Uint64(b)
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Validate message length is valid and remove MLI length
+	if n < included {
+		return 0, &LengthError{Key: key, Value: int64(n)}
+	}
+	return n - included, nil
+}
+
+// decode4 decodes the value of a 4-byte MLI, removing the included MLI size
+func decode4(key string, v uint32, included int64) (int64, error) {
+	n := int64(v)
+	// If 0 return right away
+	if n == 0 {
+		return 0, nil
+	}
+
+	// Remove MLI length and validate message length is valid
+	n = n - included
+	if n < 0 {
+		return 0, &LengthError{Key: key, Value: n + included}
+	}
+	return n, nil
 }
 
 // Encode will accept a message length type and message length value desired. Encode will return a byte slice which
@@ -268,62 +874,372 @@ func unsafeByteToStr(b []byte) string {
 // Note: 2EE Message Length Indicators are unique in that the messages should include a 2-byte embedded header which is
 // not accounted for in the MLI. When encoding a 2EE MLI, users should include the embedded header in the length value.
 // For example, a message of 1500 bytes, with a 2-byte embedded header will have a 2EE MLI value of 1500.
+//
+// Lengths the MLI type cannot represent, such as 70000 for a 2I MLI, return a *RangeError matching ErrTooLarge rather
+// than a silently truncated MLI.
 func Encode(key string, length int) ([]byte, error) {
 	// Reject negative values
 	if length < 0 {
-		return empty, ErrLength
+		return empty, &LengthError{Key: key, Value: int64(length)}
 	}
 
-	switch key {
-	case MLI2I:
-		// Create MLI in Network Byte Order
-		b := make([]byte, Size2I)
-		binary.BigEndian.PutUint16(b, uint16(length+Size2I)) // include mli size
-		return b, nil
-
-	case MLI2E:
-		// Create MLI in Network Byte Order
-		b := make([]byte, Size2E)
-		binary.BigEndian.PutUint16(b, uint16(length))
-		return b, nil
+	var b []byte
+	var err error
+	if c, ok := builtin(key); ok {
+		b, err = c.append(make([]byte, 0, c.size), length)
+	} else {
+		c, ok := registered(key)
+		if !ok {
+			return empty, ErrInvalidType
+		}
+		b = make([]byte, c.Size())
+		err = c.Encode(length, b)
+	}
+	if err != nil {
+		return empty, err
+	}
+	return b, nil
+}
 
-	case MLI4I:
-		// Create MLI in Network Byte Order
-		b := make([]byte, Size4I)
-		binary.BigEndian.PutUint32(b, uint32(length+Size4I)) // include mli size
-		return b, nil
+// EncodeAppend is Encode appending the MLI to dst and returning the extended slice, so an MLI and message can be built
+// in one pre-sized buffer without allocating. On error dst is returned unchanged.
+//
+//	buf = buf[:0]
+//	buf, err = simplemli.EncodeAppend(buf, simplemli.MLI2I, len(msg))
+//	if err != nil {
+//		// Do something
+//	}
+//	buf = append(buf, msg...)
+func EncodeAppend(dst []byte, key string, length int) ([]byte, error) {
+	// Reject negative values
+	if length < 0 {
+		return dst, &LengthError{Key: key, Value: int64(length)}
+	}
 
-	case MLI4E:
-		// Create MLI in Network Byte Order
-		b := make([]byte, Size4E)
-		binary.BigEndian.PutUint32(b, uint32(length))
+	if c, ok := builtin(key); ok {
+		b, err := c.append(dst, length)
+		if err != nil {
+			return dst, err
+		}
 		return b, nil
+	}
 
-	case MLI2EE:
-		// Create MLI in Network Byte Order
-		b := make([]byte, Size2EE)
-		binary.BigEndian.PutUint16(b, uint16(length-Size2EE)) // remove embedded 2-byte header length
-		return b, nil
+	c, ok := registered(key)
+	if !ok {
+		return dst, ErrInvalidType
+	}
+	n := len(dst)
+	b := append(dst, make([]byte, c.Size())...)
+	if err := c.Encode(length, b[n:]); err != nil {
+		return dst, err
+	}
+	return b, nil
+}
 
-	case MLI2BCD2:
-		// Create MLI in Binary-Coded Decimal
-		h, err := hex.DecodeString(fmt.Sprintf("%04d", length+Size2BCD2)) // %04d is binary-coded decimal format, wrap in hex
-		if err != nil {
-			return empty, fmt.Errorf("unable to convert length to hex binary-coded decimal - %s", err)
+// EncodeInto is Encode writing the MLI into buf[:Size] and returning the number of bytes written, for callers keeping
+// a fixed scratch buffer. buf shorter than the MLI returns ErrByteSize.
+//
+//	n, err := simplemli.EncodeInto(simplemli.MLI2I, len(msg), scratch)
+//	if err != nil {
+//		// Do something
+//	}
+//	_, err = conn.Write(scratch[:n])
+func EncodeInto(key string, length int, buf []byte) (int, error) {
+	if c, ok := builtin(key); ok {
+		if err := c.Encode(length, buf); err != nil {
+			return 0, err
 		}
-		// Create empty 2-byte header
-		b := make([]byte, 2)
-		b = append(b, h...)
-		return b, nil
+		return c.size, nil
+	}
 
-	case MLIA4E:
-		// Create MLI in Hex-ASCII format
-		s := fmt.Sprintf("%04d", length)
-		s = fmt.Sprintf("%X", s)
-		b, _ := hex.DecodeString(s)
-		return b, nil
+	c, ok := registered(key)
+	if !ok {
+		return 0, ErrInvalidType
+	}
+	if length < 0 {
+		return 0, &LengthError{Key: key, Value: int64(length)}
+	}
+	if err := c.Encode(length, buf); err != nil {
+		return 0, err
+	}
+	return c.Size(), nil
+}
 
-	default:
-		return empty, fmt.Errorf("Invalid MLI type provided")
+// The append funcs append the MLI for a non-negative length to dst, callers are responsible for rejecting negative
+// lengths
+
+func append2I(dst []byte, length int) ([]byte, error) {
+	if length > math.MaxUint16-Size2I {
+		return dst, &RangeError{Key: MLI2I, Length: length, Max: math.MaxUint16 - Size2I}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint16(dst, uint16(length+Size2I)), nil // include mli size
+}
+
+func append2E(dst []byte, length int) ([]byte, error) {
+	if length > math.MaxUint16 {
+		return dst, &RangeError{Key: MLI2E, Length: length, Max: math.MaxUint16}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint16(dst, uint16(length)), nil
+}
+
+func append4I(dst []byte, length int) ([]byte, error) {
+	// int may be 64 bits, compare as uint64 so the check compiles on 32-bit platforms
+	if uint64(length) > math.MaxUint32-Size4I {
+		return dst, &RangeError{Key: MLI4I, Length: length, Max: capInt(math.MaxUint32 - Size4I)}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint32(dst, uint32(length+Size4I)), nil // include mli size
+}
+
+func append4E(dst []byte, length int) ([]byte, error) {
+	if uint64(length) > math.MaxUint32 {
+		return dst, &RangeError{Key: MLI4E, Length: length, Max: capInt(math.MaxUint32)}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint32(dst, uint32(length)), nil
+}
+
+func append2EE(dst []byte, length int) ([]byte, error) {
+	return appendEmbedded(dst, MLI2EE, length, 2) // remove embedded 2-byte header length
+}
+
+// appendEmbedded appends a 2-byte network byte order MLI which excludes an embedded header of header bytes, reporting
+// errors against the MLI type key
+func appendEmbedded(dst []byte, key string, length, header int) ([]byte, error) {
+	// The length includes the embedded header, so it must be at least the header size
+	if length < header {
+		return dst, &LengthError{Key: key, Value: int64(length)}
+	}
+	if length > math.MaxUint16+header {
+		return dst, &RangeError{Key: key, Length: length, Max: math.MaxUint16 + header}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint16(dst, uint16(length-header)), nil // remove embedded header length
+}
+
+func append2IL(dst []byte, length int) ([]byte, error) {
+	if length > math.MaxUint16-Size2IL {
+		return dst, &RangeError{Key: MLI2IL, Length: length, Max: math.MaxUint16 - Size2IL}
+	}
+	// Create MLI in little-endian byte order
+	return appendUint16LE(dst, uint16(length+Size2IL)), nil // include mli size
+}
+
+func append2EL(dst []byte, length int) ([]byte, error) {
+	if length > math.MaxUint16 {
+		return dst, &RangeError{Key: MLI2EL, Length: length, Max: math.MaxUint16}
+	}
+	// Create MLI in little-endian byte order
+	return appendUint16LE(dst, uint16(length)), nil
+}
+
+func append4IL(dst []byte, length int) ([]byte, error) {
+	// int may be 64 bits, compare as uint64 so the check compiles on 32-bit platforms
+	if uint64(length) > math.MaxUint32-Size4IL {
+		return dst, &RangeError{Key: MLI4IL, Length: length, Max: capInt(math.MaxUint32 - Size4IL)}
+	}
+	// Create MLI in little-endian byte order
+	return appendUint32LE(dst, uint32(length+Size4IL)), nil // include mli size
+}
+
+func append4EL(dst []byte, length int) ([]byte, error) {
+	if uint64(length) > math.MaxUint32 {
+		return dst, &RangeError{Key: MLI4EL, Length: length, Max: capInt(math.MaxUint32)}
+	}
+	// Create MLI in little-endian byte order
+	return appendUint32LE(dst, uint32(length)), nil
+}
+
+func append1I(dst []byte, length int) ([]byte, error) {
+	if length > math.MaxUint8-Size1I {
+		return dst, &RangeError{Key: MLI1I, Length: length, Max: math.MaxUint8 - Size1I}
+	}
+	return append(dst, byte(length+Size1I)), nil // include mli size
+}
+
+func append1E(dst []byte, length int) ([]byte, error) {
+	if length > math.MaxUint8 {
+		return dst, &RangeError{Key: MLI1E, Length: length, Max: math.MaxUint8}
+	}
+	return append(dst, byte(length)), nil
+}
+
+func append3I(dst []byte, length int) ([]byte, error) {
+	if length > maxUint24-Size3I {
+		return dst, &RangeError{Key: MLI3I, Length: length, Max: maxUint24 - Size3I}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint24(dst, uint32(length+Size3I)), nil // include mli size
+}
+
+func append3E(dst []byte, length int) ([]byte, error) {
+	if length > maxUint24 {
+		return dst, &RangeError{Key: MLI3E, Length: length, Max: maxUint24}
+	}
+	// Create MLI in Network Byte Order
+	return appendUint24(dst, uint32(length)), nil
+}
+
+func append8I(dst []byte, length int) ([]byte, error) {
+	// Every int fits in 8 bytes, including the MLI size
+	return appendUint64(dst, uint64(length)+Size8I), nil // include mli size
+}
+
+func append8E(dst []byte, length int) ([]byte, error) {
+	// Create MLI in Network Byte Order
+	return appendUint64(dst, uint64(length)), nil
+}
+
+// appendUint16 appends v in Network Byte Order
+func appendUint16(dst []byte, v uint16) []byte {
+	return append(dst, byte(v>>8), byte(v))
+}
+
+// appendUint64 appends v in Network Byte Order
+func appendUint64(dst []byte, v uint64) []byte {
+	return append(dst, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// maxUint24 is the largest value of a 3-byte MLI
+const maxUint24 = 1<<24 - 1
+
+// appendUint24 appends the low 3 bytes of v in Network Byte Order
+func appendUint24(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendUint32 appends v in Network Byte Order
+func appendUint32(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendUint16LE appends v in little-endian byte order
+func appendUint16LE(dst []byte, v uint16) []byte {
+	return append(dst, byte(v), byte(v>>8))
+}
+
+// appendUint32LE appends v in little-endian byte order
+func appendUint32LE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func append2BCD2(dst []byte, length int) ([]byte, error) {
+	return appendHeaderBCD(dst, MLI2BCD2, length)
+}
+
+func append2BCD2I(dst []byte, length int) ([]byte, error) {
+	return appendHeaderBCD(dst, MLI2BCD2I, length)
+}
+
+// appendHeaderBCD appends an empty 2-byte header and a 2-byte Binary-Coded Decimal value which counts the MLI
+func appendHeaderBCD(dst []byte, key string, length int) ([]byte, error) {
+	n := length + Size2BCD2
+	if n > 9999 {
+		return dst, &RangeError{Key: key, Length: length, Max: 9999 - Size2BCD2}
+	}
+	// Create MLI in Binary-Coded Decimal with an empty 2-byte header, four digits are packed directly
+	return append(dst, 0, 0, byte(n/1000)<<4|byte(n/100%10), byte(n/10%10)<<4|byte(n%10)), nil
+}
+
+func appendBCD2(dst []byte, length int) ([]byte, error) {
+	if length > 9999 {
+		return dst, &RangeError{Key: MLIBCD2, Length: length, Max: 9999}
+	}
+	// Create MLI in Binary-Coded Decimal, four digits are packed directly
+	return append(dst, byte(length/1000)<<4|byte(length/100%10), byte(length/10%10)<<4|byte(length%10)), nil
+}
+
+func appendBCD4(dst []byte, length int) ([]byte, error) {
+	if length > 99999999 {
+		return dst, &RangeError{Key: MLIBCD4, Length: length, Max: 99999999}
+	}
+	return appendBCD(dst, length, SizeBCD4), nil
+}
+
+func appendBCD4I(dst []byte, length int) ([]byte, error) {
+	if length > 99999999-SizeBCD4I {
+		return dst, &RangeError{Key: MLIBCD4I, Length: length, Max: 99999999 - SizeBCD4I}
+	}
+	return appendBCD(dst, length+SizeBCD4I, SizeBCD4I), nil // include mli size
+}
+
+// appendBCD appends n as width bytes of Binary-Coded Decimal, two digits per byte, n must fit within width bytes and
+// width is at most 8
+func appendBCD(dst []byte, n, width int) []byte {
+	dst = append(dst, "\x00\x00\x00\x00\x00\x00\x00\x00"[:width]...)
+	for i := len(dst) - 1; i >= len(dst)-width; i-- {
+		dst[i] = byte(n/10%10)<<4 | byte(n%10)
+		n /= 100
+	}
+	return dst
+}
+
+func appendA4E(dst []byte, length int) ([]byte, error) {
+	// A4E is fixed at four digits, longer lengths would produce an oversized MLI
+	if length > 9999 {
+		return dst, &RangeError{Key: MLIA4E, Length: length, Max: 9999}
+	}
+	// Create MLI in Hex-ASCII format, four digits are formatted directly
+	return append(dst, '0'+byte(length/1000), '0'+byte(length/100%10), '0'+byte(length/10%10), '0'+byte(length%10)), nil
+}
+
+func appendA2E(dst []byte, length int) ([]byte, error) {
+	// A2E is fixed at two digits, longer lengths would produce an oversized MLI
+	if length > 99 {
+		return dst, &RangeError{Key: MLIA2E, Length: length, Max: 99}
+	}
+	// Create MLI in ASCII format, zero padded to two digits
+	return append(dst, '0'+byte(length/10), '0'+byte(length%10)), nil
+}
+
+func appendA6E(dst []byte, length int) ([]byte, error) {
+	// A6E is fixed at six digits, longer lengths would produce an oversized MLI
+	if length > 999999 {
+		return dst, &RangeError{Key: MLIA6E, Length: length, Max: 999999}
+	}
+	return appendDigits(dst, length, SizeA6E, asciiDigits), nil
+}
+
+func appendA6I(dst []byte, length int) ([]byte, error) {
+	if length > 999999-SizeA6I {
+		return dst, &RangeError{Key: MLIA6I, Length: length, Max: 999999 - SizeA6I}
+	}
+	return appendDigits(dst, length+SizeA6I, SizeA6I, asciiDigits), nil // include mli size
+}
+
+func appendE4E(dst []byte, length int) ([]byte, error) {
+	// E4E is fixed at four digits, longer lengths would produce an oversized MLI
+	if length > 9999 {
+		return dst, &RangeError{Key: MLIE4E, Length: length, Max: 9999}
+	}
+	return appendDigits(dst, length, SizeE4E, ebcdicDigits), nil
+}
+
+func appendH4E(dst []byte, length int) ([]byte, error) {
+	return appendHex(dst, length, "0123456789ABCDEF")
+}
+
+func appendH4ELower(dst []byte, length int) ([]byte, error) {
+	return appendHex(dst, length, "0123456789abcdef")
+}
+
+// appendHex appends length as four ASCII hexadecimal digits from the digits alphabet
+func appendHex(dst []byte, length int, digits string) ([]byte, error) {
+	if length > math.MaxUint16 {
+		return dst, &RangeError{Key: MLIH4E, Length: length, Max: math.MaxUint16}
+	}
+	return append(dst, digits[length>>12&0xf], digits[length>>8&0xf], digits[length>>4&0xf], digits[length&0xf]), nil
+}
+
+// appendDigits appends n as width zero padded digits of the character set, n must fit within width digits and width
+// is at most 8
+func appendDigits(dst []byte, n, width int, set digits) []byte {
+	dst = append(dst, "00000000"[:width]...)
+	for i := len(dst) - 1; i >= len(dst)-width; i-- {
+		dst[i] = set.zero + byte(n%10)
+		n /= 10
 	}
+	return dst
 }
@@ -36,6 +36,15 @@ func BenchmarkEncoding(b *testing.B) {
 			}
 		})
 
+		buf := make([]byte, 0, 8)
+		b.Run("Append Encoding "+k, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = EncodeAppend(buf[:0], k, 1500)
+			}
+		})
+
 		x, _ := Encode(k, 1500)
 		b.Run("Decoding "+k, func(b *testing.B) {
 			b.ReportAllocs()
@@ -44,5 +53,23 @@ func BenchmarkEncoding(b *testing.B) {
 				_, _ = Decode(k, &x)
 			}
 		})
+
+		c, _ := New(k)
+		dst := make([]byte, c.Size())
+		b.Run("Codec Encoding "+k, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = c.Encode(1500, dst)
+			}
+		})
+
+		b.Run("Codec Decoding "+k, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = c.Decode(x)
+			}
+		})
 	}
 }
@@ -15,6 +15,9 @@ package simplemli
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
 	"testing"
 )
 
@@ -75,6 +78,127 @@ func TestMLIs(t *testing.T) {
 			Encoded: "30303433",
 			Value:   43,
 		},
+		{
+			Name:    "2IL",
+			Size:    Size2IL,
+			Encoded: "2d00",
+			Invalid: "0100",
+			Value:   43,
+		},
+		{
+			Name:    "2EL",
+			Size:    Size2EL,
+			Encoded: "dc05",
+			Value:   1500,
+		},
+		{
+			Name:    "4IL",
+			Size:    Size4IL,
+			Encoded: "35000000",
+			Invalid: "01000000",
+			Value:   49,
+		},
+		{
+			Name:    "4EL",
+			Size:    Size4EL,
+			Encoded: "dc050100",
+			Value:   67036,
+		},
+		{
+			Name:    "1I",
+			Size:    Size1I,
+			Encoded: "2c",
+			Value:   43,
+		},
+		{
+			Name:    "1E",
+			Size:    Size1E,
+			Encoded: "ff",
+			Value:   255,
+		},
+		{
+			Name:    "3I",
+			Size:    Size3I,
+			Encoded: "01000c",
+			Invalid: "000002",
+			Value:   65545,
+		},
+		{
+			Name:    "3E",
+			Size:    Size3E,
+			Encoded: "ffffff",
+			Value:   16777215,
+		},
+		{
+			Name:    "8I",
+			Size:    Size8I,
+			Encoded: "00000000000005e4",
+			Invalid: "0000000000000007",
+			Value:   1500,
+		},
+		{
+			Name:    "8E",
+			Size:    Size8E,
+			Encoded: "00000000000005dc",
+			Value:   1500,
+		},
+		{
+			Name:    "A2E",
+			Size:    SizeA2E,
+			Encoded: "3037",
+			Value:   7,
+		},
+		{
+			Name:    "A6E",
+			Size:    SizeA6E,
+			Encoded: "303031353030",
+			Value:   1500,
+		},
+		{
+			Name:    "A6I",
+			Size:    SizeA6I,
+			Encoded: "303031353036",
+			Invalid: "303030303035",
+			Value:   1500,
+		},
+		{
+			Name:    "H4E",
+			Size:    SizeH4E,
+			Encoded: "30354443",
+			Value:   1500,
+		},
+		{
+			Name:    "E4E",
+			Size:    SizeE4E,
+			Encoded: "f1f5f0f0",
+			Value:   1500,
+		},
+		{
+			Name:    "BCD2",
+			Size:    SizeBCD2,
+			Encoded: "1500",
+			Value:   1500,
+		},
+		{
+			Name:    "BCD4",
+			Size:    SizeBCD4,
+			Encoded: "12345678",
+			Value:   12345678,
+		},
+		{
+			Name:    "BCD4I",
+			Size:    SizeBCD4I,
+			Encoded: "00001504",
+			Invalid: "00000003",
+			Value:   1500,
+		},
+		{
+			Name:    "2BCD2I",
+			Size:    Size2BCD2I,
+			Encoded: "00000288",
+			Invalid: "00000001",
+			Value:   284,
+		},
 	}
 
 	// Execute Various Test Cases
@@ -155,7 +279,7 @@ func TestMLIs(t *testing.T) {
 				}
 
 				_, err = Decode(c.Name, &b)
-				if err == nil || err != ErrLength {
+				if !errors.Is(err, ErrLength) {
 					t.Errorf("Expected error decoding invalid MLI got %s", err)
 				}
 			})
@@ -222,3 +346,226 @@ func TestBadSizedBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestDecode64(t *testing.T) {
+	tc := []struct {
+		key      string
+		mli      []byte
+		expected int64
+	}{
+		{MLI4E, []byte{0xff, 0xff, 0xff, 0xff}, 4294967295},
+		{MLI4I, []byte{0xff, 0xff, 0xff, 0xff}, 4294967291},
+		{MLI4E, []byte{0x80, 0x00, 0x00, 0x00}, 2147483648},
+		{MLI4I, []byte{0x00, 0x00, 0x00, 0x00}, 0},
+		{MLI2I, []byte{0x05, 0xde}, 1500},
+		{MLI4EL, []byte{0x00, 0x00, 0x00, 0x80}, 2147483648},
+		{MLI4IL, []byte{0xe0, 0x05, 0x00, 0x00}, 1500},
+		{MLI8E, []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}, 4294967296},
+		{MLI8I, []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 9223372036854775799},
+	}
+	for _, c := range tc {
+		t.Run(fmt.Sprintf("%s %x", c.key, c.mli), func(t *testing.T) {
+			n, err := Decode64(c.key, c.mli)
+			if err != nil || n != c.expected {
+				t.Errorf("Unexpected result from Decode64, got %d, %v expected %d", n, err, c.expected)
+			}
+
+			// Decode agrees wherever the value fits an int, and reports ErrTooLarge otherwise
+			i, err := Decode(c.key, &c.mli)
+			if c.expected > math.MaxInt {
+				if err != ErrTooLarge {
+					t.Errorf("Expected ErrTooLarge from Decode, got %d, %v", i, err)
+				}
+			} else if err != nil || int64(i) != c.expected {
+				t.Errorf("Unexpected result from Decode, got %d, %v expected %d", i, err, c.expected)
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		short := []byte{0x00}
+		_, err := Decode64(MLI4E, short)
+		if !errors.Is(err, ErrByteSize) {
+			t.Errorf("Expected ErrByteSize, got %v", err)
+		}
+		_, err = Decode64(MLI4I, short)
+		if !errors.Is(err, ErrByteSize) {
+			t.Errorf("Expected ErrByteSize, got %v", err)
+		}
+		invalid := []byte{0x00, 0x00, 0x00, 0x01}
+		_, err = Decode64(MLI4I, invalid)
+		if !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength, got %v", err)
+		}
+	})
+}
+
+func TestEncodeAppend(t *testing.T) {
+	for _, k := range []string{MLI2I, MLI2E, MLI4I, MLI4E, MLI2EE, MLI2BCD2, MLIA4E} {
+		t.Run(k, func(t *testing.T) {
+			expected, _ := Encode(k, 1500)
+			dst := make([]byte, 3, 16)
+			copy(dst, "abc")
+
+			b, err := EncodeAppend(dst, k, 1500)
+			if err != nil {
+				t.Fatalf("Unexpected error from EncodeAppend - %s", err)
+			}
+			if string(b[:3]) != "abc" || hex.EncodeToString(b[3:]) != hex.EncodeToString(expected) {
+				t.Errorf("Unexpected result from EncodeAppend, got %x expected abc followed by %x", b, expected)
+			}
+			if &b[0] != &dst[0] {
+				t.Errorf("Expected EncodeAppend to reuse the capacity of dst")
+			}
+		})
+	}
+
+	t.Run("Zero Allocations", func(t *testing.T) {
+		dst := make([]byte, 0, 16)
+		for _, k := range []string{MLI4E, MLI2BCD2, MLIA4E} {
+			allocs := testing.AllocsPerRun(100, func() {
+				_, _ = EncodeAppend(dst[:0], k, 1500)
+			})
+			if allocs != 0 {
+				t.Errorf("Expected no allocations for %s, got %v", k, allocs)
+			}
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dst := []byte("abc")
+		b, err := EncodeAppend(dst, "bad", 1)
+		if err != ErrInvalidType || string(b) != "abc" {
+			t.Errorf("Expected ErrInvalidType and dst unchanged, got %q, %v", b, err)
+		}
+		b, err = EncodeAppend(dst, MLI2I, -1)
+		if !errors.Is(err, ErrLength) || string(b) != "abc" {
+			t.Errorf("Expected ErrLength and dst unchanged, got %q, %v", b, err)
+		}
+	})
+}
+
+func TestEncodeInto(t *testing.T) {
+	for _, k := range []string{MLI2I, MLI2E, MLI4I, MLI4E, MLI2EE, MLI2BCD2, MLIA4E} {
+		t.Run(k, func(t *testing.T) {
+			expected, _ := Encode(k, 1500)
+			buf := make([]byte, 8)
+			n, err := EncodeInto(k, 1500, buf)
+			if err != nil || n != len(expected) {
+				t.Fatalf("Unexpected result from EncodeInto, got %d, %v expected %d", n, err, len(expected))
+			}
+			if hex.EncodeToString(buf[:n]) != hex.EncodeToString(expected) {
+				t.Errorf("Unexpected MLI, got %x expected %x", buf[:n], expected)
+			}
+
+			_, err = EncodeInto(k, 1500, buf[:len(expected)-1])
+			if !errors.Is(err, ErrByteSize) {
+				t.Errorf("Expected ErrByteSize for a short buffer, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Zero Allocations", func(t *testing.T) {
+		buf := make([]byte, 4)
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = EncodeInto(MLI2BCD2, 1500, buf)
+		})
+		if allocs != 0 {
+			t.Errorf("Expected no allocations, got %v", allocs)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		buf := make([]byte, 4)
+		if _, err := EncodeInto("bad", 1, buf); err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+		if _, err := EncodeInto(MLI2I, -1, buf); !errors.Is(err, ErrLength) {
+			t.Errorf("Expected ErrLength, got %v", err)
+		}
+	})
+}
+
+func TestDecodeBytes(t *testing.T) {
+	for _, k := range []string{MLI2I, MLI2E, MLI4I, MLI4E, MLI2EE, MLI2BCD2, MLIA4E} {
+		t.Run(k, func(t *testing.T) {
+			mli, _ := Encode(k, 1500)
+			buf := append(mli, "message"...)
+
+			// A sub-slice can be passed inline
+			n, err := DecodeBytes(k, buf[:len(mli)])
+			if err != nil || n != 1500 {
+				t.Errorf("Unexpected result from DecodeBytes, got %d, %v", n, err)
+			}
+
+			// The deprecated pointer form agrees
+			p, err := Decode(k, &mli)
+			if err != nil || p != n {
+				t.Errorf("Unexpected result from Decode, got %d, %v expected %d", p, err, n)
+			}
+
+			_, err = DecodeBytes(k, buf)
+			if !errors.Is(err, ErrByteSize) {
+				t.Errorf("Expected ErrByteSize decoding the MLI and message, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := DecodeBytes("bad", []byte{0, 1})
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+	})
+}
+
+func TestSize(t *testing.T) {
+	tc := map[string]int{
+		MLI2I:    Size2I,
+		MLI2E:    Size2E,
+		MLI4I:    Size4I,
+		MLI4E:    Size4E,
+		MLI2EE:   Size2EE,
+		MLI2BCD2: Size2BCD2,
+		MLIA4E:   SizeA4E,
+	}
+	for k, expected := range tc {
+		t.Run(k, func(t *testing.T) {
+			size, err := Size(k)
+			if err != nil || size != expected {
+				t.Errorf("Unexpected result from Size, got %d, %v expected %d", size, err, expected)
+			}
+		})
+	}
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := Size("bad")
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType, got %v", err)
+		}
+	})
+}
+
+func TestMaxLength(t *testing.T) {
+	mli, _ := Encode(MLI4E, 8192)
+
+	n, err := DecodeBytes(MLI4E, mli, MaxLength(8192))
+	if err != nil || n != 8192 {
+		t.Errorf("Unexpected result at the limit, got %d, %v", n, err)
+	}
+	n, err = DecodeBytes(MLI4E, mli, MaxLength(0))
+	if err != nil || n != 8192 {
+		t.Errorf("Unexpected result with the check disabled, got %d, %v", n, err)
+	}
+
+	_, err = DecodeBytes(MLI4E, []byte{0xff, 0xff, 0xff, 0xfe}, MaxLength(8192))
+	if !errors.Is(err, ErrTooLarge) || ErrorCode(err) != CodeTooLarge {
+		t.Errorf("Expected ErrTooLarge above the limit, got %v", err)
+	}
+
+	// Errors decoding the MLI take precedence
+	_, err = DecodeBytes(MLI4E, mli[:2], MaxLength(8192))
+	if !errors.Is(err, ErrByteSize) {
+		t.Errorf("Expected ErrByteSize, got %v", err)
+	}
+}
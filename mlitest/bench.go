@@ -0,0 +1,57 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// benchLength is the message length encoded and decoded by BenchmarkCodec, matching the built-in benchmarks
+const benchLength = 1500
+
+// BenchmarkCodec runs the encode and decode benchmarks used for the built-in MLI types against c, so custom codecs can
+// be compared with the built-ins on equal footing.
+//
+//	func BenchmarkMyCodec(b *testing.B) {
+//		mlitest.BenchmarkCodec(b, myCodec{})
+//	}
+//
+// Compare the results with the built-in types by running the same helper with simplemli.New.
+func BenchmarkCodec(b *testing.B, c simplemli.Codec) {
+	mli := make([]byte, c.Size())
+	err := c.Encode(benchLength, mli)
+	if err != nil {
+		b.Fatalf("Unable to encode a %d byte message - %s", benchLength, err)
+	}
+
+	b.Run("Encoding", func(b *testing.B) {
+		dst := make([]byte, c.Size())
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = c.Encode(benchLength, dst)
+		}
+	})
+
+	b.Run("Decoding", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = c.Decode(mli)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+func BenchmarkBuiltinCodecs(b *testing.B) {
+	for _, k := range framing.Keys() {
+		c, err := simplemli.New(k)
+		if err != nil {
+			b.Fatalf("Unable to create codec - %s", err)
+		}
+		b.Run(k, func(b *testing.B) {
+			BenchmarkCodec(b, c)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+)
+
+// Chaos describes the corruption injected by a ChaosConn. The zero value injects nothing.
+type Chaos struct {
+	// CorruptRate is the probability, between 0 and 1, that a frame has one byte of its MLI corrupted.
+	CorruptRate float64
+
+	// GarbageRate is the probability, between 0 and 1, that garbage bytes are inserted before a frame.
+	GarbageRate float64
+
+	// GarbageLen is the largest number of garbage bytes inserted at once, defaulting to 8.
+	GarbageLen int
+
+	// Seed seeds the corruption, so a failing run can be reproduced.
+	Seed int64
+}
+
+// ChaosStats counts the corruption injected by a ChaosConn.
+type ChaosStats struct {
+	// Frames is the number of frames written
+	Frames int
+
+	// Corrupted is the number of frames with a corrupted MLI
+	Corrupted int
+
+	// Garbage is the number of garbage runs inserted between frames
+	Garbage int
+}
+
+// ChaosConn wraps a net.Conn and randomly corrupts the frames written to it, so applications can be validated against
+// dirty streams and prove they resynchronize or fail cleanly.
+//
+//	client, server := net.Pipe()
+//	dirty := mlitest.NewChaosConn(server, simplemli.MLI2I, mlitest.Chaos{CorruptRate: 0.01, GarbageRate: 0.01})
+//
+// Corruption is only injected on writes, frame boundaries are tracked using the original, uncorrupted MLIs.
+type ChaosConn struct {
+	net.Conn
+	chaos Chaos
+
+	mu      sync.Mutex
+	rnd     *rand.Rand
+	frames  frameTracker
+	stats   ChaosStats
+	corrupt int
+}
+
+// NewChaosConn wraps conn, corrupting frames of the MLI type key according to chaos.
+func NewChaosConn(conn net.Conn, key string, chaos Chaos) *ChaosConn {
+	if chaos.GarbageLen <= 0 {
+		chaos.GarbageLen = 8
+	}
+	return &ChaosConn{
+		Conn:    conn,
+		chaos:   chaos,
+		rnd:     rand.New(rand.NewSource(chaos.Seed)),
+		frames:  newFrameTracker(key),
+		corrupt: -1,
+	}
+}
+
+// Stats returns the corruption injected so far.
+func (c *ChaosConn) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Write writes p to the underlying connection with corruption injected. The returned count covers only the bytes of
+// p, not inserted garbage.
+func (c *ChaosConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		s := c.frames.next(p[written:])
+		seg := p[written : written+s.n]
+
+		if s.start {
+			c.stats.Frames++
+			if c.chaos.GarbageRate > 0 && c.rnd.Float64() < c.chaos.GarbageRate {
+				garbage := make([]byte, 1+c.rnd.Intn(c.chaos.GarbageLen))
+				_, _ = c.rnd.Read(garbage)
+				c.stats.Garbage++
+				if _, err := c.Conn.Write(garbage); err != nil {
+					return written, err
+				}
+			}
+			c.corrupt = -1
+			if s.mli && c.chaos.CorruptRate > 0 && c.rnd.Float64() < c.chaos.CorruptRate {
+				c.corrupt = c.rnd.Intn(c.frames.size)
+				c.stats.Corrupted++
+			}
+		}
+
+		if s.mli && c.corrupt >= s.offset && c.corrupt < s.offset+s.n {
+			seg = append([]byte{}, seg...)
+			seg[c.corrupt-s.offset] ^= byte(1 + c.rnd.Intn(255))
+		}
+
+		n, err := c.Conn.Write(seg)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// recorder is a MockConn which also keeps the raw bytes written
+type recorder struct {
+	*MockConn
+	raw bytes.Buffer
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.raw.Write(p)
+	return r.MockConn.Write(p)
+}
+
+func TestChaosConn(t *testing.T) {
+	frame := []byte{0x00, 0x04, 'a', 'b'}
+
+	t.Run("No chaos", func(t *testing.T) {
+		rec := &recorder{MockConn: NewMockConn(simplemli.MLI2I)}
+		conn := NewChaosConn(rec, simplemli.MLI2I, Chaos{})
+		for i := 0; i < 10; i++ {
+			_, _ = conn.Write(frame)
+		}
+		if !bytes.Equal(rec.raw.Bytes(), bytes.Repeat(frame, 10)) {
+			t.Errorf("Unexpected change to stream, got %x", rec.raw.Bytes())
+		}
+		if s := conn.Stats(); s.Frames != 10 || s.Corrupted != 0 || s.Garbage != 0 {
+			t.Errorf("Unexpected stats, got %+v", s)
+		}
+	})
+
+	t.Run("Corrupt every MLI", func(t *testing.T) {
+		rec := &recorder{MockConn: NewMockConn(simplemli.MLI2I)}
+		conn := NewChaosConn(rec, simplemli.MLI2I, Chaos{CorruptRate: 1, Seed: 3})
+		for i := 0; i < 10; i++ {
+			n, err := conn.Write(frame)
+			if err != nil || n != len(frame) {
+				t.Errorf("Unexpected result from Write, got %d, %v", n, err)
+			}
+		}
+		raw := rec.raw.Bytes()
+		if len(raw) != 10*len(frame) {
+			t.Fatalf("Unexpected stream length, got %d", len(raw))
+		}
+		for i := 0; i < 10; i++ {
+			f := raw[i*len(frame) : (i+1)*len(frame)]
+			if bytes.Equal(f[:2], frame[:2]) {
+				t.Errorf("Expected MLI of frame %d to be corrupted, got %x", i, f)
+			}
+			if !bytes.Equal(f[2:], frame[2:]) {
+				t.Errorf("Expected body of frame %d to be untouched, got %x", i, f)
+			}
+		}
+		if s := conn.Stats(); s.Corrupted != 10 {
+			t.Errorf("Expected 10 corrupted frames, got %+v", s)
+		}
+	})
+
+	t.Run("Garbage between frames", func(t *testing.T) {
+		rec := &recorder{MockConn: NewMockConn(simplemli.MLI2I)}
+		conn := NewChaosConn(rec, simplemli.MLI2I, Chaos{GarbageRate: 0.5, GarbageLen: 4, Seed: 5})
+		for i := 0; i < 20; i++ {
+			_, _ = conn.Write(frame)
+		}
+		s := conn.Stats()
+		if s.Garbage == 0 || s.Garbage == 20 {
+			t.Errorf("Expected some frames preceded by garbage, got %+v", s)
+		}
+		if n := rec.raw.Len(); n <= 20*len(frame) || n > 20*len(frame)+4*s.Garbage {
+			t.Errorf("Unexpected stream length %d for %d garbage runs", n, s.Garbage)
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		var streams [2][]byte
+		for i := range streams {
+			rec := &recorder{MockConn: NewMockConn(simplemli.MLI2I)}
+			conn := NewChaosConn(rec, simplemli.MLI2I, Chaos{CorruptRate: 0.3, GarbageRate: 0.3, Seed: 9})
+			for j := 0; j < 20; j++ {
+				_, _ = conn.Write(frame)
+			}
+			streams[i] = rec.raw.Bytes()
+		}
+		if !bytes.Equal(streams[0], streams[1]) {
+			t.Errorf("Expected identical streams for the same seed")
+		}
+	})
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"math/rand"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// DefaultMaxBody is the largest message body generated by Corpus when CorpusOptions.MaxBody is not set.
+const DefaultMaxBody = 64 * 1024
+
+// CorpusOptions configures the frames generated by Corpus.
+type CorpusOptions struct {
+	// Keys lists the MLI types to generate frames for, all supported types are used when empty.
+	Keys []string
+
+	// Random is the number of additional random-length frames generated per type.
+	Random int
+
+	// MaxBody caps the size of generated message bodies, defaulting to DefaultMaxBody. Edge-case lengths larger than
+	// MaxBody are still included, with the MLI but no body.
+	MaxBody int
+}
+
+// CorpusEntry is a single generated frame.
+type CorpusEntry struct {
+	// Key is the MLI type
+	Key string
+
+	// Length is the message length encoded in the MLI
+	Length int
+
+	// MLI is the encoded message length indicator
+	MLI []byte
+
+	// Body is the message, nil when Length exceeds CorpusOptions.MaxBody
+	Body []byte
+}
+
+// Frame returns the MLI followed by the message body.
+func (e CorpusEntry) Frame() []byte {
+	return append(append([]byte{}, e.MLI...), e.Body...)
+}
+
+// Corpus generates a deterministic set of frames for every requested MLI type, covering the edge-case lengths (the
+// minimum, minimum+1, maximum-1 and maximum representable length) plus random lengths. The same seed and options always
+// produce the same corpus, making it suitable for fuzz seeds and for fixtures in downstream tests.
+//
+//	for _, e := range mlitest.Corpus(1, mlitest.CorpusOptions{Random: 10}) {
+//		f.Add(e.Frame())
+//	}
+func Corpus(seed int64, opts CorpusOptions) []CorpusEntry {
+	keys := opts.Keys
+	if len(keys) == 0 {
+		keys = framing.Keys()
+	}
+	maxBody := opts.MaxBody
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBody
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	var entries []CorpusEntry
+	for _, key := range keys {
+		lo, hi, err := framing.Bounds(key)
+		if err != nil {
+			continue
+		}
+
+		lengths := []int{lo, lo + 1, hi - 1, hi}
+		limit := hi
+		if limit > maxBody {
+			limit = maxBody
+		}
+		for i := 0; i < opts.Random && limit >= lo; i++ {
+			lengths = append(lengths, lo+rnd.Intn(limit-lo+1))
+		}
+
+		for _, n := range lengths {
+			mli, err := simplemli.Encode(key, n)
+			if err != nil {
+				continue
+			}
+			e := CorpusEntry{Key: key, Length: n, MLI: mli}
+			if n <= maxBody {
+				e.Body = make([]byte, n)
+				_, _ = rnd.Read(e.Body)
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestCorpus(t *testing.T) {
+	opts := CorpusOptions{Random: 5, MaxBody: 1024}
+	a := Corpus(7, opts)
+	b := Corpus(7, opts)
+
+	t.Run("Deterministic", func(t *testing.T) {
+		if len(a) != len(b) {
+			t.Fatalf("Corpus size differs for the same seed, got %d and %d", len(a), len(b))
+		}
+		for i := range a {
+			if !bytes.Equal(a[i].Frame(), b[i].Frame()) {
+				t.Errorf("Corpus entry %d differs for the same seed", i)
+			}
+		}
+	})
+
+	t.Run("Entries decode", func(t *testing.T) {
+		types := make(map[string]int)
+		for _, e := range a {
+			types[e.Key]++
+			n, err := simplemli.Decode(e.Key, &e.MLI)
+			if err != nil || n != e.Length {
+				t.Errorf("Corpus entry %s/%d does not decode, got %d, %v", e.Key, e.Length, n, err)
+			}
+			if e.Length <= opts.MaxBody && len(e.Body) != e.Length {
+				t.Errorf("Corpus entry %s/%d has body of %d bytes", e.Key, e.Length, len(e.Body))
+			}
+			if e.Length > opts.MaxBody && e.Body != nil {
+				t.Errorf("Corpus entry %s/%d exceeds MaxBody but has a body", e.Key, e.Length)
+			}
+		}
+		if len(types) != 26 {
+			t.Errorf("Expected entries for all 26 built-in types, got %d", len(types))
+		}
+		for k, n := range types {
+			if n != 9 {
+				t.Errorf("Expected 9 entries for %s, got %d", k, n)
+			}
+		}
+	})
+
+	t.Run("Selected keys", func(t *testing.T) {
+		c := Corpus(1, CorpusOptions{Keys: []string{simplemli.MLIA4E}})
+		if len(c) != 4 {
+			t.Fatalf("Expected 4 edge-case entries, got %d", len(c))
+		}
+		expected := []int{0, 1, 9998, 9999}
+		for i, e := range c {
+			if e.Length != expected[i] {
+				t.Errorf("Unexpected edge-case length, got %d expected %d", e.Length, expected[i])
+			}
+		}
+	})
+}
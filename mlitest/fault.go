@@ -0,0 +1,124 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// Faults describes the pathological behaviors injected by a FaultConn. The zero value injects no faults.
+type Faults struct {
+	// WriteChunk splits every Write into separate writes of at most this many bytes to the underlying connection.
+	// A value of 1 guarantees MLIs are split across writes.
+	WriteChunk int
+
+	// ReadChunk limits every Read to at most this many bytes. A value of 1 delivers messages one byte at a time.
+	ReadChunk int
+
+	// ShortWrite makes every Write send at most this many bytes and return io.ErrShortWrite when the data is longer.
+	ShortWrite int
+
+	// InjectEOF makes Read return io.ErrUnexpectedEOF once EOFAfter bytes have been read.
+	InjectEOF bool
+
+	// EOFAfter is the number of bytes delivered by Read before io.ErrUnexpectedEOF is injected.
+	EOFAfter int
+}
+
+// FaultConn wraps a net.Conn and injects the configured Faults, so consumers can prove their framing code survives
+// split MLIs, fragmented reads, short writes and abrupt stream ends.
+//
+//	client, server := net.Pipe()
+//	conn := mlitest.NewFaultConn(client, mlitest.Faults{WriteChunk: 1, ReadChunk: 1})
+type FaultConn struct {
+	net.Conn
+
+	mu     sync.Mutex
+	faults Faults
+	read   int
+}
+
+// NewFaultConn wraps conn to inject faults.
+func NewFaultConn(conn net.Conn, faults Faults) *FaultConn {
+	return &FaultConn{Conn: conn, faults: faults}
+}
+
+// SetFaults replaces the faults injected by the connection.
+func (c *FaultConn) SetFaults(faults Faults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.faults = faults
+}
+
+// Read reads from the underlying connection, limited by ReadChunk and EOF injection.
+func (c *FaultConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	f := c.faults
+	read := c.read
+	c.mu.Unlock()
+
+	if f.InjectEOF {
+		remaining := f.EOFAfter - read
+		if remaining <= 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if len(p) > remaining {
+			p = p[:remaining]
+		}
+	}
+	if f.ReadChunk > 0 && len(p) > f.ReadChunk {
+		p = p[:f.ReadChunk]
+	}
+
+	n, err := c.Conn.Read(p)
+	c.mu.Lock()
+	c.read += n
+	c.mu.Unlock()
+	return n, err
+}
+
+// Write writes to the underlying connection, split by WriteChunk and truncated by ShortWrite.
+func (c *FaultConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	f := c.faults
+	c.mu.Unlock()
+
+	var shortErr error
+	if f.ShortWrite > 0 && len(p) > f.ShortWrite {
+		p = p[:f.ShortWrite]
+		shortErr = io.ErrShortWrite
+	}
+
+	chunk := len(p)
+	if f.WriteChunk > 0 && f.WriteChunk < chunk {
+		chunk = f.WriteChunk
+	}
+
+	written := 0
+	for written < len(p) {
+		end := written + chunk
+		if end > len(p) {
+			end = len(p)
+		}
+		n, err := c.Conn.Write(p[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, shortErr
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// countingConn counts the writes made to a net.Conn
+type countingConn struct {
+	net.Conn
+	writes chan int
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	c.writes <- len(p)
+	return c.Conn.Write(p)
+}
+
+func TestFaultConn(t *testing.T) {
+	t.Run("Split writes and byte reads", func(t *testing.T) {
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		counter := &countingConn{Conn: a, writes: make(chan int, 64)}
+		w := NewConn(NewFaultConn(counter, Faults{WriteChunk: 1}), simplemli.MLI4E)
+		r := NewConn(NewFaultConn(b, Faults{ReadChunk: 1}), simplemli.MLI4E)
+
+		go func() {
+			_ = w.WriteMessage([]byte("hello"))
+			close(counter.writes)
+		}()
+
+		msg, err := r.ReadMessage()
+		if err != nil || string(msg) != "hello" {
+			t.Errorf("Unexpected message through faulty connection, got %q, %v", msg, err)
+		}
+
+		n := 0
+		for size := range counter.writes {
+			if size != 1 {
+				t.Errorf("Expected 1-byte writes, got %d", size)
+			}
+			n++
+		}
+		if n != 9 {
+			t.Errorf("Expected 9 separate writes, got %d", n)
+		}
+	})
+
+	t.Run("Short write", func(t *testing.T) {
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+		go func() { _, _ = io.Copy(io.Discard, b) }()
+
+		c := NewFaultConn(a, Faults{ShortWrite: 3})
+		n, err := c.Write([]byte("hello"))
+		if n != 3 || err != io.ErrShortWrite {
+			t.Errorf("Expected short write of 3 bytes, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Injected EOF", func(t *testing.T) {
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		r := NewConn(NewFaultConn(b, Faults{InjectEOF: true, EOFAfter: 4}), simplemli.MLI2E)
+		go func() {
+			_ = NewConn(a, simplemli.MLI2E).WriteMessage([]byte("hello"))
+		}()
+
+		_, err := r.ReadMessage()
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+
+	t.Run("SetFaults", func(t *testing.T) {
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		c := NewFaultConn(b, Faults{InjectEOF: true})
+		c.SetFaults(Faults{})
+		go func() { _, _ = a.Write([]byte("x")) }()
+		p := make([]byte, 1)
+		if _, err := c.Read(p); err != nil {
+			t.Errorf("Unexpected error after clearing faults - %s", err)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set to a non-empty value, makes ExpectGolden rewrite golden
+// files with the frames observed rather than comparing against them.
+//
+//	MLITEST_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "MLITEST_UPDATE_GOLDEN"
+
+// ExpectGolden compares frames, including their MLIs, with the golden file at path and reports a test error with a hex
+// diff on mismatch. Golden files hold one frame per line in hex so changes are readable in code review.
+//
+// When UpdateGoldenEnv is set the golden file, and any missing directories, are written instead.
+func ExpectGolden(t testing.TB, path string, frames ...[]byte) {
+	t.Helper()
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		err := writeGolden(path, frames)
+		if err != nil {
+			t.Fatalf("Unable to update golden file %s - %s", path, err)
+		}
+		return
+	}
+
+	want, err := readGolden(path)
+	if err != nil {
+		t.Fatalf("Unable to read golden file %s, set %s=1 to create it - %s", path, UpdateGoldenEnv, err)
+	}
+
+	if len(frames) != len(want) {
+		t.Errorf("Unexpected number of frames compared with %s, got %d expected %d", path, len(frames), len(want))
+	}
+	for i := 0; i < len(frames) || i < len(want); i++ {
+		var got, exp []byte
+		if i < len(frames) {
+			got = frames[i]
+		}
+		if i < len(want) {
+			exp = want[i]
+		}
+		if !bytes.Equal(got, exp) {
+			t.Errorf("Frame %d differs from %s at byte %d\n got: %x\nwant: %x", i, path, diffOffset(got, exp), got, exp)
+		}
+	}
+}
+
+// ExpectGolden compares the frames written to the connection, with their MLIs, against the golden file at path.
+func (c *MockConn) ExpectGolden(t testing.TB, path string) {
+	t.Helper()
+	var frames [][]byte
+	for _, msg := range c.Frames() {
+		var buf bytes.Buffer
+		_ = simplemli.WriteFrame(&buf, c.key, msg)
+		frames = append(frames, buf.Bytes())
+	}
+	ExpectGolden(t, path, frames...)
+}
+
+// readGolden parses a golden file, skipping blank lines
+func readGolden(path string) ([][]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames [][]byte
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		f, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f)
+	}
+	return frames, nil
+}
+
+// writeGolden writes frames to a golden file, one hex frame per line
+func writeGolden(path string, frames [][]byte) error {
+	var buf bytes.Buffer
+	for _, f := range frames {
+		buf.WriteString(hex.EncodeToString(f))
+		buf.WriteByte('\n')
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// diffOffset returns the offset of the first byte which differs between a and b
+func diffOffset(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "logon.golden")
+	frames := [][]byte{{0x00, 0x04, 0x08, 0x00}, {0x00, 0x02}}
+
+	t.Run("Missing file", func(t *testing.T) {
+		ft := &fakeTB{TB: t}
+		defer func() {
+			if r := recover(); r != errFatal {
+				t.Errorf("Expected fatal error for missing golden file, got %v", r)
+			}
+		}()
+		ExpectGolden(ft, path, frames...)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Setenv(UpdateGoldenEnv, "1")
+		ExpectGolden(t, path, frames...)
+
+		b, err := os.ReadFile(path)
+		if err != nil || string(b) != "00040800\n0002\n" {
+			t.Errorf("Unexpected golden file contents, got %q, %v", b, err)
+		}
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		ExpectGolden(t, path, frames...)
+
+		mock := NewMockConn(simplemli.MLI2I)
+		for _, f := range frames {
+			_, _ = mock.Write(f)
+		}
+		mock.ExpectGolden(t, path)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		ft := &fakeTB{TB: t}
+		ExpectGolden(ft, path, []byte{0x00, 0x04, 0x08, 0x10})
+		if ft.errors != 3 {
+			t.Errorf("Expected count and two frame errors, got %d", ft.errors)
+		}
+	})
+
+	t.Run("Diff offset", func(t *testing.T) {
+		if n := diffOffset([]byte{1, 2, 3}, []byte{1, 2, 4}); n != 2 {
+			t.Errorf("Unexpected diff offset, got %d expected 2", n)
+		}
+		if n := diffOffset([]byte{1}, []byte{1, 2}); n != 1 {
+			t.Errorf("Unexpected diff offset, got %d expected 1", n)
+		}
+	})
+}
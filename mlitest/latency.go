@@ -0,0 +1,103 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Latency describes the network conditions simulated by a SlowConn. The zero value adds no delay.
+type Latency struct {
+	// Delay is added before each frame is written.
+	Delay time.Duration
+
+	// Jitter adds a random delay of up to this duration to each frame.
+	Jitter time.Duration
+
+	// BytesPerSecond caps the write bandwidth, zero means unlimited.
+	BytesPerSecond int
+
+	// Seed seeds the jitter, so delays are reproducible between runs.
+	Seed int64
+}
+
+// SlowConn wraps a net.Conn and delays the frames written to it, reproducing slow-partner conditions without external
+// network shaping tools. Delays are applied per frame, so a frame split across several writes is delayed once.
+//
+//	client, server := net.Pipe()
+//	partner := mlitest.NewSlowConn(server, simplemli.MLI2I, mlitest.Latency{Delay: 200 * time.Millisecond})
+//
+// If the written bytes stop forming valid frames, the delay is applied to every write instead.
+type SlowConn struct {
+	net.Conn
+	latency Latency
+
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	frames frameTracker
+}
+
+// NewSlowConn wraps conn, delaying frames of the MLI type key according to latency.
+func NewSlowConn(conn net.Conn, key string, latency Latency) *SlowConn {
+	return &SlowConn{
+		Conn:    conn,
+		latency: latency,
+		rnd:     rand.New(rand.NewSource(latency.Seed)),
+		frames:  newFrameTracker(key),
+	}
+}
+
+// Write writes p to the underlying connection, sleeping before each frame and throttling to the bandwidth cap.
+func (c *SlowConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		s := c.frames.next(p[written:])
+		if s.start {
+			c.sleep()
+		}
+		c.throttle(s.n)
+		n, err := c.Conn.Write(p[written : written+s.n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// sleep waits for the per-frame delay and jitter
+func (c *SlowConn) sleep() {
+	d := c.latency.Delay
+	if c.latency.Jitter > 0 {
+		d += time.Duration(c.rnd.Int63n(int64(c.latency.Jitter)))
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// throttle waits for the time n bytes take to send at the bandwidth cap
+func (c *SlowConn) throttle(n int) {
+	if c.latency.BytesPerSecond <= 0 {
+		return
+	}
+	time.Sleep(time.Duration(n) * time.Second / time.Duration(c.latency.BytesPerSecond))
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestSlowConn(t *testing.T) {
+	t.Run("Delay per frame", func(t *testing.T) {
+		mock := NewMockConn(simplemli.MLI2I)
+		conn := NewSlowConn(mock, simplemli.MLI2I, Latency{Delay: 20 * time.Millisecond})
+
+		start := time.Now()
+		// Two frames in one write plus a third split across writes
+		_, _ = conn.Write([]byte{0x00, 0x03, 'a', 0x00, 0x03, 'b', 0x00})
+		_, _ = conn.Write([]byte{0x03, 'c'})
+		elapsed := time.Since(start)
+
+		if elapsed < 60*time.Millisecond {
+			t.Errorf("Expected three frame delays, took %s", elapsed)
+		}
+		if elapsed > time.Second {
+			t.Errorf("Split frame delayed more than once, took %s", elapsed)
+		}
+		mock.ExpectFrames(t, []byte("a"), []byte("b"), []byte("c"))
+	})
+
+	t.Run("Bandwidth", func(t *testing.T) {
+		mock := NewMockConn(simplemli.MLI2E)
+		conn := NewSlowConn(mock, simplemli.MLI2E, Latency{BytesPerSecond: 1000})
+
+		start := time.Now()
+		_, _ = conn.Write(append([]byte{0x00, 48}, make([]byte, 48)...))
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("Expected 50 bytes at 1000 bytes/sec to take 50ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("Jitter", func(t *testing.T) {
+		mock := NewMockConn(simplemli.MLI2I)
+		conn := NewSlowConn(mock, simplemli.MLI2I, Latency{Jitter: 10 * time.Millisecond, Seed: 1})
+		for i := 0; i < 3; i++ {
+			_, err := conn.Write([]byte{0x00, 0x02})
+			if err != nil {
+				t.Errorf("Unexpected error from Write - %s", err)
+			}
+		}
+		if n := len(mock.Frames()); n != 3 {
+			t.Errorf("Expected 3 frames written, got %d", n)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// MockConn is a net.Conn test double which records every frame written to it and serves preloaded frames on reads.
+// Once the preloaded frames are consumed, Read returns io.EOF.
+//
+//	conn := mlitest.NewMockConn(simplemli.MLI2I, []byte("0810..."))
+//	err := client.Logon(conn) // code under test
+//	conn.ExpectFrames(t, []byte("0800..."))
+type MockConn struct {
+	key string
+
+	mu      sync.Mutex
+	rbuf    bytes.Buffer
+	wbuf    []byte
+	written [][]byte
+	closed  bool
+}
+
+// NewMockConn returns a MockConn for the MLI type key which serves msgs, framed with an MLI, to Read.
+func NewMockConn(key string, msgs ...[]byte) *MockConn {
+	c := &MockConn{key: key}
+	for _, msg := range msgs {
+		c.Serve(msg)
+	}
+	return c
+}
+
+// Serve frames msg with an MLI and queues it to be returned by Read.
+func (c *MockConn) Serve(msg []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = simplemli.WriteFrame(&c.rbuf, c.key, msg)
+}
+
+// Read reads preloaded frames, returning io.EOF once all have been consumed.
+func (c *MockConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	return c.rbuf.Read(p)
+}
+
+// Write records p, splitting the written bytes into frames as each frame is completed.
+func (c *MockConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	c.wbuf = append(c.wbuf, p...)
+	for {
+		msg, rest, err := simplemli.Deframe(c.key, c.wbuf)
+		if err != nil {
+			break
+		}
+		c.written = append(c.written, append([]byte{}, msg...))
+		c.wbuf = rest
+	}
+	return len(p), nil
+}
+
+// Frames returns the messages written to the connection so far, without their MLIs.
+func (c *MockConn) Frames() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte{}, c.written...)
+}
+
+// Pending returns written bytes which do not yet form a complete frame.
+func (c *MockConn) Pending() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte{}, c.wbuf...)
+}
+
+// ExpectFrames reports a test error unless exactly the messages in want, without MLIs, have been written to the
+// connection in order.
+func (c *MockConn) ExpectFrames(t testing.TB, want ...[]byte) {
+	t.Helper()
+	got := c.Frames()
+	if len(got) != len(want) {
+		t.Errorf("Unexpected number of frames written, got %d expected %d", len(got), len(want))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("Unexpected frame %d written, got %x expected %x", i, got[i], want[i])
+		}
+	}
+	if pending := c.Pending(); len(pending) > 0 {
+		t.Errorf("Incomplete frame written, got %x", pending)
+	}
+}
+
+// Close marks the connection closed, subsequent reads and writes return net.ErrClosed.
+func (c *MockConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// LocalAddr returns a placeholder address.
+func (c *MockConn) LocalAddr() net.Addr {
+	return mockAddr("local")
+}
+
+// RemoteAddr returns a placeholder address.
+func (c *MockConn) RemoteAddr() net.Addr {
+	return mockAddr("remote")
+}
+
+// SetDeadline is a no-op, MockConn never blocks.
+func (c *MockConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+// SetReadDeadline is a no-op, MockConn never blocks.
+func (c *MockConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline is a no-op, MockConn never blocks.
+func (c *MockConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// mockAddr is the net.Addr reported by MockConn
+type mockAddr string
+
+func (a mockAddr) Network() string {
+	return "mock"
+}
+
+func (a mockAddr) String() string {
+	return string(a)
+}
+
+// ensure MockConn satisfies the interfaces consumers expect
+var (
+	_ net.Conn      = (*MockConn)(nil)
+	_ io.ReadWriter = (*MockConn)(nil)
+)
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// fakeTB records errors reported by assertion helpers rather than failing the test
+type fakeTB struct {
+	testing.TB
+	errors int
+}
+
+func (f *fakeTB) Errorf(string, ...interface{}) {
+	f.errors++
+}
+
+// Fatalf records the error and panics with errFatal, as fakeTB cannot stop the calling goroutine
+func (f *fakeTB) Fatalf(string, ...interface{}) {
+	f.errors++
+	panic(errFatal)
+}
+
+var errFatal = fmt.Errorf("fatal test error")
+
+func TestMockConn(t *testing.T) {
+	t.Run("Serves preloaded frames", func(t *testing.T) {
+		conn := NewConn(NewMockConn(simplemli.MLI2E, []byte("one"), []byte("two")), simplemli.MLI2E)
+		for _, want := range []string{"one", "two"} {
+			msg, err := conn.ReadMessage()
+			if err != nil || string(msg) != want {
+				t.Errorf("Unexpected message, got %q, %v expected %q", msg, err, want)
+			}
+		}
+		_, err := conn.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF once frames are consumed, got %v", err)
+		}
+	})
+
+	t.Run("Records written frames", func(t *testing.T) {
+		mock := NewMockConn(simplemli.MLI4I)
+		conn := NewFaultConn(mock, Faults{WriteChunk: 1})
+		_ = NewConn(conn, simplemli.MLI4I).WriteMessage([]byte("hello"))
+		_ = NewConn(conn, simplemli.MLI4I).WriteMessage([]byte{})
+		mock.ExpectFrames(t, []byte("hello"), []byte{})
+	})
+
+	t.Run("Reports mismatches", func(t *testing.T) {
+		mock := NewMockConn(simplemli.MLI2I)
+		_, _ = mock.Write([]byte{0x00, 0x05, 'a', 'b', 'c', 0x00})
+
+		ft := &fakeTB{TB: t}
+		mock.ExpectFrames(ft, []byte("abd"))
+		if ft.errors != 2 {
+			t.Errorf("Expected ExpectFrames to fail on mismatched and incomplete frames")
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		mock := NewMockConn(simplemli.MLI2I, []byte("x"))
+		_ = mock.Close()
+		_, err := mock.Read(make([]byte, 8))
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Expected net.ErrClosed, got %v", err)
+		}
+		_, err = mock.Write([]byte{0x00})
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Expected net.ErrClosed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+/*
+Package mlitest provides helpers for testing code which exchanges MLI-framed messages, without the need for real
+sockets.
+
+	client, server := mlitest.Pipe(simplemli.MLI2I)
+	defer client.Close()
+	defer server.Close()
+
+	go connector.Run(client) // code under test
+
+	msg, err := server.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+*/
+package mlitest
+
+import (
+	"net"
+	"sync"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// Conn is an MLI-framed connection endpoint. Conn embeds a net.Conn so it can be passed to code expecting a raw
+// connection while the test reads and writes whole messages.
+type Conn struct {
+	net.Conn
+	key string
+
+	// wmu serializes message writes so frames are never interleaved
+	wmu sync.Mutex
+}
+
+// NewConn wraps conn as a framed endpoint using the MLI type key.
+func NewConn(conn net.Conn, key string) *Conn {
+	return &Conn{Conn: conn, key: key}
+}
+
+// Pipe returns two connected framed endpoints backed by net.Pipe. As with net.Pipe, writes are synchronous and block
+// until the other endpoint has read the data.
+func Pipe(key string) (*Conn, *Conn) {
+	a, b := net.Pipe()
+	return NewConn(a, key), NewConn(b, key)
+}
+
+// Key returns the MLI type of the connection.
+func (c *Conn) Key() string {
+	return c.key
+}
+
+// ReadMessage reads the next framed message and returns it without the MLI.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	return simplemli.ReadFrame(c.Conn, c.key)
+}
+
+// WriteMessage frames msg with an MLI and writes it to the connection.
+func (c *Conn) WriteMessage(msg []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return simplemli.WriteFrame(c.Conn, c.key, msg)
+}
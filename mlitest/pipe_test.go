@@ -0,0 +1,59 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"io"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestPipe(t *testing.T) {
+	for _, key := range []string{simplemli.MLI2I, simplemli.MLI4E, simplemli.MLIA4E} {
+		t.Run(key, func(t *testing.T) {
+			a, b := Pipe(key)
+			defer a.Close()
+			defer b.Close()
+
+			go func() {
+				_ = a.WriteMessage([]byte("request"))
+			}()
+
+			msg, err := b.ReadMessage()
+			if err != nil || string(msg) != "request" {
+				t.Errorf("Unexpected message, got %q, %v", msg, err)
+			}
+
+			// The raw connection carries the MLI
+			go func() {
+				_ = b.WriteMessage([]byte("ok"))
+			}()
+			raw := make([]byte, 64)
+			n, err := io.ReadAtLeast(a.Conn, raw, 2)
+			if err != nil || n <= 2 {
+				t.Errorf("Expected framed bytes on raw connection, got %x, %v", raw[:n], err)
+			}
+		})
+	}
+
+	t.Run("Closed", func(t *testing.T) {
+		a, b := Pipe(simplemli.MLI2I)
+		b.Close()
+		_, err := a.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF reading from closed pipe, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// exhaustiveLimit is the largest length range CheckCodec tests exhaustively, larger ranges are sampled
+const exhaustiveLimit = 1 << 17
+
+// propertySamples is the number of random lengths and random MLIs checked by CheckCodec
+const propertySamples = 100000
+
+// CheckCodec asserts the properties every Codec must hold for messages of minLen to maxLen bytes:
+//
+//   - Decode of an encoded length returns the same length, across the full valid range. Ranges larger than 128KiB are
+//     checked at the edges and a deterministic random sample.
+//   - Encode of a length just outside the range returns an error rather than a truncated MLI.
+//   - Decode of random bytes either returns an error or a length within the range.
+//
+// CheckCodec reports the first failure of each property and is intended to be run in CI against custom codecs.
+//
+//	func TestMyCodec(t *testing.T) {
+//		mlitest.CheckCodec(t, myCodec{}, 0, 99999)
+//	}
+func CheckCodec(t testing.TB, c simplemli.Codec, minLen, maxLen int) {
+	t.Helper()
+	rnd := rand.New(rand.NewSource(1))
+	buf := make([]byte, c.Size())
+
+	roundTrip := func(n int) bool {
+		err := c.Encode(n, buf)
+		if err != nil {
+			t.Errorf("Unable to encode valid length %d - %s", n, err)
+			return false
+		}
+		got, err := c.Decode(buf)
+		if err != nil || got != n {
+			t.Errorf("Encode/Decode round trip of %d returned %d, %v (MLI %x)", n, got, err, buf)
+			return false
+		}
+		return true
+	}
+
+	if maxLen-minLen <= exhaustiveLimit {
+		for n := minLen; n <= maxLen; n++ {
+			if !roundTrip(n) {
+				break
+			}
+		}
+	} else {
+		ok := roundTrip(minLen) && roundTrip(minLen+1) && roundTrip(maxLen-1) && roundTrip(maxLen)
+		span := int64(maxLen - minLen)
+		for i := 0; i < propertySamples && ok; i++ {
+			// A span of the full int64 range cannot be incremented, Int63 already covers it
+			if span == math.MaxInt64 {
+				ok = roundTrip(minLen + int(rnd.Int63()))
+				continue
+			}
+			ok = roundTrip(minLen + int(rnd.Int63n(span+1)))
+		}
+	}
+
+	// maxLen may already be the largest int for wide MLIs on 32-bit platforms
+	if maxLen < math.MaxInt {
+		if err := c.Encode(maxLen+1, buf); err == nil {
+			t.Errorf("Encode of out of range length %d returned MLI %x, expected an error", maxLen+1, buf)
+		}
+	}
+	if minLen > 0 {
+		if err := c.Encode(minLen-1, buf); err == nil {
+			t.Errorf("Encode of out of range length %d returned MLI %x, expected an error", minLen-1, buf)
+		}
+	}
+
+	for i := 0; i < propertySamples; i++ {
+		_, _ = rnd.Read(buf)
+		n, err := c.Decode(buf)
+		if err == nil && (n < minLen || n > maxLen) {
+			t.Errorf("Decode of random MLI %x returned out of range length %d", buf, n)
+			break
+		}
+	}
+}
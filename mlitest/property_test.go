@@ -0,0 +1,62 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// lossyCodec is a Codec which truncates lengths above 255 and decodes lengths up to 65535
+type lossyCodec struct{}
+
+func (lossyCodec) Size() int {
+	return 2
+}
+
+func (lossyCodec) Encode(length int, dst []byte) error {
+	dst[0], dst[1] = 0, byte(length)
+	return nil
+}
+
+func (lossyCodec) Decode(src []byte) (int, error) {
+	return int(src[0])<<8 | int(src[1]), nil
+}
+
+func TestCheckCodec(t *testing.T) {
+	for _, k := range framing.Keys() {
+		t.Run(k, func(t *testing.T) {
+			c, err := simplemli.New(k)
+			if err != nil {
+				t.Fatalf("Unable to create codec - %s", err)
+			}
+			lo, hi, err := framing.Bounds(k)
+			if err != nil {
+				t.Fatalf("Unable to get bounds - %s", err)
+			}
+			CheckCodec(t, c, lo, hi)
+		})
+	}
+
+	t.Run("Broken codec", func(t *testing.T) {
+		ft := &fakeTB{TB: t}
+		CheckCodec(ft, lossyCodec{}, 0, 1000)
+		// Round trip of 256, rejecting 1001 and decoding within range all fail
+		if ft.errors != 3 {
+			t.Errorf("Expected 3 property failures, got %d", ft.errors)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/americanexpress/simplemli"
+)
+
+// Responder is a scripted host simulator which answers inbound frames from a set of request/response pairs.
+// Requests are matched in the order they were added, either exactly or by prefix, falling back to a default response.
+//
+// Pairs are commonly loaded from fixture files with one pair per line, written as hex with optional spaces:
+//
+//	# logon
+//	0800 8220000000000000 0400000000000000 301 => 0810 8220000002000000 0400000000000000 00 301
+//	# any authorization request, matched by prefix
+//	0100* => 0110 7220000002000000 00
+//	# everything else
+//	* => 0810 8220000000000000 0400000000000000 301
+//
+// Blank lines and lines starting with # are ignored.
+type Responder struct {
+	key string
+
+	mu    sync.Mutex
+	rules []rule
+	def   []byte
+}
+
+// rule is a single request/response pair
+type rule struct {
+	request  []byte
+	prefix   bool
+	response []byte
+}
+
+// NewResponder returns a Responder with no pairs for the MLI type key.
+func NewResponder(key string) *Responder {
+	return &Responder{key: key}
+}
+
+// Add answers messages equal to request with response.
+func (r *Responder) Add(request, response []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rule{request: request, response: response})
+}
+
+// AddPrefix answers messages starting with prefix with response.
+func (r *Responder) AddPrefix(prefix, response []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rule{request: prefix, prefix: true, response: response})
+}
+
+// SetDefault answers messages matching no pair with response. If response is nil, unmatched messages are not answered.
+func (r *Responder) SetDefault(response []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.def = response
+}
+
+// LoadFile adds the pairs in the fixture file at path.
+func (r *Responder) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = r.Load(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Load adds the pairs read from rd in fixture file format.
+func (r *Responder) Load(rd io.Reader) error {
+	s := bufio.NewScanner(rd)
+	s.Buffer(nil, 1<<20)
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, "=>", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %d: expected request => response", line)
+		}
+		resp, err := parseHex(parts[1])
+		if err != nil {
+			return fmt.Errorf("line %d: invalid response - %w", line, err)
+		}
+
+		req := strings.TrimSpace(parts[0])
+		if req == "*" {
+			r.SetDefault(resp)
+			continue
+		}
+		prefix := strings.HasSuffix(req, "*")
+		b, err := parseHex(strings.TrimSuffix(req, "*"))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid request - %w", line, err)
+		}
+		if prefix {
+			r.AddPrefix(b, resp)
+		} else {
+			r.Add(b, resp)
+		}
+	}
+	return s.Err()
+}
+
+// Respond returns the response for msg, or false if msg matches no pair and there is no default.
+func (r *Responder) Respond(msg []byte) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rl := range r.rules {
+		if rl.prefix && bytes.HasPrefix(msg, rl.request) || !rl.prefix && bytes.Equal(msg, rl.request) {
+			return rl.response, true
+		}
+	}
+	return r.def, r.def != nil
+}
+
+// Serve answers frames read from conn until the connection is closed. Serve returns nil when the peer closes the
+// connection.
+func (r *Responder) Serve(conn net.Conn) error {
+	for {
+		msg, err := simplemli.ReadFrame(conn, r.key)
+		if err != nil {
+			if err == io.EOF || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		resp, ok := r.Respond(msg)
+		if !ok {
+			continue
+		}
+		err = simplemli.WriteFrame(conn, r.key, resp)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ServeListener accepts connections from ln and serves each in its own goroutine until ln is closed.
+func (r *Responder) ServeListener(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			_ = r.Serve(conn)
+		}()
+	}
+}
+
+// parseHex decodes hex allowing spaces between bytes and a 0x prefix
+func parseHex(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return hex.DecodeString(s)
+}
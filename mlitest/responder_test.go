@@ -0,0 +1,122 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package mlitest
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/americanexpress/simplemli"
+)
+
+func TestResponder(t *testing.T) {
+	fixture := `
+# logon
+0800 01 => 0810 01
+
+0100* => 0110
+* => 0x0810ff
+`
+	path := filepath.Join(t.TempDir(), "host.fixture")
+	err := os.WriteFile(path, []byte(fixture), 0600)
+	if err != nil {
+		t.Fatalf("Unable to write fixture - %s", err)
+	}
+
+	r := NewResponder(simplemli.MLI2I)
+	err = r.LoadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading fixture - %s", err)
+	}
+
+	t.Run("Respond", func(t *testing.T) {
+		cases := map[string]string{
+			"\x08\x00\x01":     "\x08\x10\x01",
+			"\x01\x00\x12\x34": "\x01\x10",
+			"\x08\x00\x02":     "\x08\x10\xff",
+		}
+		for req, want := range cases {
+			resp, ok := r.Respond([]byte(req))
+			if !ok || string(resp) != want {
+				t.Errorf("Unexpected response to %x, got %x, %v expected %x", req, resp, ok, want)
+			}
+		}
+	})
+
+	t.Run("No default", func(t *testing.T) {
+		r := NewResponder(simplemli.MLI2I)
+		r.Add([]byte("ping"), []byte("pong"))
+		if _, ok := r.Respond([]byte("other")); ok {
+			t.Errorf("Expected no response without a default")
+		}
+	})
+
+	t.Run("Serve", func(t *testing.T) {
+		client, server := Pipe(simplemli.MLI2I)
+		done := make(chan error, 1)
+		go func() { done <- r.Serve(server.Conn) }()
+
+		err := client.WriteMessage([]byte{0x08, 0x00, 0x01})
+		if err != nil {
+			t.Fatalf("Unexpected error writing request - %s", err)
+		}
+		resp, err := client.ReadMessage()
+		if err != nil || string(resp) != "\x08\x10\x01" {
+			t.Errorf("Unexpected response, got %x, %v", resp, err)
+		}
+
+		client.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected error from Serve - %s", err)
+		}
+	})
+
+	t.Run("Serve Listener", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Unable to listen - %s", err)
+		}
+		done := make(chan error, 1)
+		go func() { done <- r.ServeListener(ln) }()
+
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("Unable to dial - %s", err)
+		}
+		conn := NewConn(c, simplemli.MLI2I)
+		_ = conn.WriteMessage([]byte{0x01, 0x00})
+		resp, err := conn.ReadMessage()
+		if err != nil || string(resp) != "\x01\x10" {
+			t.Errorf("Unexpected response, got %x, %v", resp, err)
+		}
+		conn.Close()
+
+		ln.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected error from ServeListener - %s", err)
+		}
+	})
+
+	t.Run("Invalid fixture", func(t *testing.T) {
+		for _, f := range []string{"0800", "zz => 0810", "0800 => zz"} {
+			err := NewResponder(simplemli.MLI2I).Load(strings.NewReader(f))
+			if err == nil {
+				t.Errorf("Expected error loading %q - got nil", f)
+			}
+		}
+	})
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package mlitest
+
+import (
+	"github.com/americanexpress/simplemli"
+	"github.com/americanexpress/simplemli/internal/framing"
+)
+
+// frameTracker follows frame boundaries in a written byte stream, allowing wrappers to act once per frame even when
+// frames are split across or combined within writes
+type frameTracker struct {
+	key  string
+	size int
+
+	// hdr holds a partially written MLI and remaining the unwritten bytes of the current message
+	hdr       []byte
+	remaining int
+
+	// broken is set once the stream stops forming valid frames
+	broken bool
+}
+
+// segment is a run of written bytes which lies within a single frame
+type segment struct {
+	// n is the length of the run
+	n int
+
+	// start is set when the run begins a new frame, or for every run once the stream is broken
+	start bool
+
+	// mli is set when the run is part of an MLI, offset is the position of the run within the MLI
+	mli    bool
+	offset int
+}
+
+func newFrameTracker(key string) frameTracker {
+	size, err := framing.Size(key)
+	return frameTracker{key: key, size: size, broken: err != nil}
+}
+
+// next returns the next segment of p and advances the tracker past it
+func (f *frameTracker) next(p []byte) segment {
+	if f.broken {
+		return segment{n: len(p), start: true}
+	}
+
+	if f.remaining > 0 {
+		n := len(p)
+		if n > f.remaining {
+			n = f.remaining
+		}
+		f.remaining -= n
+		return segment{n: n}
+	}
+
+	s := segment{start: len(f.hdr) == 0, mli: true, offset: len(f.hdr)}
+	s.n = f.size - len(f.hdr)
+	if s.n > len(p) {
+		s.n = len(p)
+	}
+	f.hdr = append(f.hdr, p[:s.n]...)
+	if len(f.hdr) == f.size {
+		length, err := simplemli.DecodeBytes(f.key, f.hdr)
+		if err != nil {
+			f.broken = true
+		}
+		f.remaining = length
+		f.hdr = f.hdr[:0]
+	}
+	return s
+}
@@ -0,0 +1,206 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CorrelationFunc extracts the correlation identifier from a message, for ISO 8583 this is commonly built from fields
+// such as the STAN and terminal ID. The same function is applied to requests and responses, a response is routed to
+// the request with an equal identifier.
+type CorrelationFunc func(msg []byte) (string, error)
+
+// ErrMuxClosed reports an attempt to use a Mux after it has been closed.
+var ErrMuxClosed = fmt.Errorf("mux is closed")
+
+// ErrDuplicateCorrelation reports a request whose correlation identifier matches a request which is still awaiting
+// a response.
+var ErrDuplicateCorrelation = fmt.Errorf("correlation identifier already in flight")
+
+// Mux shares a single MLI-framed connection among many concurrent callers. Each request is written to the connection
+// and the caller waits for the response with the same correlation identifier, allowing many logical clients to
+// multiplex over a limited number of sessions.
+//
+//	m := simplemli.NewMux(conn, simplemli.MLI2I, correlate)
+//	defer m.Close()
+//
+//	resp, err := m.RoundTrip(ctx, msg)
+//	if err != nil {
+//		// Do something
+//	}
+//
+// Messages passed to and returned from a Mux exclude the MLI.
+type Mux struct {
+	rw        io.ReadWriter
+	key       string
+	correlate CorrelationFunc
+	opts      []DecodeOption
+
+	// wmu serializes writes so frames are never interleaved
+	wmu sync.Mutex
+
+	mu        sync.Mutex
+	pending   map[string]chan []byte
+	unmatched func(msg []byte)
+	closed    bool
+	err       error
+	done      chan struct{}
+}
+
+// NewMux returns a Mux framing messages on rw with the MLI type key and starts reading responses. If rw implements
+// io.Closer it is closed by Mux.Close. Options such as MaxLength are applied to every inbound MLI, without MaxLength
+// the Mux allocates whatever length the MLI describes, so set a limit for untrusted peers.
+//
+//	m := simplemli.NewMux(conn, simplemli.MLI2I, correlate, simplemli.MaxLength(8192))
+func NewMux(rw io.ReadWriter, key string, correlate CorrelationFunc, opts ...DecodeOption) *Mux {
+	m := &Mux{
+		rw:        rw,
+		key:       key,
+		correlate: correlate,
+		opts:      opts,
+		pending:   make(map[string]chan []byte),
+		done:      make(chan struct{}),
+	}
+	go m.readLoop()
+	return m
+}
+
+// HandleUnmatched registers a func called with inbound messages which do not correlate to an outstanding request,
+// such as late responses or unsolicited network management messages. Unmatched messages are dropped by default.
+func (m *Mux) HandleUnmatched(fn func(msg []byte)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unmatched = fn
+}
+
+// RoundTrip writes msg to the connection and waits for the correlated response, the context deadline or the
+// connection failing.
+func (m *Mux) RoundTrip(ctx context.Context, msg []byte) ([]byte, error) {
+	id, err := m.correlate(msg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to correlate request - %w", err)
+	}
+
+	ch := make(chan []byte, 1)
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrMuxClosed
+	}
+	if m.err != nil {
+		m.mu.Unlock()
+		return nil, m.err
+	}
+	if _, ok := m.pending[id]; ok {
+		m.mu.Unlock()
+		return nil, ErrDuplicateCorrelation
+	}
+	m.pending[id] = ch
+	m.mu.Unlock()
+
+	m.wmu.Lock()
+	err = WriteFrame(m.rw, m.key, msg)
+	m.wmu.Unlock()
+	if err != nil {
+		m.forget(id)
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		m.forget(id)
+		return nil, ctx.Err()
+	case <-m.done:
+		m.forget(id)
+		return nil, m.Err()
+	}
+}
+
+// Err returns the error which stopped the Mux, or nil if it is still running.
+func (m *Mux) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// Done returns a channel which is closed once the Mux stops reading from the connection.
+func (m *Mux) Done() <-chan struct{} {
+	return m.done
+}
+
+// Close stops the Mux by closing the underlying connection, outstanding requests return ErrMuxClosed. If the
+// connection does not implement io.Closer, Close only marks the Mux as closed and the caller must stop the connection.
+func (m *Mux) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	c, ok := m.rw.(io.Closer)
+	if !ok {
+		return nil
+	}
+	err := c.Close()
+	<-m.done
+	return err
+}
+
+// forget removes an outstanding request
+func (m *Mux) forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, id)
+}
+
+// readLoop routes inbound messages to waiting callers until the connection fails
+func (m *Mux) readLoop() {
+	for {
+		msg, err := ReadFrame(m.rw, m.key, m.opts...)
+		if err != nil {
+			m.mu.Lock()
+			if m.closed {
+				err = ErrMuxClosed
+			}
+			m.err = err
+			m.mu.Unlock()
+			close(m.done)
+			return
+		}
+
+		id, err := m.correlate(msg)
+		m.mu.Lock()
+		ch, ok := m.pending[id]
+		if err == nil && ok {
+			delete(m.pending, id)
+		}
+		unmatched := m.unmatched
+		m.mu.Unlock()
+
+		if err == nil && ok {
+			ch <- msg
+			continue
+		}
+		if unmatched != nil {
+			unmatched(msg)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// correlateFirst4 correlates on the first 4 bytes of a message
+func correlateFirst4(msg []byte) (string, error) {
+	if len(msg) < 4 {
+		return "", fmt.Errorf("message too short")
+	}
+	return string(msg[:4]), nil
+}
+
+func TestMux(t *testing.T) {
+	client, server := net.Pipe()
+	m := NewMux(client, MLI2I, correlateFirst4)
+
+	// Server collects a batch of requests and responds in reverse order
+	batch := 5
+	go func() {
+		var reqs [][]byte
+		for i := 0; i < batch; i++ {
+			msg, err := ReadFrame(server, MLI2I)
+			if err != nil {
+				return
+			}
+			reqs = append(reqs, msg)
+		}
+		for i := len(reqs) - 1; i >= 0; i-- {
+			_ = WriteFrame(server, MLI2I, append(reqs[i], []byte(" response")...))
+		}
+		_ = WriteFrame(server, MLI2I, []byte("9999 unsolicited"))
+	}()
+
+	unmatched := make(chan []byte, 1)
+	m.HandleUnmatched(func(msg []byte) { unmatched <- msg })
+
+	var wg sync.WaitGroup
+	for i := 0; i < batch; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := []byte(fmt.Sprintf("%04d request", i))
+			resp, err := m.RoundTrip(context.Background(), req)
+			if err != nil {
+				t.Errorf("Unexpected error from RoundTrip - %s", err)
+				return
+			}
+			if string(resp) != string(req)+" response" {
+				t.Errorf("Response routed to wrong caller, got %s for %s", resp, req)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case msg := <-unmatched:
+		if string(msg) != "9999 unsolicited" {
+			t.Errorf("Unexpected unmatched message %s", msg)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Timed out waiting for unmatched message")
+	}
+
+	t.Run("Context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		go func() { _, _ = ReadFrame(server, MLI2I) }()
+		_, err := m.RoundTrip(ctx, []byte("0001 no response"))
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		err := m.Close()
+		if err != nil {
+			t.Errorf("Unexpected error closing mux - %s", err)
+		}
+		_, err = m.RoundTrip(context.Background(), []byte("0002 closed"))
+		if err != ErrMuxClosed {
+			t.Errorf("Expected ErrMuxClosed, got %v", err)
+		}
+	})
+}
+
+func TestMuxDuplicateCorrelation(t *testing.T) {
+	client, server := net.Pipe()
+	m := NewMux(client, MLI2I, correlateFirst4)
+	defer m.Close()
+
+	go func() {
+		for {
+			_, err := ReadFrame(server, MLI2I)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		close(started)
+		_, _ = m.RoundTrip(ctx, []byte("0001 first"))
+	}()
+	<-started
+
+	// Wait for the first request to register
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		_, err := m.RoundTrip(ctx, []byte("0001 second"))
+		if err == ErrDuplicateCorrelation {
+			cancel()
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	t.Errorf("Expected ErrDuplicateCorrelation for in-flight correlation identifier")
+}
+
+func TestMuxMaxLength(t *testing.T) {
+	client, server := net.Pipe()
+	m := NewMux(client, MLI2I, correlateFirst4, MaxLength(100))
+	defer m.Close()
+
+	go func() { _, _ = server.Write([]byte{0xff, 0xff}) }()
+	select {
+	case <-m.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Mux did not stop reading an oversized frame")
+	}
+	if !errors.Is(m.Err(), ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", m.Err())
+	}
+}
@@ -0,0 +1,175 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Dial connects to address on the named network and returns the connection as a MessageConn framing messages with the
+// MLI type key. Socket options such as WithNoDelay are applied to TCP connections before Dial returns.
+//
+//	conn, err := simplemli.Dial("tcp", "host:9100", simplemli.MLI2I, simplemli.WithNoDelay(true))
+//	if err != nil {
+//		// Do something
+//	}
+func Dial(network, address, key string, opts ...Option) (*MessageConn, error) {
+	return DialContext(context.Background(), network, address, key, opts...)
+}
+
+// DialTimeout is Dial with a limit on the time taken to connect, the equivalent of WithDialTimeout.
+//
+//	conn, err := simplemli.DialTimeout("tcp", "host:9100", simplemli.MLI2I, 5*time.Second)
+func DialTimeout(network, address, key string, timeout time.Duration, opts ...Option) (*MessageConn, error) {
+	return Dial(network, address, key, append(opts[:len(opts):len(opts)], WithDialTimeout(timeout))...)
+}
+
+// DialContext is Dial with a context which cancels the connection attempt. When address is a host name resolving to
+// both IPv4 and IPv6 addresses, DialContext attempts both families concurrently in the style of Happy Eyeballs (RFC
+// 6555), starting the fallback family after the delay set by WithFallbackDelay. The whole attempt is limited by
+// WithDialTimeout.
+func DialContext(ctx context.Context, network, address, key string, opts ...Option) (*MessageConn, error) {
+	conn, err := dialTuned(ctx, network, address, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return NewMessageConn(conn, key, opts...), nil
+}
+
+// dialTuned connects to address and applies socket options
+func dialTuned(ctx context.Context, network, address string, o options) (net.Conn, error) {
+	d := net.Dialer{Timeout: o.dialTimeout, FallbackDelay: o.fallbackDelay}
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tune(conn, o)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Listener is a net.Listener which applies socket options to each accepted connection and can wrap them as
+// MessageConns.
+type Listener struct {
+	net.Listener
+	key  string
+	opts []Option
+}
+
+// Listen announces on the local network address and returns a Listener for connections framed with the MLI type key.
+//
+//	ln, err := simplemli.Listen("tcp", ":9000", simplemli.MLI2I, simplemli.WithNoDelay(true))
+//	if err != nil {
+//		// Do something
+//	}
+//	for {
+//		conn, err := ln.AcceptMessageConn()
+//		// ...
+//	}
+func Listen(network, address, key string, opts ...Option) (*Listener, error) {
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(ln, key, opts...), nil
+}
+
+// NewListener wraps an existing listener, such as one returned by tls.Listen, so accepted connections are framed with
+// the MLI type key and configured with opts, including limits such as WithMaxLength.
+//
+//	ln := simplemli.NewListener(tlsListener, simplemli.MLI2I, simplemli.WithMaxLength(8192))
+//	conn, err := ln.AcceptMessageConn()
+//
+// Accept returns the plain net.Conn so the Listener still satisfies net.Listener, AcceptMessageConn returns a
+// MessageConn.
+func NewListener(ln net.Listener, key string, opts ...Option) *Listener {
+	return &Listener{Listener: ln, key: key, opts: opts}
+}
+
+// Accept waits for the next connection and applies the configured socket options to it.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tune(conn, newOptions(l.opts))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// AcceptMessageConn waits for the next connection and returns it as a MessageConn.
+func (l *Listener) AcceptMessageConn() (*MessageConn, error) {
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewMessageConn(conn, l.key, l.opts...), nil
+}
+
+// tune applies socket options to conn, connections other than TCP are left unchanged
+func tune(conn net.Conn, o options) error {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	if o.noDelaySet {
+		err := tc.SetNoDelay(o.noDelay)
+		if err != nil {
+			return err
+		}
+	}
+	if o.keepAlive < 0 {
+		err := tc.SetKeepAlive(false)
+		if err != nil {
+			return err
+		}
+	}
+	if o.keepAlive > 0 {
+		err := tc.SetKeepAlive(true)
+		if err != nil {
+			return err
+		}
+		err = tc.SetKeepAlivePeriod(o.keepAlive)
+		if err != nil {
+			return err
+		}
+	}
+	if o.socketReadBuffer > 0 {
+		err := tc.SetReadBuffer(o.socketReadBuffer)
+		if err != nil {
+			return err
+		}
+	}
+	if o.socketWriteBuffer > 0 {
+		err := tc.SetWriteBuffer(o.socketWriteBuffer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDialListen(t *testing.T) {
+	opts := []Option{WithNoDelay(true), WithKeepAlive(30 * time.Second), WithSocketBuffers(64<<10, 64<<10)}
+	ln, err := Listen("tcp", "127.0.0.1:0", MLI2I, opts...)
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.AcceptMessageConn()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg, err := conn.ReadMessage()
+		if err == nil {
+			_ = conn.WriteMessage(append(msg, '!'))
+		}
+	}()
+
+	conn, err := Dial("tcp", ln.Addr().String(), MLI2I, append(opts, WithReadTimeout(time.Second))...)
+	if err != nil {
+		t.Fatalf("Unable to dial - %s", err)
+	}
+	defer conn.Close()
+
+	err = conn.WriteMessage([]byte("0800"))
+	if err != nil {
+		t.Fatalf("Unexpected error writing - %s", err)
+	}
+	msg, err := conn.ReadMessage()
+	if err != nil || string(msg) != "0800!" {
+		t.Errorf("Unexpected response, got %q, %v", msg, err)
+	}
+
+	_, err = Dial("tcp", "127.0.0.1:0", MLI2I, WithKeepAlive(-1))
+	if err == nil {
+		t.Errorf("Expected error dialing invalid address - got nil")
+	}
+}
+
+func TestNewListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	ln := NewListener(inner, MLI4E, WithMaxLength(8))
+	defer ln.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		conn, err := ln.AcceptMessageConn()
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+		_, err = conn.ReadMessage()
+		errs <- err
+	}()
+
+	conn, err := Dial("tcp", ln.Addr().String(), MLI4E)
+	if err != nil {
+		t.Fatalf("Unable to dial - %s", err)
+	}
+	defer conn.Close()
+
+	err = conn.WriteMessage([]byte("too long for the listener"))
+	if err != nil {
+		t.Fatalf("Unexpected error writing - %s", err)
+	}
+	err = <-errs
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected the accepted conn to enforce its max length, got %v", err)
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	ln, err := Listen("tcp", "127.0.0.1:0", MLI2I)
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	defer ln.Close()
+	go acceptAll(ln)
+
+	conn, err := DialTimeout("tcp", ln.Addr().String(), MLI2I, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error dialing - %s", err)
+	}
+	conn.Close()
+
+	_, err = DialTimeout("tcp", "127.0.0.1:0", MLI2I, time.Second)
+	if err == nil {
+		t.Errorf("Expected error dialing invalid address - got nil")
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	defer ln.Close()
+	go acceptAll(ln)
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	t.Run("Dual Stack", func(t *testing.T) {
+		// localhost may resolve to ::1 first, which refuses the connection and falls back to IPv4
+		conn, err := DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port), MLI2I,
+			WithDialTimeout(time.Second), WithFallbackDelay(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Unexpected error dialing - %s", err)
+		}
+		conn.Close()
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := DialContext(ctx, "tcp", ln.Addr().String(), MLI2I)
+		if err == nil {
+			t.Errorf("Expected error dialing with a cancelled context - got nil")
+		}
+	})
+}
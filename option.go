@@ -0,0 +1,323 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"net"
+	"time"
+)
+
+// Option configures a Reader, Writer or MessageConn. Options which do not apply to a type are ignored by its
+// constructor, so one set of options can be shared by all three. Socket options are applied by Dial and Listen.
+//
+//	| Option               | Reader | Writer | MessageConn |
+//	| -------------------- | ------ | ------ | ----------- |
+//	| WithMaxLength        | yes    |        | yes         |
+//	| WithReadTimeout      |        |        | yes         |
+//	| WithWriteTimeout     |        |        | yes         |
+//	| WithBufferSize       | yes    | yes    | yes         |
+//	| WithReadHook         | yes    |        | yes         |
+//	| WithWriteHook        |        | yes    | yes         |
+//	| WithLogger           | yes    | yes    | yes         |
+//	| WithTag              | yes    |        | yes         |
+//	| WithInterFrameFiller | yes    |        | yes         |
+//	| WithSpacePadded      | yes    |        | yes         |
+//	| WithSequence         | yes    | yes    | yes         |
+//	| WithIdleTimeout      |        |        | yes         |
+//	| WithReadAhead        | yes    |        | yes         |
+//	| WithMemoryBudget     | yes    |        | yes         |
+//	| WithTee              | yes    | yes    | yes         |
+//	| WithAuditLog         | yes    | yes    | yes         |
+//	| WithKeepaliveFrame   |        | yes    | yes         |
+//	| WithRateLimit        |        | yes    | yes         |
+//	| WithDialTimeout      |        |        | Dial        |
+//	| WithFallbackDelay    |        |        | Dial        |
+//	| WithNoDelay          |        |        | Dial/Listen |
+//	| WithKeepAlive        |        |        | Dial/Listen |
+//	| WithSocketBuffers    |        |        | Dial/Listen |
+type Option func(*options)
+
+// options holds the settings applied by Option funcs
+type options struct {
+	maxLength    int
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	bufferSize   int
+	onRead       func(msg []byte)
+	onWrite      func(msg []byte)
+	logger       Logger
+	tag          string
+	filler       byte
+	fillerSet    bool
+	spacePadded  bool
+	sequence     *sequence
+	idleTimeout  time.Duration
+	onIdle       func(conn net.Conn)
+	readAhead    int
+	budget       *MemoryBudget
+	tee          *Tee
+	audit        *AuditLog
+	keepalive    []byte
+	keepaliveInt time.Duration
+	rate         float64
+	ratePolicy   RatePolicy
+	rateSet      bool
+
+	dialTimeout       time.Duration
+	fallbackDelay     time.Duration
+	noDelay           bool
+	noDelaySet        bool
+	keepAlive         time.Duration
+	socketReadBuffer  int
+	socketWriteBuffer int
+}
+
+// defaultBufferSize is the read buffer size used when an option requires buffering and WithBufferSize is not set
+const defaultBufferSize = 4096
+
+// newOptions applies opts over the defaults
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// logf writes to the configured logger, if any
+func (o *options) logf(format string, v ...interface{}) {
+	if o.logger != nil {
+		o.logger.Printf(format, v...)
+	}
+}
+
+// Logger receives diagnostic messages, such as framing errors. A *log.Logger satisfies Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithMaxLength rejects inbound messages longer than n bytes with ErrTooLarge before the message is allocated, zero
+// means no limit beyond the MLI type.
+func WithMaxLength(n int) Option {
+	return func(o *options) {
+		o.maxLength = n
+	}
+}
+
+// WithReadTimeout sets a read deadline of d before each message is read from a MessageConn.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = d
+	}
+}
+
+// WithWriteTimeout sets a write deadline of d before each message is written to a MessageConn.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = d
+	}
+}
+
+// WithBufferSize buffers reads from the underlying reader with a buffer of n bytes and sizes the initial frame buffer
+// used for writes.
+func WithBufferSize(n int) Option {
+	return func(o *options) {
+		o.bufferSize = n
+	}
+}
+
+// WithReadHook calls fn with each message read, after the MLI has been removed.
+func WithReadHook(fn func(msg []byte)) Option {
+	return func(o *options) {
+		o.onRead = fn
+	}
+}
+
+// WithWriteHook calls fn with each message successfully written, without the MLI.
+func WithWriteHook(fn func(msg []byte)) Option {
+	return func(o *options) {
+		o.onWrite = fn
+	}
+}
+
+// WithLogger reports framing errors to l.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithTag sets the tag carried by frames read with ReadFrame, identifying the connection they arrived on for audit
+// and routing layers.
+func WithTag(tag string) Option {
+	return func(o *options) {
+		o.tag = tag
+	}
+}
+
+// WithInterFrameFiller skips runs of the filler byte b found between frames, for hosts which pad the stream with NUL
+// or 0xFF bytes. Filler is only recognized where an MLI is expected, so b must not be a valid first byte of an MLI in
+// the stream; NUL filler is ambiguous with binary MLIs for messages shorter than 256 bytes.
+func WithInterFrameFiller(b byte) Option {
+	return func(o *options) {
+		o.filler = b
+		o.fillerSet = true
+	}
+}
+
+// WithSpacePadded accepts inbound decimal MLIs padded with leading spaces, see SpacePadded. The option has no effect
+// on other MLI types or on codecs built with CodecOptions.
+func WithSpacePadded() Option {
+	return func(o *options) {
+		o.spacePadded = true
+	}
+}
+
+// WithSequence inserts a big-endian sequence number of size bytes at offset within each message written, starting at 1
+// and wrapping to 0 when the field overflows, and removes it from each message read. Reads check that every sequence
+// number follows the previous one and call onGap with the expected and received numbers when a gap or duplicate is
+// found; the received number becomes the new baseline. size must be between 1 and 8, otherwise reads and writes
+// return ErrSequence.
+//
+//	// 4-byte sequence following a 2-byte header
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithSequence(2, 4, func(expected, got uint64) {
+//		log.Printf("sequence gap, expected %d got %d", expected, got)
+//	}))
+func WithSequence(offset, size int, onGap func(expected, got uint64)) Option {
+	return func(o *options) {
+		o.sequence = &sequence{offset: offset, size: size, onGap: onGap}
+	}
+}
+
+// WithIdleTimeout closes a MessageConn when no message has been read or written for d, so abandoned sockets from
+// crashed terminals do not accumulate. If onIdle is not nil it is called instead of closing the connection, allowing
+// the caller to log, send an echo test or close the connection itself. The idle period restarts with the next message.
+//
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithIdleTimeout(5*time.Minute, nil))
+func WithIdleTimeout(d time.Duration, onIdle func(conn net.Conn)) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+		o.onIdle = onIdle
+	}
+}
+
+// WithReadAhead reads from the underlying reader in a background goroutine, queueing up to n reads of the buffer size
+// while the application processes the current frame. This smooths latency on links where frames arrive in bursts, at
+// the cost of one goroutine and up to n buffers per Reader. The goroutine exits when the underlying reader returns an
+// error other than a timeout, or when the MessageConn is closed, so reads which time out or are cancelled through a
+// context can be retried as they can without read-ahead.
+func WithReadAhead(n int) Option {
+	return func(o *options) {
+		o.readAhead = n
+	}
+}
+
+// WithMemoryBudget reserves each inbound message's length from b while the message is being read, capping the memory
+// used by frames in flight across every Reader sharing b. The reservation is released once the message has been read,
+// messages retained by the application afterwards are not counted. A read waiting for the budget returns
+// net.ErrClosed if the MessageConn is closed, or ctx.Err() from ReadMessageContext if ctx is done.
+func WithMemoryBudget(b *MemoryBudget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithNoDelay sets TCP_NODELAY on connections created by Dial and Listen. Disabling Nagle's algorithm avoids delays
+// when frames are written in small pieces on low volume links; Go enables TCP_NODELAY by default.
+func WithNoDelay(noDelay bool) Option {
+	return func(o *options) {
+		o.noDelay = noDelay
+		o.noDelaySet = true
+	}
+}
+
+// WithKeepAlive sets the TCP keepalive period of connections created by Dial and Listen, a negative d disables TCP
+// keepalives.
+func WithKeepAlive(d time.Duration) Option {
+	return func(o *options) {
+		o.keepAlive = d
+	}
+}
+
+// WithSocketBuffers sets the operating system receive (SO_RCVBUF) and send (SO_SNDBUF) buffer sizes of connections
+// created by Dial and Listen, zero leaves a size unchanged.
+func WithSocketBuffers(read, write int) Option {
+	return func(o *options) {
+		o.socketReadBuffer = read
+		o.socketWriteBuffer = write
+	}
+}
+
+// WithTee mirrors every frame read or written, including its MLI, to t. Mirroring is best-effort and never blocks
+// the primary path.
+func WithTee(t *Tee) Option {
+	return func(o *options) {
+		o.tee = t
+	}
+}
+
+// WithAuditLog records every frame read or written, including its MLI, in a. Outbound frames are recorded before they
+// are written and a read or write fails if its frame cannot be recorded.
+func WithAuditLog(a *AuditLog) Option {
+	return func(o *options) {
+		o.audit = a
+	}
+}
+
+// WithKeepaliveFrame writes frame, a complete pre-encoded frame such as a network management message or a zero-length
+// MLI, after each interval of write inactivity, see KeepaliveWriter. Keepalives stop when MessageConn.Close is called
+// or a write fails with an error other than a timeout, and are disabled if interval is not positive.
+//
+//	ka, _ := simplemli.Encode(simplemli.MLI2I, 0)
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithKeepaliveFrame(ka, 30*time.Second))
+func WithKeepaliveFrame(frame []byte, interval time.Duration) Option {
+	return func(o *options) {
+		o.keepalive = frame
+		o.keepaliveInt = interval
+	}
+}
+
+// WithRateLimit allows at most tps messages per second to be written, so a partner's contracted transactions-per-second
+// is never exceeded. Messages over the limit wait with RateBlock or fail with ErrRateLimited with RateReject. Resuming a
+// partly written message does not count against the limit. If tps is not greater than zero WriteMessage returns
+// ErrInvalidRate.
+//
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithRateLimit(50, simplemli.RateBlock))
+func WithRateLimit(tps float64, policy RatePolicy) Option {
+	return func(o *options) {
+		o.rate = tps
+		o.ratePolicy = policy
+		o.rateSet = true
+	}
+}
+
+// WithDialTimeout limits the time Dial and DialContext wait for a connection, including name resolution and every
+// address attempted.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = d
+	}
+}
+
+// WithFallbackDelay sets how long Dial waits for a connection to the preferred address family before also attempting
+// the other family, when a host name resolves to both IPv4 and IPv6 addresses. Zero uses the default of 300ms and a
+// negative d disables the concurrent fallback.
+func WithFallbackDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.fallbackDelay = d
+	}
+}
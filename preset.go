@@ -0,0 +1,87 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownPreset reports a lookup of a preset name which has not been registered.
+var ErrUnknownPreset = fmt.Errorf("unknown preset")
+
+// presets maps partner names to their framing configuration
+var presets = struct {
+	sync.RWMutex
+	m map[string]Config
+}{
+	m: map[string]Config{
+		// 2-byte length followed by a 2-byte reserved header carried with the message
+		"visa-sms": {Key: MLI2EE, MaxMessageSize: 8192},
+
+		// 2-byte length excluding the MLI
+		"mastercard-mip": {Key: MLI2E, MaxMessageSize: 8192},
+
+		// 2-byte length excluding the MLI
+		"base24": {Key: MLI2E, MaxMessageSize: 8192},
+
+		"generic-2I": {Key: MLI2I},
+	},
+}
+
+// Preset returns the framing configuration registered for a partner or network name, allowing operators to configure
+// connections by partner rather than by MLI type. The built-in presets are:
+//
+//	visa-sms        2EE, 8KiB messages
+//	mastercard-mip  2E, 8KiB messages
+//	base24          2E, 8KiB messages
+//	generic-2I      2I
+//
+// Built-in presets describe common deployments, confirm the framing against the partner's specification.
+func Preset(name string) (Config, error) {
+	presets.RLock()
+	defer presets.RUnlock()
+	cfg, ok := presets.m[name]
+	if !ok {
+		return Config{}, fmt.Errorf("%w %q", ErrUnknownPreset, name)
+	}
+	return cfg, nil
+}
+
+// RegisterPreset adds or replaces the preset for name. The configured MLI type must be valid.
+func RegisterPreset(name string, cfg Config) error {
+	if _, err := Size(cfg.Key); err != nil {
+		return err
+	}
+	presets.Lock()
+	defer presets.Unlock()
+	presets.m[name] = cfg
+	return nil
+}
+
+// Presets returns the names of all registered presets in sorted order.
+func Presets() []string {
+	presets.RLock()
+	defer presets.RUnlock()
+	names := make([]string, 0, len(presets.m))
+	for name := range presets.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
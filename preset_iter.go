@@ -0,0 +1,41 @@
+//go:build go1.23 && !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"iter"
+)
+
+// AllPresets returns an iterator over the registered presets and their configuration, in name order.
+//
+//	for name, cfg := range simplemli.AllPresets() {
+//		fmt.Println(name, cfg.Key)
+//	}
+func AllPresets() iter.Seq2[string, Config] {
+	return func(yield func(string, Config) bool) {
+		for _, name := range Presets() {
+			cfg, err := Preset(name)
+			if err != nil {
+				// Unregistered since Presets was called
+				continue
+			}
+			if !yield(name, cfg) {
+				return
+			}
+		}
+	}
+}
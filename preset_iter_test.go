@@ -0,0 +1,33 @@
+//go:build go1.23 && !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"testing"
+)
+
+func TestAllPresets(t *testing.T) {
+	found := false
+	for name, cfg := range AllPresets() {
+		if name == "generic-2I" {
+			found = cfg.Key == MLI2I
+		}
+	}
+	if !found {
+		t.Errorf("Expected generic-2I preset from iterator")
+	}
+}
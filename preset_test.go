@@ -0,0 +1,70 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPreset(t *testing.T) {
+	builtin := map[string]string{
+		"visa-sms":       MLI2EE,
+		"mastercard-mip": MLI2E,
+		"base24":         MLI2E,
+		"generic-2I":     MLI2I,
+	}
+	for name, key := range builtin {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Preset(name)
+			if err != nil || cfg.Key != key {
+				t.Errorf("Unexpected preset, got %+v, %v expected key %s", cfg, err, key)
+			}
+			if _, err := cfg.Codec(); err != nil {
+				t.Errorf("Unexpected error creating codec from preset - %s", err)
+			}
+		})
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := Preset("acme")
+		if !errors.Is(err, ErrUnknownPreset) {
+			t.Errorf("Expected ErrUnknownPreset, got %v", err)
+		}
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		err := RegisterPreset("acme-host", Config{Key: MLIA4E, MaxMessageSize: 4096})
+		if err != nil {
+			t.Fatalf("Unexpected error registering preset - %s", err)
+		}
+		cfg, err := Preset("acme-host")
+		if err != nil || cfg.Key != MLIA4E || cfg.MaxMessageSize != 4096 {
+			t.Errorf("Unexpected registered preset, got %+v, %v", cfg, err)
+		}
+
+		expected := []string{"acme-host", "base24", "generic-2I", "mastercard-mip", "visa-sms"}
+		if names := Presets(); !reflect.DeepEqual(names, expected) {
+			t.Errorf("Unexpected preset names, got %v expected %v", names, expected)
+		}
+
+		err = RegisterPreset("bad", Config{Key: "3Q"})
+		if err == nil {
+			t.Errorf("Expected error registering invalid MLI type - got nil")
+		}
+	})
+}
@@ -0,0 +1,167 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited reports a write rejected because it would exceed the configured transactions-per-second.
+var ErrRateLimited = fmt.Errorf("write exceeds configured rate limit")
+
+// ErrInvalidRate reports a rate limit which is not greater than zero.
+var ErrInvalidRate = fmt.Errorf("rate limit must be greater than zero")
+
+// RatePolicy selects how a rate limited writer handles writes which exceed the limit.
+type RatePolicy int
+
+const (
+	// RateBlock delays writes until they fall within the limit
+	RateBlock RatePolicy = iota
+
+	// RateReject rejects writes which exceed the limit with ErrRateLimited
+	RateReject
+)
+
+// RateLimiter is a token bucket limiting events to a number per second. A RateLimiter is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing tps events per second with bursts of up to burst events. A burst of 1
+// spaces events evenly and never allows more than tps events within any one second. NewRateLimiter returns
+// ErrInvalidRate if tps is not greater than zero.
+func NewRateLimiter(tps float64, burst int) (*RateLimiter, error) {
+	if !(tps > 0) {
+		return nil, ErrInvalidRate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   tps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}, nil
+}
+
+// refill adds the tokens accrued since the last call, the caller must hold the lock
+func (l *RateLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// Allow reports whether an event may happen now, consuming a token if so.
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait blocks until an event may happen or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.refill(time.Now())
+	l.tokens--
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Return the reserved token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// permit consumes a token according to policy, waiting for one with RateBlock or returning ErrRateLimited with
+// RateReject
+func (l *RateLimiter) permit(policy RatePolicy) error {
+	if policy == RateReject {
+		if !l.Allow() {
+			return ErrRateLimited
+		}
+		return nil
+	}
+	return l.Wait(context.Background())
+}
+
+// RateLimitedWriter wraps an io.Writer to cap the number of frames written per second, so a partner's contracted
+// transactions-per-second is never exceeded. Each call to Write is counted as one frame.
+//
+//	w, err := simplemli.NewRateLimitedWriter(conn, 50, simplemli.RateBlock)
+//	if err != nil {
+//		// Do something
+//	}
+//
+// Use WithRateLimit to limit a Writer or MessageConn, which counts messages rather than calls to Write.
+type RateLimitedWriter struct {
+	w       io.Writer
+	limiter *RateLimiter
+	policy  RatePolicy
+}
+
+// NewRateLimitedWriter returns a writer allowing at most tps writes per second to w, handling excess writes according
+// to policy. NewRateLimitedWriter returns ErrInvalidRate if tps is not greater than zero.
+func NewRateLimitedWriter(w io.Writer, tps float64, policy RatePolicy) (*RateLimitedWriter, error) {
+	l, err := NewRateLimiter(tps, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimitedWriter{w: w, limiter: l, policy: policy}, nil
+}
+
+// Write writes p to the underlying writer once permitted by the rate limit. With the RateReject policy, Write returns
+// ErrRateLimited without writing if the limit has been reached.
+func (r *RateLimitedWriter) Write(p []byte) (int, error) {
+	err := r.limiter.permit(r.policy)
+	if err != nil {
+		return 0, err
+	}
+	return r.w.Write(p)
+}
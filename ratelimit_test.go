@@ -0,0 +1,138 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedWriter(t *testing.T) {
+	msg := []byte{0x00, 0x02}
+
+	t.Run("Block", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewRateLimitedWriter(&buf, 100, RateBlock)
+		if err != nil {
+			t.Fatalf("Unexpected error creating writer - %s", err)
+		}
+
+		start := time.Now()
+		for i := 0; i < 6; i++ {
+			_, err := w.Write(msg)
+			if err != nil {
+				t.Errorf("Unexpected error from Write - %s", err)
+			}
+		}
+
+		// First write is immediate, the following 5 are spaced 10ms apart
+		if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+			t.Errorf("Writes were not rate limited, 6 writes at 100 TPS took %s", elapsed)
+		}
+		if buf.Len() != 12 {
+			t.Errorf("Unexpected bytes written, got %d expected 12", buf.Len())
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := NewRateLimitedWriter(&buf, 1, RateReject)
+		if err != nil {
+			t.Fatalf("Unexpected error creating writer - %s", err)
+		}
+
+		_, err = w.Write(msg)
+		if err != nil {
+			t.Errorf("Unexpected error from first Write - %s", err)
+		}
+		_, err = w.Write(msg)
+		if err != ErrRateLimited {
+			t.Errorf("Expected ErrRateLimited, got %v", err)
+		}
+		if buf.Len() != 2 {
+			t.Errorf("Rejected write reached the underlying writer")
+		}
+	})
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l, err := NewRateLimiter(1, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error creating limiter - %s", err)
+	}
+	if !l.Allow() {
+		t.Fatalf("Expected first event to be allowed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = l.Wait(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded from Wait, got %v", err)
+	}
+}
+
+func TestRateLimiterInvalidRate(t *testing.T) {
+	for _, tps := range []float64{0, -1, math.NaN()} {
+		t.Run(fmt.Sprint(tps), func(t *testing.T) {
+			if _, err := NewRateLimiter(tps, 1); err != ErrInvalidRate {
+				t.Errorf("Expected ErrInvalidRate from NewRateLimiter, got %v", err)
+			}
+			if _, err := NewRateLimitedWriter(io.Discard, tps, RateBlock); err != ErrInvalidRate {
+				t.Errorf("Expected ErrInvalidRate from NewRateLimitedWriter, got %v", err)
+			}
+			w := NewWriter(io.Discard, MLI2I, WithRateLimit(tps, RateBlock))
+			if err := w.WriteMessage([]byte("0800")); err != ErrInvalidRate {
+				t.Errorf("Expected ErrInvalidRate from WriteMessage, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	t.Run("Block", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, MLI2I, WithRateLimit(100, RateBlock))
+		start := time.Now()
+		for i := 0; i < 6; i++ {
+			if err := w.WriteMessage([]byte("0800")); err != nil {
+				t.Errorf("Unexpected error from WriteMessage - %s", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+			t.Errorf("Messages were not rate limited, 6 messages at 100 TPS took %s", elapsed)
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, MLI2I, WithRateLimit(1, RateReject))
+		if err := w.WriteMessage([]byte("0800")); err != nil {
+			t.Errorf("Unexpected error from first WriteMessage - %s", err)
+		}
+		if err := w.WriteMessage([]byte("0800")); err != ErrRateLimited {
+			t.Errorf("Expected ErrRateLimited, got %v", err)
+		}
+		if buf.Len() != 6 {
+			t.Errorf("Rejected message reached the underlying writer, got %x", buf.Bytes())
+		}
+	})
+}
@@ -0,0 +1,166 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// chunk is a single read from the underlying reader
+type chunk struct {
+	b   []byte
+	err error
+}
+
+// readAhead is an io.Reader which reads from an underlying reader in a background goroutine, queueing up to a fixed
+// number of reads so data which arrives in bursts is already buffered when the application asks for it
+type readAhead struct {
+	chunks chan chunk
+	free   chan []byte
+	stop   chan struct{}
+	once   sync.Once
+	gate   *gate
+
+	// want is signalled by Read when it blocks waiting for data
+	want chan struct{}
+
+	cur chunk
+	off int
+}
+
+// newReadAhead starts reading r in the background with reads of size bytes, queueing at most n reads and waiting on
+// g before each read
+func newReadAhead(r io.Reader, size, n int, g *gate) *readAhead {
+	ra := &readAhead{
+		chunks: make(chan chunk, n),
+		free:   make(chan []byte, n+1),
+		stop:   make(chan struct{}),
+		gate:   g,
+		want:   make(chan struct{}, 1),
+	}
+	go ra.fill(r, size)
+	return ra
+}
+
+// fill reads from r until it returns an error or the readAhead is closed. A timeout is only passed on if a Read is
+// waiting, since a deadline which expires while the application is idle belongs to no read. Either way fill waits for
+// the next Read before reading again, by which time the caller may have set a new deadline.
+func (ra *readAhead) fill(r io.Reader, size int) {
+	// waiting is set once a Read is known to be blocked on the next chunk
+	var waiting bool
+	for {
+		if !ra.gate.wait(ra.stop) {
+			return
+		}
+		var buf []byte
+		select {
+		case buf = <-ra.free:
+		default:
+			buf = make([]byte, size)
+		}
+
+		n, err := r.Read(buf)
+		timeout := err != nil && isTimeout(err)
+		if timeout && !waiting {
+			select {
+			case <-ra.want:
+			default:
+				err = nil
+			}
+		}
+		if n > 0 || err != nil {
+			select {
+			case ra.chunks <- chunk{b: buf[:n], err: err}:
+			case <-ra.stop:
+				return
+			}
+			waiting = false
+		}
+		if timeout {
+			select {
+			case <-ra.want:
+				waiting = true
+			case <-ra.stop:
+				return
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// isTimeout reports whether err is a timeout, which leaves the underlying reader usable once a new deadline is set
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// Read returns data read ahead from the underlying reader, blocking until some is available
+func (ra *readAhead) Read(p []byte) (int, error) {
+	for ra.off >= len(ra.cur.b) {
+		if ra.cur.err != nil {
+			err := ra.cur.err
+			if isTimeout(err) {
+				ra.cur = chunk{}
+			}
+			return 0, err
+		}
+
+		// Return the consumed buffer for reuse
+		if ra.cur.b != nil {
+			select {
+			case ra.free <- ra.cur.b[:cap(ra.cur.b)]:
+			default:
+			}
+		}
+
+		select {
+		case ra.cur = <-ra.chunks:
+		default:
+			select {
+			case ra.want <- struct{}{}:
+			default:
+			}
+			select {
+			case ra.cur = <-ra.chunks:
+			case <-ra.stop:
+				ra.cur = chunk{err: net.ErrClosed}
+			}
+			select {
+			case <-ra.want:
+			default:
+			}
+		}
+		ra.off = 0
+	}
+
+	n := copy(p, ra.cur.b[ra.off:])
+	ra.off += n
+	return n, nil
+}
+
+// close stops the background goroutine once its current read returns
+func (ra *readAhead) close() {
+	ra.once.Do(func() {
+		close(ra.stop)
+	})
+}
@@ -0,0 +1,135 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestReadAhead(t *testing.T) {
+	t.Run("Read Frames", func(t *testing.T) {
+		var stream []byte
+		for _, msg := range []string{"abc", "", "defgh"} {
+			mli, _ := Encode(MLI2E, len(msg))
+			stream = append(append(stream, mli...), msg...)
+		}
+		r := NewReader(iotest.OneByteReader(bytes.NewReader(stream)), MLI2E, WithReadAhead(2), WithBufferSize(4))
+		for _, expected := range []string{"abc", "", "defgh"} {
+			msg, err := r.ReadMessage()
+			if err != nil || string(msg) != expected {
+				t.Errorf("Unexpected message, got %q, %v expected %q", msg, err, expected)
+			}
+		}
+		_, err := r.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF, got %v", err)
+		}
+		_, err = r.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF to be repeated, got %v", err)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		r := NewReader(bytes.NewReader([]byte{0x00, 0x05, 'a'}), MLI2E, WithReadAhead(1))
+		_, err := r.ReadMessage()
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+
+	t.Run("Timeout Retry", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I, WithReadAhead(2), WithReadTimeout(20*time.Millisecond))
+		peer := NewMessageConn(b, MLI2I)
+		defer conn.Close()
+		defer peer.Close()
+
+		_, err := conn.ReadMessage()
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("Expected a timeout, got %v", err)
+		}
+
+		// The deadline also expires while no read is waiting, which must not be reported to the next read
+		time.Sleep(50 * time.Millisecond)
+		go func() { _ = peer.WriteMessage([]byte("0800")) }()
+		msg, err := conn.ReadMessage()
+		if err != nil || string(msg) != "0800" {
+			t.Errorf("Unexpected message after timeout, got %q, %v", msg, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		_, err = conn.ReadMessageContext(ctx)
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		go func() { _ = peer.WriteMessage([]byte("0810")) }()
+		msg, err = conn.ReadMessage()
+		if err != nil || string(msg) != "0810" {
+			t.Errorf("Unexpected message after cancelled read, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Burst", func(t *testing.T) {
+		a, b := net.Pipe()
+		conn := NewMessageConn(a, MLI2I, WithReadAhead(4), WithBufferSize(16))
+		peer := NewMessageConn(b, MLI2I)
+		defer peer.Close()
+
+		// net.Pipe writes block until read, so the burst only completes if it is read ahead
+		done := make(chan error, 1)
+		go func() {
+			for i := 0; i < 3; i++ {
+				err := peer.WriteMessage([]byte("0100"))
+				if err != nil {
+					done <- err
+					return
+				}
+			}
+			done <- nil
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Unexpected error writing - %s", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Burst was not read ahead")
+		}
+
+		for i := 0; i < 3; i++ {
+			msg, err := conn.ReadMessage()
+			if err != nil || string(msg) != "0100" {
+				t.Errorf("Unexpected message, got %q, %v", msg, err)
+			}
+		}
+
+		_ = conn.Close()
+		_, err := conn.ReadMessage()
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Expected net.ErrClosed reading after close, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,322 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SAFRecord is a frame held by a store-and-forward queue along with the time it was stored.
+type SAFRecord struct {
+	Frame  []byte
+	Stored time.Time
+}
+
+// SAFStore persists store-and-forward records. Implementations must return records in the order they were appended
+// and must be safe for concurrent use.
+type SAFStore interface {
+	// Append persists a record at the end of the queue.
+	Append(r SAFRecord) error
+
+	// Records returns all persisted records, oldest first.
+	Records() ([]SAFRecord, error)
+
+	// Remove deletes the oldest n records.
+	Remove(n int) error
+}
+
+// SAF is a store-and-forward queue for outbound frames. Frames which cannot be written while a connection is down are
+// persisted to a SAFStore and forwarded in order once the connection is re-established.
+//
+//	store, err := simplemli.NewFileStore("/var/spool/advices.saf")
+//	if err != nil {
+//		// Do something
+//	}
+//
+//	saf := simplemli.NewSAF(store)
+//	saf.Expired = simplemli.MaxAge(24 * time.Hour)
+//
+//	// On reconnect, forward anything stored while the link was down
+//	_, err = saf.Drain(conn)
+//
+// Delivery is at-least-once, if the process stops mid-drain some frames may be forwarded again on the next Drain.
+type SAF struct {
+	// Expired reports whether a stored record should be discarded rather than forwarded. If nil, records never expire.
+	Expired func(r SAFRecord) bool
+
+	// OnExpire is called with each record discarded by Expired.
+	OnExpire func(r SAFRecord)
+
+	mu    sync.Mutex
+	store SAFStore
+}
+
+// NewSAF returns a store-and-forward queue backed by store.
+func NewSAF(store SAFStore) *SAF {
+	return &SAF{store: store}
+}
+
+// MaxAge returns an expiry func for SAF.Expired which expires records stored longer than d ago.
+func MaxAge(d time.Duration) func(SAFRecord) bool {
+	return func(r SAFRecord) bool {
+		return time.Since(r.Stored) > d
+	}
+}
+
+// Store persists a complete frame (MLI and message) for later forwarding. The frame is copied, so the caller may reuse
+// its buffer once Store returns.
+func (s *SAF) Store(frame []byte) error {
+	return s.store.Append(SAFRecord{Frame: append([]byte{}, frame...), Stored: time.Now()})
+}
+
+// Len returns the number of frames currently held by the queue.
+func (s *SAF) Len() (int, error) {
+	r, err := s.store.Records()
+	return len(r), err
+}
+
+// Send forwards any stored frames to w followed by frame. If w is nil or a write fails, frame is stored to be
+// forwarded by a later Drain and the write error is returned so the caller may re-establish the connection.
+//
+// A write failure may leave a partially written frame on w, callers should close the connection when Send returns an
+// error.
+func (s *SAF) Send(w io.Writer, frame []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w == nil {
+		return s.Store(frame)
+	}
+
+	// Stored frames must be forwarded first to keep delivery in order
+	_, err := s.drain(w)
+	if err == nil {
+		_, err = w.Write(frame)
+	}
+	if err != nil {
+		if serr := s.Store(frame); serr != nil {
+			return serr
+		}
+		return err
+	}
+	return nil
+}
+
+// Drain forwards stored frames to w in the order they were stored, discarding any which have expired. Drain returns
+// the number of frames written and stops at the first write error, leaving unsent frames in the store.
+func (s *SAF) Drain(w io.Writer) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drain(w)
+}
+
+func (s *SAF) drain(w io.Writer) (int, error) {
+	records, err := s.store.Records()
+	if err != nil {
+		return 0, err
+	}
+
+	var sent, done int
+	for _, r := range records {
+		if s.Expired != nil && s.Expired(r) {
+			if s.OnExpire != nil {
+				s.OnExpire(r)
+			}
+			done++
+			continue
+		}
+
+		_, err = w.Write(r.Frame)
+		if err != nil {
+			break
+		}
+		sent++
+		done++
+	}
+
+	if done > 0 {
+		if rerr := s.store.Remove(done); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return sent, err
+}
+
+// MemoryStore is an in-memory SAFStore. Records held by a MemoryStore do not survive a restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []SAFRecord
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append adds a copy of a record to the end of the store.
+func (m *MemoryStore) Append(r SAFRecord) error {
+	r.Frame = append([]byte{}, r.Frame...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, r)
+	return nil
+}
+
+// Records returns a copy of the stored records, oldest first.
+func (m *MemoryStore) Records() ([]SAFRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SAFRecord{}, m.records...), nil
+}
+
+// Remove deletes the oldest n records.
+func (m *MemoryStore) Remove(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n > len(m.records) {
+		n = len(m.records)
+	}
+	m.records = append([]SAFRecord{}, m.records[n:]...)
+	return nil
+}
+
+// safRecordHeader is the size of the file record header, an 8-byte timestamp followed by a 4-byte frame length
+const safRecordHeader = 12
+
+// ErrCorruptStore reports a store-and-forward file which could not be parsed.
+var ErrCorruptStore = fmt.Errorf("store-and-forward file is corrupt")
+
+// FileStore is a file-backed SAFStore and the default store for SAF queues. Records are appended to a single file
+// and synced to disk before Append returns.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore returns a FileStore persisting records to path, creating the file if it does not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store-and-forward file - %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{path: path}, nil
+}
+
+// Append writes a record to the end of the file.
+func (s *FileStore) Append(r SAFRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := make([]byte, safRecordHeader, safRecordHeader+len(r.Frame))
+	binary.BigEndian.PutUint64(b, uint64(r.Stored.UnixNano()))
+	binary.BigEndian.PutUint32(b[8:], uint32(len(r.Frame)))
+	b = append(b, r.Frame...)
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Records reads all records from the file, oldest first.
+func (s *FileStore) Records() ([]SAFRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records()
+}
+
+func (s *FileStore) records() ([]SAFRecord, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []SAFRecord
+	for len(b) > 0 {
+		if len(b) < safRecordHeader {
+			return records, ErrCorruptStore
+		}
+		ts := int64(binary.BigEndian.Uint64(b))
+		n := int(binary.BigEndian.Uint32(b[8:]))
+		b = b[safRecordHeader:]
+		if len(b) < n {
+			return records, ErrCorruptStore
+		}
+		records = append(records, SAFRecord{Frame: b[:n:n], Stored: time.Unix(0, ts)})
+		b = b[n:]
+	}
+	return records, nil
+}
+
+// Remove deletes the oldest n records by rewriting the file.
+func (s *FileStore) Remove(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.records()
+	if err != nil {
+		return err
+	}
+	if n > len(records) {
+		n = len(records)
+	}
+
+	var buf bytes.Buffer
+	hdr := make([]byte, safRecordHeader)
+	for _, r := range records[n:] {
+		binary.BigEndian.PutUint64(hdr, uint64(r.Stored.UnixNano()))
+		binary.BigEndian.PutUint32(hdr[8:], uint32(len(r.Frame)))
+		buf.Write(hdr)
+		buf.Write(r.Frame)
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a truncated store
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
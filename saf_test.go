@@ -0,0 +1,154 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSAF(t *testing.T) {
+	frames := [][]byte{
+		{0x00, 0x03, 'a'},
+		{0x00, 0x04, 'b', 'c'},
+		{0x00, 0x05, 'd', 'e', 'f'},
+	}
+
+	fs, err := NewFileStore(filepath.Join(t.TempDir(), "test.saf"))
+	if err != nil {
+		t.Fatalf("Unable to create file store - %s", err)
+	}
+
+	stores := map[string]SAFStore{
+		"Memory": NewMemoryStore(),
+		"File":   fs,
+	}
+
+	for name, store := range stores {
+		t.Run(name+" Store and Drain", func(t *testing.T) {
+			saf := NewSAF(store)
+			for _, f := range frames {
+				err := saf.Send(nil, f)
+				if err != nil {
+					t.Errorf("Unexpected error storing frame - %s", err)
+				}
+			}
+
+			n, err := saf.Len()
+			if err != nil || n != len(frames) {
+				t.Errorf("Unexpected queue length, got %d, %v expected %d", n, err, len(frames))
+			}
+
+			var buf bytes.Buffer
+			sent, err := saf.Drain(&buf)
+			if err != nil {
+				t.Errorf("Unexpected error draining queue - %s", err)
+			}
+			if sent != len(frames) {
+				t.Errorf("Unexpected number of frames drained, got %d expected %d", sent, len(frames))
+			}
+			if !bytes.Equal(buf.Bytes(), bytes.Join(frames, nil)) {
+				t.Errorf("Frames not drained in order, got %x", buf.Bytes())
+			}
+
+			n, _ = saf.Len()
+			if n != 0 {
+				t.Errorf("Expected empty queue after drain, got %d", n)
+			}
+		})
+
+		t.Run(name+" Buffer reuse", func(t *testing.T) {
+			saf := NewSAF(store)
+			buf := append([]byte{}, frames[0]...)
+			err := saf.Store(buf)
+			if err != nil {
+				t.Errorf("Unexpected error storing frame - %s", err)
+			}
+			copy(buf, frames[1])
+
+			var out bytes.Buffer
+			_, err = saf.Drain(&out)
+			if err != nil {
+				t.Errorf("Unexpected error draining queue - %s", err)
+			}
+			if !bytes.Equal(out.Bytes(), frames[0]) {
+				t.Errorf("Stored frame changed by caller, got %x expected %x", out.Bytes(), frames[0])
+			}
+		})
+
+		t.Run(name+" Expiry", func(t *testing.T) {
+			saf := NewSAF(store)
+			var expired int
+			saf.Expired = MaxAge(time.Millisecond)
+			saf.OnExpire = func(SAFRecord) { expired++ }
+
+			err := saf.Store(frames[0])
+			if err != nil {
+				t.Errorf("Unexpected error storing frame - %s", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			var buf bytes.Buffer
+			sent, err := saf.Drain(&buf)
+			if err != nil || sent != 0 || buf.Len() != 0 {
+				t.Errorf("Expected expired frame to be discarded, got %d sent, %v", sent, err)
+			}
+			if expired != 1 {
+				t.Errorf("Expected OnExpire to be called once, got %d", expired)
+			}
+		})
+
+		t.Run(name+" Send failure stores frame", func(t *testing.T) {
+			saf := NewSAF(store)
+			w := &lockedBuffer{err: fmt.Errorf("connection reset")}
+
+			err := saf.Send(w, frames[1])
+			if err == nil {
+				t.Errorf("Expected write error from Send - got nil")
+			}
+
+			var buf bytes.Buffer
+			err = saf.Send(&buf, frames[2])
+			if err != nil {
+				t.Errorf("Unexpected error from Send - %s", err)
+			}
+			if !bytes.Equal(buf.Bytes(), append(append([]byte{}, frames[1]...), frames[2]...)) {
+				t.Errorf("Stored frame not forwarded before new frame, got %x", buf.Bytes())
+			}
+		})
+	}
+
+	t.Run("Corrupt File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "corrupt.saf")
+		err := os.WriteFile(path, []byte{0x00, 0x01, 0x02}, 0600)
+		if err != nil {
+			t.Fatalf("Unable to write test file - %s", err)
+		}
+
+		fs, err := NewFileStore(path)
+		if err != nil {
+			t.Fatalf("Unable to create file store - %s", err)
+		}
+		_, err = fs.Records()
+		if err != ErrCorruptStore {
+			t.Errorf("Expected ErrCorruptStore, got %v", err)
+		}
+	})
+}
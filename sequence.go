@@ -0,0 +1,117 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"fmt"
+)
+
+// ErrSequence reports a message too short to hold the sequence field configured by WithSequence, or an invalid
+// sequence field size.
+var ErrSequence = fmt.Errorf("invalid sequence field")
+
+func init() {
+	codeErrors = append(codeErrors, codeError{ErrSequence, CodeSequence})
+}
+
+// sequence is the position of a sequence field within message bodies along with the running sequence number
+type sequence struct {
+	offset  int
+	size    int
+	onGap   func(expected, got uint64)
+	next    uint64
+	started bool
+}
+
+// validate checks the field size is usable
+func (s *sequence) validate() error {
+	if s.size < 1 || s.size > 8 || s.offset < 0 {
+		return fmt.Errorf("%w - offset %d size %d", ErrSequence, s.offset, s.size)
+	}
+	return nil
+}
+
+// mask returns the largest sequence number representable by the field
+func (s *sequence) mask() uint64 {
+	if s.size == 8 {
+		return ^uint64(0)
+	}
+	return 1<<(8*uint(s.size)) - 1
+}
+
+// put writes the sequence number n into b as a big-endian field of the configured size
+func (s *sequence) put(b []byte, n uint64) {
+	for i := s.size - 1; i >= 0; i-- {
+		b[i] = byte(n)
+		n >>= 8
+	}
+}
+
+// get reads the big-endian sequence field from b
+func (s *sequence) get(b []byte) uint64 {
+	var n uint64
+	for _, c := range b[:s.size] {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// insert writes msg into dst with the next sequence number inserted at the configured offset, dst must be
+// len(msg)+size bytes. The number is not used up until advance is called, so a frame which is never sent does not
+// leave a gap.
+func (s *sequence) insert(dst, msg []byte) error {
+	if len(msg) < s.offset {
+		return fmt.Errorf("%w - message of %d bytes shorter than offset %d", ErrSequence, len(msg), s.offset)
+	}
+	if !s.started {
+		s.next, s.started = 1, true
+	}
+	n := copy(dst, msg[:s.offset])
+	s.put(dst[n:], s.next)
+	copy(dst[n+s.size:], msg[s.offset:])
+	return nil
+}
+
+// advance moves on to the next sequence number once a frame carrying the current one has been sent
+func (s *sequence) advance() {
+	s.next = (s.next + 1) & s.mask()
+}
+
+// strip removes the sequence field from body, checking it continues from the previous message, and returns the
+// shortened body
+func (s *sequence) strip(body []byte) ([]byte, error) {
+	if len(body) < s.offset+s.size {
+		return body, fmt.Errorf("%w - message of %d bytes too short for field at offset %d", ErrSequence, len(body), s.offset)
+	}
+	got := s.get(body[s.offset:])
+	if s.started && got != s.next && s.onGap != nil {
+		s.onGap(s.next, got)
+	}
+	s.next, s.started = (got+1)&s.mask(), true
+	n := copy(body[s.offset:], body[s.offset+s.size:])
+	return body[:s.offset+n], nil
+}
+
+// newSequence returns the sequence state for a Reader or Writer, or nil if WithSequence was not set. Each Reader and
+// Writer keeps its own copy so the directions of a MessageConn are numbered independently.
+func newSequence(o options) (*sequence, error) {
+	if o.sequence == nil {
+		return nil, nil
+	}
+	s := &sequence{offset: o.sequence.offset, size: o.sequence.size, onGap: o.sequence.onGap}
+	return s, s.validate()
+}
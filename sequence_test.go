@@ -0,0 +1,163 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// failFirstWriter fails its first write without writing anything
+type failFirstWriter struct {
+	bytes.Buffer
+	failed bool
+}
+
+func (f *failFirstWriter) Write(p []byte) (int, error) {
+	if !f.failed {
+		f.failed = true
+		return 0, fmt.Errorf("write failed")
+	}
+	return f.Buffer.Write(p)
+}
+
+func TestSequence(t *testing.T) {
+	type gap struct{ expected, got uint64 }
+
+	t.Run("Write", func(t *testing.T) {
+		var buf bytes.Buffer
+		var hooked []string
+		w := NewWriter(&buf, MLI2E, WithSequence(2, 2, nil), WithWriteHook(func(msg []byte) {
+			hooked = append(hooked, string(msg))
+		}))
+		for _, msg := range []string{"h1ab", "h2"} {
+			err := w.WriteMessage([]byte(msg))
+			if err != nil {
+				t.Fatalf("Unexpected error writing message - %s", err)
+			}
+		}
+		expected := []byte{0x00, 0x06, 'h', '1', 0x00, 0x01, 'a', 'b', 0x00, 0x04, 'h', '2', 0x00, 0x02}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Unexpected bytes written, got %x expected %x", buf.Bytes(), expected)
+		}
+		if len(hooked) != 2 || hooked[0] != "h1ab" {
+			t.Errorf("Expected hook to receive messages without sequence, got %q", hooked)
+		}
+	})
+
+	t.Run("Failed Write", func(t *testing.T) {
+		fw := &failFirstWriter{}
+		w := NewWriter(fw, MLI2E, WithSequence(0, 1, nil))
+		if err := w.WriteMessage([]byte("a")); err == nil {
+			t.Fatalf("Expected error from failed write - got nil")
+		}
+		if err := w.WriteMessage([]byte("b")); err != nil {
+			t.Fatalf("Unexpected error writing message - %s", err)
+		}
+		expected := []byte{0x00, 0x02, 0x01, 'b'}
+		if !bytes.Equal(fw.Bytes(), expected) {
+			t.Errorf("Expected an unsent frame not to use up a sequence number, got %x expected %x", fw.Bytes(), expected)
+		}
+	})
+
+	t.Run("Round Trip", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, MLI2I, WithSequence(0, 4, nil))
+		for _, msg := range []string{"a", "bc", ""} {
+			_ = w.WriteMessage([]byte(msg))
+		}
+		var gaps []gap
+		r := NewReader(&buf, MLI2I, WithSequence(0, 4, func(expected, got uint64) {
+			gaps = append(gaps, gap{expected, got})
+		}))
+		for _, expected := range []string{"a", "bc", ""} {
+			f, err := r.ReadFrame()
+			if err != nil || string(f.Body) != expected || f.Length != len(expected) {
+				t.Errorf("Unexpected frame, got %q length %d, %v expected %q", f.Body, f.Length, err, expected)
+			}
+		}
+		if len(gaps) != 0 {
+			t.Errorf("Unexpected gaps reported, got %v", gaps)
+		}
+	})
+
+	t.Run("Gaps and Duplicates", func(t *testing.T) {
+		var stream []byte
+		for _, n := range []byte{5, 6, 8, 8, 9} {
+			stream = append(stream, 0x00, 0x02, n, 'x')
+		}
+		var gaps []gap
+		r := NewReader(bytes.NewReader(stream), MLI2E, WithSequence(0, 1, func(expected, got uint64) {
+			gaps = append(gaps, gap{expected, got})
+		}))
+		for i := 0; i < 5; i++ {
+			msg, err := r.ReadMessage()
+			if err != nil || string(msg) != "x" {
+				t.Fatalf("Unexpected message, got %q, %v", msg, err)
+			}
+		}
+		if len(gaps) != 2 || gaps[0] != (gap{7, 8}) || gaps[1] != (gap{9, 8}) {
+			t.Errorf("Unexpected gaps, got %v", gaps)
+		}
+	})
+
+	t.Run("Wrap", func(t *testing.T) {
+		var gaps int
+		stream := []byte{0x00, 0x01, 0xff, 0x00, 0x01, 0x00}
+		r := NewReader(bytes.NewReader(stream), MLI2E, WithSequence(0, 1, func(uint64, uint64) { gaps++ }))
+		_, _ = r.ReadMessage()
+		_, err := r.ReadMessage()
+		if err != nil || gaps != 0 {
+			t.Errorf("Expected sequence to wrap without a gap, got %d gaps, %v", gaps, err)
+		}
+	})
+
+	t.Run("Short Message", func(t *testing.T) {
+		err := NewWriter(&bytes.Buffer{}, MLI2E, WithSequence(4, 2, nil)).WriteMessage([]byte("ab"))
+		if !errors.Is(err, ErrSequence) {
+			t.Errorf("Expected ErrSequence writing, got %v", err)
+		}
+		_, err = NewReader(bytes.NewReader([]byte{0x00, 0x01, 'a'}), MLI2E, WithSequence(0, 2, nil)).ReadMessage()
+		if !errors.Is(err, ErrSequence) {
+			t.Errorf("Expected ErrSequence reading, got %v", err)
+		}
+	})
+
+	t.Run("Error Code", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader([]byte{0x00, 0x03, 'a'}), MLI2I, WithSequence(0, 2, nil)).ReadMessage()
+		if code := ErrorCode(err); code != CodeSequence || code.String() != "sequence" {
+			t.Errorf("Unexpected code for %v, got %d %s", err, code, code)
+		}
+	})
+
+	t.Run("Invalid Size", func(t *testing.T) {
+		for _, size := range []int{0, 9} {
+			w := NewWriter(&bytes.Buffer{}, MLI2E, WithSequence(0, size, nil))
+			err := w.WriteMessage([]byte("ab"))
+			if !errors.Is(err, ErrSequence) {
+				t.Errorf("Expected ErrSequence for size %d, got %v", size, err)
+			}
+			c, _ := New(MLI2I)
+			w.SetCodec(c)
+			err = w.WriteMessage([]byte("ab"))
+			if !errors.Is(err, ErrSequence) {
+				t.Errorf("Expected ErrSequence for size %d after SetCodec, got %v", size, err)
+			}
+		}
+	})
+}
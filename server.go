@@ -0,0 +1,206 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// ErrServerClosed is returned by Server.Serve after Close.
+var ErrServerClosed = fmt.Errorf("server closed")
+
+// RequestHandler handles a single inbound message and returns the response to send, or nil to send nothing.
+type RequestHandler func(msg []byte) ([]byte, error)
+
+// ErrorPolicy selects how a Server handles handler errors and messages which cannot be read.
+type ErrorPolicy int
+
+const (
+	// ErrorClose closes the connection
+	ErrorClose ErrorPolicy = iota
+
+	// ErrorRespond sends the frame returned by Server.ErrorResponse and keeps the connection open
+	ErrorRespond
+
+	// ErrorContinue logs the error and keeps the connection open
+	ErrorContinue
+)
+
+// Server accepts MLI-framed connections and calls Handler with each message received, writing back any response.
+// Errors are handled consistently according to OnError rather than by each handler.
+//
+// Handler errors and messages shed after being fully read with ErrBudgetExceeded follow the policy. Read errors which
+// leave the stream misaligned, such as an invalid MLI or ErrTooLarge, always close the connection; with ErrorRespond
+// the error response is sent first.
+//
+//	s := &simplemli.Server{
+//		Key:     simplemli.MLI2I,
+//		Handler: authorize,
+//		OnError: simplemli.ErrorRespond,
+//		ErrorResponse: func(msg []byte, err error) []byte {
+//			return formatError(msg)
+//		},
+//	}
+//	err := s.Serve(ln)
+type Server struct {
+	// Key is the MLI type of every connection
+	Key string
+
+	// Handler is called with each message, calls for one connection are made in order
+	Handler RequestHandler
+
+	// Options configure each connection's MessageConn
+	Options []Option
+
+	// OnError is the error handling policy, ErrorClose by default
+	OnError ErrorPolicy
+
+	// ErrorResponse builds the frame sent under ErrorRespond from the message, nil if it could not be read, and error.
+	// If ErrorResponse is nil or returns nil no response is sent.
+	ErrorResponse func(msg []byte, err error) []byte
+
+	mu     sync.Mutex
+	ln     net.Listener
+	conns  map[*MessageConn]struct{}
+	closed bool
+}
+
+// Serve accepts connections on ln and handles each in its own goroutine until ln fails or the Server is closed.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.ln = ln
+	if s.conns == nil {
+		s.conns = make(map[*MessageConn]struct{})
+	}
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		mc := NewMessageConn(conn, s.Key, s.Options...)
+		s.mu.Lock()
+		if s.closed {
+			// Close ran between Accept and here, so it never saw this connection
+			s.mu.Unlock()
+			mc.Close()
+			return ErrServerClosed
+		}
+		s.conns[mc] = struct{}{}
+		s.mu.Unlock()
+		go s.handle(mc)
+	}
+}
+
+// handle serves a single connection until it is closed
+func (s *Server) handle(c *MessageConn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	for {
+		msg, err := c.ReadMessage()
+		if err != nil {
+			if err == io.EOF || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if !s.fail(c, nil, err) || !recoverable(err) {
+				return
+			}
+			continue
+		}
+
+		resp, err := s.Handler(msg)
+		if err != nil {
+			if !s.fail(c, msg, err) {
+				return
+			}
+			continue
+		}
+		if resp != nil {
+			err = c.WriteMessage(resp)
+			if err != nil {
+				c.opts.logf("simplemli: unable to respond to %s - %s", c.RemoteAddr(), err)
+				return
+			}
+		}
+	}
+}
+
+// fail applies the error policy and reports whether the connection should stay open
+func (s *Server) fail(c *MessageConn, msg []byte, err error) bool {
+	c.opts.logf("simplemli: error handling message from %s - %s", c.RemoteAddr(), err)
+	switch s.OnError {
+	case ErrorRespond:
+		if s.ErrorResponse == nil {
+			return true
+		}
+		resp := s.ErrorResponse(msg, err)
+		if resp == nil {
+			return true
+		}
+		werr := c.WriteMessage(resp)
+		if werr != nil {
+			c.opts.logf("simplemli: unable to send error response to %s - %s", c.RemoteAddr(), werr)
+			return false
+		}
+		return true
+	case ErrorContinue:
+		return true
+	default:
+		return false
+	}
+}
+
+// recoverable reports whether a read error left the stream positioned at the next frame
+func recoverable(err error) bool {
+	return errors.Is(err, ErrBudgetExceeded)
+}
+
+// Close closes the listener and every open connection.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// startServer serves s on a local listener and returns a connected client
+func startServer(t *testing.T, s *Server) *MessageConn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen - %s", err)
+	}
+	go func() {
+		_ = s.Serve(ln)
+	}()
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	conn, err := Dial("tcp", ln.Addr().String(), s.Key, WithReadTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Unable to dial - %s", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return conn
+}
+
+func TestServer(t *testing.T) {
+	handler := func(msg []byte) ([]byte, error) {
+		if bytes.HasPrefix(msg, []byte("bad")) {
+			return nil, fmt.Errorf("unsupported message")
+		}
+		return append([]byte("re:"), msg...), nil
+	}
+
+	t.Run("Close", func(t *testing.T) {
+		conn := startServer(t, &Server{Key: MLI2I, Handler: handler})
+		_ = conn.WriteMessage([]byte("bad"))
+		_, err := conn.ReadMessage()
+		if err == nil {
+			t.Errorf("Expected connection to be closed after a handler error - got nil")
+		}
+	})
+
+	t.Run("Respond", func(t *testing.T) {
+		conn := startServer(t, &Server{Key: MLI2I, Handler: handler, OnError: ErrorRespond,
+			ErrorResponse: func(msg []byte, err error) []byte { return []byte("error:" + string(msg)) }})
+		for _, c := range []struct{ req, resp string }{{"bad1", "error:bad1"}, {"ok", "re:ok"}} {
+			_ = conn.WriteMessage([]byte(c.req))
+			msg, err := conn.ReadMessage()
+			if err != nil || string(msg) != c.resp {
+				t.Errorf("Unexpected response, got %q, %v expected %q", msg, err, c.resp)
+			}
+		}
+	})
+
+	t.Run("Continue", func(t *testing.T) {
+		l := &testLogger{}
+		conn := startServer(t, &Server{Key: MLI2I, Handler: handler, OnError: ErrorContinue,
+			Options: []Option{WithLogger(l)}})
+		_ = conn.WriteMessage([]byte("bad"))
+		_ = conn.WriteMessage([]byte("ok"))
+		msg, err := conn.ReadMessage()
+		if err != nil || string(msg) != "re:ok" {
+			t.Errorf("Unexpected response, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("Misaligned Stream", func(t *testing.T) {
+		conn := startServer(t, &Server{Key: MLI2I, Handler: handler, OnError: ErrorRespond,
+			Options:       []Option{WithMaxLength(4)},
+			ErrorResponse: func(msg []byte, err error) []byte { return []byte("too large") }})
+		_ = conn.WriteMessage([]byte("0100 long message"))
+		msg, err := conn.ReadMessage()
+		if err != nil || string(msg) != "too large" {
+			t.Errorf("Expected error response, got %q, %v", msg, err)
+		}
+		_, err = conn.ReadMessage()
+		if err == nil {
+			t.Errorf("Expected connection to be closed - got nil")
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		s := &Server{Key: MLI2I, Handler: handler}
+		_ = s.Close()
+		ln, _ := net.Listen("tcp", "127.0.0.1:0")
+		defer ln.Close()
+		if err := s.Serve(ln); !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Expected ErrServerClosed, got %v", err)
+		}
+	})
+
+	t.Run("Closed During Accept", func(t *testing.T) {
+		s := &Server{Key: MLI2I, Handler: handler}
+		a, b := net.Pipe()
+		defer b.Close()
+		ln := &closingListener{conn: a, close: func() { _ = s.Close() }}
+		if err := s.Serve(ln); !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Expected ErrServerClosed, got %v", err)
+		}
+		if _, err := b.Read(make([]byte, 1)); err == nil {
+			t.Errorf("Expected connection accepted during Close to be closed - got nil")
+		}
+	})
+}
+
+// closingListener returns conn from Accept after calling close, as if the server was closed mid-accept
+type closingListener struct {
+	net.Listener
+	conn  net.Conn
+	close func()
+}
+
+func (l *closingListener) Accept() (net.Conn, error) {
+	l.close()
+	return l.conn, nil
+}
+
+func (l *closingListener) Close() error {
+	return nil
+}
@@ -0,0 +1,54 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"bufio"
+	"io"
+)
+
+// SplitFunc returns a bufio.SplitFunc which splits a stream of messages framed with the MLI type key, for code built
+// on bufio.Scanner. Each token is a message without its MLI. A frame straddling the scanner's buffer requests more
+// data, and a stream ending part way through a frame fails with io.ErrUnexpectedEOF.
+//
+//	scanner := bufio.NewScanner(conn)
+//	scanner.Split(simplemli.SplitFunc(simplemli.MLI2I))
+//	for scanner.Scan() {
+//		msg := scanner.Bytes()
+//	}
+//
+// A Scanner rejects tokens larger than its buffer, call Buffer on the Scanner to accept messages above 64KB. MaxLength
+// rejects an oversized frame as soon as its MLI is read, rather than after the Scanner has buffered it.
+func SplitFunc(key string, opts ...DecodeOption) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		msg, rest, err := Deframe(key, data, opts...)
+		if err == ErrIncomplete {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		return len(data) - len(rest), msg, nil
+	}
+}
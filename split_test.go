@@ -0,0 +1,83 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplitFunc(t *testing.T) {
+	stream := []byte{0x00, 0x03, 'a', 'b', 'c', 0x00, 0x00, 0x00, 0x01, 'd'}
+
+	t.Run("Scan", func(t *testing.T) {
+		// One byte reads straddle every frame across buffer fills
+		scanner := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(stream)))
+		scanner.Split(SplitFunc(MLI2E))
+		var msgs []string
+		for scanner.Scan() {
+			msgs = append(msgs, scanner.Text())
+		}
+		if scanner.Err() != nil {
+			t.Fatalf("Unexpected scanner error - %s", scanner.Err())
+		}
+		if len(msgs) != 3 || msgs[0] != "abc" || msgs[1] != "" || msgs[2] != "d" {
+			t.Errorf("Unexpected messages, got %q", msgs)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		scanner := bufio.NewScanner(bytes.NewReader(stream[:4]))
+		scanner.Split(SplitFunc(MLI2E))
+		for scanner.Scan() {
+			t.Errorf("Unexpected message %q", scanner.Text())
+		}
+		if scanner.Err() != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", scanner.Err())
+		}
+	})
+
+	t.Run("Invalid MLI", func(t *testing.T) {
+		scanner := bufio.NewScanner(bytes.NewReader([]byte{0x00, 0x01, 'a'}))
+		scanner.Split(SplitFunc(MLI2I))
+		if scanner.Scan() || !errors.Is(scanner.Err(), ErrLength) {
+			t.Errorf("Expected ErrLength, got %v", scanner.Err())
+		}
+	})
+
+	t.Run("Max Length", func(t *testing.T) {
+		scanner := bufio.NewScanner(bytes.NewReader(stream))
+		scanner.Split(SplitFunc(MLI2E, MaxLength(2)))
+		if scanner.Scan() || !errors.Is(scanner.Err(), ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", scanner.Err())
+		}
+	})
+
+	t.Run("Large Message", func(t *testing.T) {
+		msg := bytes.Repeat([]byte{'x'}, 100000)
+		frame, _ := EncodeFrame(MLI4E, msg)
+		scanner := bufio.NewScanner(bytes.NewReader(frame))
+		scanner.Buffer(nil, 200000)
+		scanner.Split(SplitFunc(MLI4E))
+		if !scanner.Scan() || len(scanner.Bytes()) != len(msg) {
+			t.Errorf("Expected a %d byte message, got %d, %v", len(msg), len(scanner.Bytes()), scanner.Err())
+		}
+	})
+}
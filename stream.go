@@ -0,0 +1,638 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Reader reads MLI-framed messages from an io.Reader, and is the canonical way to consume a framed stream message by
+// message. The Reader owns the scratch buffer for the MLI and reads both the MLI and message with io.ReadFull, so
+// short reads from a TCP connection never split a frame.
+//
+//	r := simplemli.NewReader(conn, simplemli.MLI2I, simplemli.WithMaxLength(8192))
+//	for {
+//		msg, err := r.ReadMessage()
+//		if err != nil {
+//			// Do something
+//		}
+//	}
+//
+// A Reader is not safe for concurrent use, except for SetCodec.
+type Reader struct {
+	r    io.Reader
+	br   *bufio.Reader
+	mli  []byte
+	err  error
+	opts options
+	seq  *sequence
+	ra   *readAhead
+	gate *gate
+
+	// stop releases reads blocked by Pause, it is the closed channel of the owning MessageConn
+	stop <-chan struct{}
+
+	// mu guards the codec, which may be replaced by SetCodec while a read is in progress
+	mu    sync.Mutex
+	codec Codec
+	key   string
+}
+
+// NewReader returns a Reader for messages framed with the MLI type key, or the default type set by SetDefault if key
+// is empty. An invalid key is reported by ReadMessage.
+func NewReader(r io.Reader, key string, opts ...Option) *Reader {
+	key = orDefault(key)
+	o := newOptions(opts)
+	g := &gate{}
+	var ra *readAhead
+	if o.readAhead > 0 {
+		size := o.bufferSize
+		if size <= 0 {
+			size = defaultBufferSize
+		}
+		ra = newReadAhead(r, size, o.readAhead, g)
+		r = ra
+	}
+	var br *bufio.Reader
+	if o.bufferSize > 0 || o.fillerSet {
+		size := o.bufferSize
+		if size <= 0 {
+			size = defaultBufferSize
+		}
+		br = bufio.NewReaderSize(r, size)
+		r = br
+	}
+	c, err := New(key)
+	seq, serr := newSequence(o)
+	if err == nil {
+		err = serr
+	}
+	return &Reader{r: r, br: br, err: err, opts: o, seq: seq, ra: ra, gate: g, codec: c, key: key}
+}
+
+// NewReader is NewReader for the MLI type t.
+func (t MLIType) NewReader(r io.Reader, opts ...Option) *Reader {
+	return NewReader(r, string(t), opts...)
+}
+
+// SetCodec switches the framing of subsequent messages to c. A read already in progress completes with the previous
+// codec, so the switch always happens at a frame boundary. SetCodec may be called concurrently with reads, for
+// example when a sign-on exchange negotiates a new framing mode.
+func (r *Reader) SetCodec(c Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codec = c
+	r.key = codecKey(c)
+	r.err = nil
+	if r.seq != nil {
+		r.err = r.seq.validate()
+	}
+}
+
+// current returns the codec and MLI type for the next frame
+func (r *Reader) current() (Codec, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.codec, r.key, r.err
+}
+
+// skipFiller discards any run of the inter-frame filler byte
+func (r *Reader) skipFiller() error {
+	for {
+		c, err := r.br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if c != r.opts.filler {
+			return r.br.UnreadByte()
+		}
+	}
+}
+
+// ReadMessage reads the next message and returns it without the MLI. ReadMessage returns io.EOF when the stream ends
+// cleanly between messages and io.ErrUnexpectedEOF when it ends part way through one.
+func (r *Reader) ReadMessage() ([]byte, error) {
+	f, err := r.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return f.Body, nil
+}
+
+// ReadFrame reads the next message and returns it with its metadata. The frame's MLI and Body share a single
+// allocation owned by the caller.
+func (r *Reader) ReadFrame() (Frame, error) {
+	var f Frame
+	err := r.ReadFrameInto(&f)
+	return f, err
+}
+
+// ReadFrameInto reads the next message into f, reusing the capacity of f.MLI and f.Body where large enough. Combined
+// with Frame.Reset this allows frames to be pooled by high-throughput consumers. The contents of f are undefined if
+// ReadFrameInto returns an error.
+//
+//	f := pool.Get().(*simplemli.Frame)
+//	err := r.ReadFrameInto(f)
+//	// ... use f
+//	f.Reset()
+//	pool.Put(f)
+func (r *Reader) ReadFrameInto(f *Frame) error {
+	return r.readFrameInto(context.Background(), f)
+}
+
+// readFrameInto is ReadFrameInto, abandoning a wait for the memory budget if ctx is done
+func (r *Reader) readFrameInto(ctx context.Context, f *Frame) error {
+	if !r.gate.wait(r.stop) {
+		return net.ErrClosed
+	}
+	c, key, err := r.current()
+	if err != nil {
+		return err
+	}
+
+	err = r.read(ctx, f, c, key)
+	if err != nil {
+		if err != io.EOF {
+			r.opts.logf("simplemli: unable to read %s message - %s", key, err)
+		}
+		return err
+	}
+	if r.opts.onRead != nil {
+		r.opts.onRead(f.Body)
+	}
+	return nil
+}
+
+func (r *Reader) read(ctx context.Context, f *Frame, c Codec, key string) error {
+	if r.opts.fillerSet {
+		err := r.skipFiller()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(r.mli) != c.Size() {
+		r.mli = make([]byte, c.Size())
+	}
+	_, err := io.ReadFull(r.r, r.mli)
+	if err != nil {
+		return err
+	}
+
+	var n int
+	if k, ok := c.(keyCodec); ok && r.opts.spacePadded {
+		n, err = DecodeBytes(k.key, r.mli, SpacePadded())
+	} else {
+		n, err = c.Decode(r.mli)
+	}
+	if err != nil {
+		return err
+	}
+	if r.opts.maxLength > 0 && n > r.opts.maxLength {
+		return fmt.Errorf("%w - got %d bytes, limit %d", ErrTooLarge, n, r.opts.maxLength)
+	}
+	if r.opts.budget != nil && n > 0 {
+		err = r.opts.budget.acquire(ctx, r.stop, n)
+		if err == errStopped {
+			return net.ErrClosed
+		}
+		if err != nil && !errors.Is(err, ErrBudgetExceeded) {
+			// The wait was abandoned by ctx, leaving the message unread
+			return err
+		}
+		if err != nil {
+			// Skip the message without buffering it so the stream stays aligned to the next frame
+			_, derr := io.CopyN(io.Discard, r.r, int64(n))
+			if derr == io.EOF {
+				derr = io.ErrUnexpectedEOF
+			}
+			if derr != nil {
+				return derr
+			}
+			return err
+		}
+		defer r.opts.budget.Release(n)
+	}
+
+	size := len(r.mli)
+	if cap(f.Body) >= n {
+		f.MLI = append(f.MLI[:0], r.mli...)
+		f.Body = f.Body[:n]
+	} else {
+		buf := make([]byte, size+n)
+		copy(buf, r.mli)
+		f.MLI = buf[:size:size]
+		f.Body = buf[size:]
+	}
+
+	_, err = io.ReadFull(r.r, f.Body)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	received := time.Now()
+	if r.opts.audit != nil {
+		err = r.opts.audit.record(Inbound, received, f.MLI, f.Body)
+		if err != nil {
+			return fmt.Errorf("unable to record inbound frame - %w", err)
+		}
+	}
+	if r.opts.tee != nil {
+		r.opts.tee.mirror(f.MLI, f.Body)
+	}
+	if r.seq != nil {
+		f.Body, err = r.seq.strip(f.Body)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.Key = key
+	f.Length = len(f.Body)
+	f.Received = received
+	f.Tag = r.opts.tag
+	return nil
+}
+
+// Writer writes MLI-framed messages to an io.Writer. Each message is written with its MLI in a single write, so
+// frames are never interleaved and a Writer is safe for concurrent use.
+type Writer struct {
+	w    io.Writer
+	opts options
+	ka   *KeepaliveWriter
+	rl   *RateLimiter
+
+	mu    sync.Mutex
+	buf   []byte
+	codec Codec
+	key   string
+	err   error
+	seq   *sequence
+	limit int
+
+	// partial describes a frame interrupted part way through, pending holds its unsent bytes and msg its message
+	partial *PartialWriteError
+	pending []byte
+	msg     []byte
+}
+
+// NewWriter returns a Writer framing messages with the MLI type key, or the default type set by SetDefault if key is
+// empty. An invalid key is reported by WriteMessage.
+func NewWriter(w io.Writer, key string, opts ...Option) *Writer {
+	key = orDefault(key)
+	o := newOptions(opts)
+	c, err := New(key)
+	seq, serr := newSequence(o)
+	if err == nil {
+		err = serr
+	}
+	var ka *KeepaliveWriter
+	if o.keepalive != nil && o.keepaliveInt > 0 {
+		ka, _ = NewKeepaliveWriter(w, o.keepalive, o.keepaliveInt)
+		w = ka
+	}
+	var rl *RateLimiter
+	if o.rateSet {
+		rl, _ = NewRateLimiter(o.rate, 1)
+	}
+	return &Writer{w: w, opts: o, ka: ka, rl: rl, buf: make([]byte, 0, o.bufferSize), codec: c, key: key, err: err, seq: seq}
+}
+
+// NewWriter is NewWriter for the MLI type t.
+func (t MLIType) NewWriter(w io.Writer, opts ...Option) *Writer {
+	return NewWriter(w, string(t), opts...)
+}
+
+// SetCodec switches the framing of subsequent messages to c. A write in progress completes with the previous codec,
+// so the switch always happens at a frame boundary.
+func (w *Writer) SetCodec(c Codec) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.codec = c
+	w.key = codecKey(c)
+	w.err = nil
+	if w.seq != nil {
+		w.err = w.seq.validate()
+	}
+}
+
+// lowerMaxLength rejects outbound messages longer than n bytes, unless the current limit is already smaller. Zero
+// leaves the current limit unchanged
+func (w *Writer) lowerMaxLength(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.limit = lowerLimit(w.limit, n)
+}
+
+// WriteMessage encodes an MLI for msg and writes the MLI and message. If the frame is only partly written,
+// WriteMessage returns a *PartialWriteError and the Writer refuses further messages until Resume completes the frame.
+func (w *Writer) WriteMessage(msg []byte) error {
+	if w.opts.rateSet {
+		if w.rl == nil {
+			return ErrInvalidRate
+		}
+		err := w.rl.permit(w.opts.ratePolicy)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	if w.err != nil {
+		w.mu.Unlock()
+		return w.err
+	}
+	if w.partial != nil {
+		err := w.partial
+		w.mu.Unlock()
+		return err
+	}
+	if w.limit > 0 && len(msg) > w.limit {
+		limit := w.limit
+		w.mu.Unlock()
+		return fmt.Errorf("%w - got %d bytes, limit %d", ErrTooLarge, len(msg), limit)
+	}
+
+	size, n := w.codec.Size(), len(msg)
+	if w.seq != nil {
+		n += w.seq.size
+	}
+	if cap(w.buf) < size+n {
+		w.buf = make([]byte, size+n)
+	}
+	w.buf = w.buf[:size+n]
+	err := w.codec.Encode(n, w.buf[:size])
+	if err == nil && w.seq != nil {
+		err = w.seq.insert(w.buf[size:], msg)
+	} else if err == nil {
+		copy(w.buf[size:], msg)
+	}
+	if err != nil {
+		w.mu.Unlock()
+		return err
+	}
+
+	if w.opts.audit != nil {
+		err = w.opts.audit.record(Outbound, time.Now(), nil, w.buf)
+		if err != nil {
+			w.mu.Unlock()
+			return fmt.Errorf("unable to record outbound frame - %w", err)
+		}
+	}
+
+	written, err := w.w.Write(w.buf)
+	if err == nil && w.opts.tee != nil {
+		w.opts.tee.mirror(nil, w.buf)
+	}
+	key := w.key
+	if w.seq != nil && (err == nil || written > 0) {
+		// The sequence number is only used up once the frame, or the start of it, reaches the peer
+		w.seq.advance()
+	}
+	if err != nil && written > 0 && written < len(w.buf) {
+		w.partial = &PartialWriteError{Written: written, Total: len(w.buf), Err: err}
+		w.pending = w.buf[written:]
+		w.msg = append([]byte(nil), msg...)
+		err = w.partial
+	}
+	w.mu.Unlock()
+	if err != nil {
+		w.opts.logf("simplemli: unable to write %s message - %s", key, err)
+		return err
+	}
+
+	if w.opts.onWrite != nil {
+		w.opts.onWrite(msg)
+	}
+	return nil
+}
+
+// Resume writes the remainder of a partly written frame, returning nil if no frame is pending. If the remainder is
+// again only partly written, Resume returns an updated *PartialWriteError and may be retried. Callers which cannot
+// complete the frame, for example because the connection has failed, should discard the connection.
+func (w *Writer) Resume() error {
+	w.mu.Lock()
+	if w.partial == nil {
+		w.mu.Unlock()
+		return nil
+	}
+
+	n, err := w.w.Write(w.pending)
+	w.pending = w.pending[n:]
+	if err != nil || len(w.pending) > 0 {
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		w.partial = &PartialWriteError{Written: w.partial.Total - len(w.pending), Total: w.partial.Total, Err: err}
+		err = w.partial
+		w.mu.Unlock()
+		return err
+	}
+
+	if w.opts.tee != nil {
+		w.opts.tee.mirror(nil, w.buf)
+	}
+	msg := w.msg
+	w.partial, w.pending, w.msg = nil, nil, nil
+	w.mu.Unlock()
+	if w.opts.onWrite != nil {
+		w.opts.onWrite(msg)
+	}
+	return nil
+}
+
+// MessageConn wraps a net.Conn with message semantics, reading and writing whole MLI-framed messages. MessageConn
+// embeds the net.Conn, so deadlines, addresses and Close are available directly. A deadline set with SetDeadline
+// applies to ReadMessage and WriteMessage unless WithReadTimeout or WithWriteTimeout is configured, as those replace
+// the deadline on every call.
+//
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithReadTimeout(30*time.Second))
+//	defer conn.Close()
+//
+//	err := conn.WriteMessage(req)
+//	if err != nil {
+//		// Do something
+//	}
+//	resp, err := conn.ReadMessage()
+//
+// ReadMessage must not be called concurrently, WriteMessage is safe for concurrent use.
+type MessageConn struct {
+	net.Conn
+	r    *Reader
+	w    *Writer
+	opts options
+	idle *time.Timer
+
+	// closed is closed by Close to stop background work tied to the connection
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMessageConn wraps conn, framing messages with the MLI type key, or the default type set by SetDefault if key is
+// empty.
+func NewMessageConn(conn net.Conn, key string, opts ...Option) *MessageConn {
+	return NewAsymmetricMessageConn(conn, key, key, opts...)
+}
+
+// NewMessageConn is NewMessageConn for the MLI type t.
+func (t MLIType) NewMessageConn(conn net.Conn, opts ...Option) *MessageConn {
+	return NewMessageConn(conn, string(t), opts...)
+}
+
+// NewAsymmetricMessageConn wraps conn for a peer which frames the messages it sends with a different MLI type from
+// the one it expects to receive. Inbound messages are read with inKey and outbound messages are written with outKey.
+//
+//	// Partner sends 2I but requires 2E
+//	conn := simplemli.NewAsymmetricMessageConn(c, simplemli.MLI2I, simplemli.MLI2E)
+func NewAsymmetricMessageConn(conn net.Conn, inKey, outKey string, opts ...Option) *MessageConn {
+	c := &MessageConn{
+		Conn:   conn,
+		r:      NewReader(conn, inKey, opts...),
+		w:      NewWriter(conn, outKey, opts...),
+		opts:   newOptions(opts),
+		closed: make(chan struct{}),
+	}
+	c.r.stop = c.closed
+	if c.opts.idleTimeout > 0 {
+		c.idle = time.AfterFunc(c.opts.idleTimeout, c.onIdle)
+	}
+	return c
+}
+
+// onIdle is called when the idle timeout expires without traffic
+func (c *MessageConn) onIdle() {
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+	if c.opts.onIdle != nil {
+		c.opts.onIdle(c.Conn)
+		return
+	}
+	c.opts.logf("simplemli: closing connection to %s after %s idle", c.Conn.RemoteAddr(), c.opts.idleTimeout)
+	_ = c.shutdown()
+}
+
+// touch restarts the idle timeout after a message is read or written, unless the connection has been closed
+func (c *MessageConn) touch() {
+	if c.idle == nil {
+		return
+	}
+	c.idle.Reset(c.opts.idleTimeout)
+	select {
+	case <-c.closed:
+		// Close may have stopped the timer before the reset, so stop it again
+		c.idle.Stop()
+	default:
+	}
+}
+
+// Close stops the idle timeout, read-ahead and keepalives, if configured, and closes the connection.
+func (c *MessageConn) Close() error {
+	if c.idle != nil {
+		c.idle.Stop()
+	}
+	return c.shutdown()
+}
+
+// shutdown is Close without stopping the idle timer, for use by the timer itself
+func (c *MessageConn) shutdown() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	if c.r.ra != nil {
+		c.r.ra.close()
+	}
+	if c.w.ka != nil {
+		c.w.ka.Stop()
+	}
+	return c.Conn.Close()
+}
+
+// ReadMessage reads the next message and returns it without the MLI, applying the read timeout if configured.
+func (c *MessageConn) ReadMessage() ([]byte, error) {
+	if c.opts.readTimeout > 0 {
+		err := c.Conn.SetReadDeadline(time.Now().Add(c.opts.readTimeout))
+		if err != nil {
+			return nil, err
+		}
+	}
+	msg, err := c.r.ReadMessage()
+	if err == nil {
+		c.touch()
+	}
+	return msg, err
+}
+
+// ReadFrame reads the next message and returns it with its metadata, applying the read timeout if configured.
+func (c *MessageConn) ReadFrame() (Frame, error) {
+	if c.opts.readTimeout > 0 {
+		err := c.Conn.SetReadDeadline(time.Now().Add(c.opts.readTimeout))
+		if err != nil {
+			return Frame{}, err
+		}
+	}
+	f, err := c.r.ReadFrame()
+	if err == nil {
+		c.touch()
+	}
+	return f, err
+}
+
+// SetCodec switches the framing of subsequent inbound and outbound messages to c, each direction switching at its next
+// frame boundary. Hosts which negotiate a new framing mode during sign-on can switch without re-establishing the
+// connection.
+func (c *MessageConn) SetCodec(codec Codec) {
+	c.r.SetCodec(codec)
+	c.w.SetCodec(codec)
+}
+
+// Resume writes the remainder of a frame interrupted by a write timeout, applying the write timeout if configured.
+func (c *MessageConn) Resume() error {
+	if c.opts.writeTimeout > 0 {
+		err := c.Conn.SetWriteDeadline(time.Now().Add(c.opts.writeTimeout))
+		if err != nil {
+			return err
+		}
+	}
+	return c.w.Resume()
+}
+
+// WriteMessage frames msg with an MLI and writes it, applying the write timeout if configured.
+func (c *MessageConn) WriteMessage(msg []byte) error {
+	if c.opts.writeTimeout > 0 {
+		err := c.Conn.SetWriteDeadline(time.Now().Add(c.opts.writeTimeout))
+		if err != nil {
+			return err
+		}
+	}
+	err := c.w.WriteMessage(msg)
+	if err == nil {
+		c.touch()
+	}
+	return err
+}
@@ -0,0 +1,616 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// testLogger collects logged messages
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestReader(t *testing.T) {
+	stream := []byte{0x00, 0x05, 'a', 'b', 'c', 0x00, 0x03, 'd'}
+
+	t.Run("Read Messages", func(t *testing.T) {
+		var hooked [][]byte
+		r := NewReader(bytes.NewReader(stream), MLI2I, WithBufferSize(16), WithReadHook(func(msg []byte) {
+			hooked = append(hooked, msg)
+		}))
+		for _, want := range []string{"abc", "d"} {
+			msg, err := r.ReadMessage()
+			if err != nil || string(msg) != want {
+				t.Errorf("Unexpected message, got %q, %v expected %q", msg, err, want)
+			}
+		}
+		_, err := r.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF at end of stream, got %v", err)
+		}
+		if len(hooked) != 2 {
+			t.Errorf("Expected read hook to be called twice, got %d", len(hooked))
+		}
+	})
+
+	t.Run("Short Reads", func(t *testing.T) {
+		r := NewReader(iotest.OneByteReader(bytes.NewReader(stream)), MLI2I)
+		for _, want := range []string{"abc", "d"} {
+			msg, err := r.ReadMessage()
+			if err != nil || string(msg) != want {
+				t.Errorf("Unexpected message, got %q, %v expected %q", msg, err, want)
+			}
+		}
+		_, err := r.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF at end of stream, got %v", err)
+		}
+	})
+
+	t.Run("Read Frames", func(t *testing.T) {
+		before := time.Now()
+		r := NewReader(bytes.NewReader(stream), MLI2I, WithTag("partner-a"))
+		f, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("Unexpected error reading frame - %s", err)
+		}
+		if f.Key != MLI2I || f.Length != 3 || f.Tag != "partner-a" || string(f.Body) != "abc" {
+			t.Errorf("Unexpected frame, got %+v", f)
+		}
+		if !bytes.Equal(f.MLI, stream[:2]) {
+			t.Errorf("Unexpected raw MLI, got %x", f.MLI)
+		}
+		if f.Received.Before(before) {
+			t.Errorf("Unexpected receive time %s", f.Received)
+		}
+
+		// The raw MLI must not alias the Reader's scratch buffer
+		_, err = r.ReadFrame()
+		if err != nil || !bytes.Equal(f.MLI, stream[:2]) {
+			t.Errorf("Frame MLI changed by subsequent read, got %x, %v", f.MLI, err)
+		}
+	})
+
+	t.Run("Inter-frame Filler", func(t *testing.T) {
+		padded := []byte{0xff, 0xff, 0x00, 0x05, 'a', 'b', 'c', 0xff, 0x00, 0x03, 'd', 0xff, 0xff}
+		r := NewReader(bytes.NewReader(padded), MLI2I, WithInterFrameFiller(0xff))
+		for _, want := range []string{"abc", "d"} {
+			msg, err := r.ReadMessage()
+			if err != nil || string(msg) != want {
+				t.Errorf("Unexpected message, got %q, %v expected %q", msg, err, want)
+			}
+		}
+		_, err := r.ReadMessage()
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF after trailing filler, got %v", err)
+		}
+
+		// Without the option the filler is decoded as an MLI
+		_, err = NewReader(bytes.NewReader(padded), MLI2I).ReadMessage()
+		if err == nil {
+			t.Errorf("Expected error reading filler without the option - got nil")
+		}
+	})
+
+	t.Run("Max Length", func(t *testing.T) {
+		l := &testLogger{}
+		r := NewReader(bytes.NewReader(stream), MLI2I, WithMaxLength(2), WithLogger(l))
+		_, err := r.ReadMessage()
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+		if len(l.lines) != 1 || !strings.Contains(l.lines[0], "exceeds maximum length") {
+			t.Errorf("Expected framing error to be logged, got %q", l.lines)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(stream[:4]), MLI2I)
+		_, err := r.ReadMessage()
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(stream), "3Q")
+		_, err := r.ReadMessage()
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+	})
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("Write Messages", func(t *testing.T) {
+		var buf bytes.Buffer
+		var hooked int
+		w := NewWriter(&buf, MLI2E, WithBufferSize(64), WithWriteHook(func([]byte) { hooked++ }))
+		for _, msg := range []string{"abc", "d"} {
+			err := w.WriteMessage([]byte(msg))
+			if err != nil {
+				t.Errorf("Unexpected error writing message - %s", err)
+			}
+		}
+		expected := []byte{0x00, 0x03, 'a', 'b', 'c', 0x00, 0x01, 'd'}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Unexpected bytes written, got %x expected %x", buf.Bytes(), expected)
+		}
+		if hooked != 2 {
+			t.Errorf("Expected write hook to be called twice, got %d", hooked)
+		}
+	})
+
+	t.Run("Concurrent Writes", func(t *testing.T) {
+		lb := &lockedBuffer{}
+		w := NewWriter(lb, MLI2I)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				msg := bytes.Repeat([]byte{byte('a' + i)}, 10+i)
+				for j := 0; j < 100; j++ {
+					_ = w.WriteMessage(msg)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// Every frame must hold a single writer's message, MLIs are never interleaved into a body
+		r := NewReader(bytes.NewReader(lb.Bytes()), MLI2I)
+		for n := 0; n < 800; n++ {
+			msg, err := r.ReadMessage()
+			if err != nil || len(msg) < 10 || !bytes.Equal(msg, bytes.Repeat(msg[:1], 10+int(msg[0]-'a'))) {
+				t.Fatalf("Unexpected frame %d, got %q, %v", n, msg, err)
+			}
+		}
+	})
+
+	t.Run("Write Error", func(t *testing.T) {
+		l := &testLogger{}
+		var hooked int
+		w := NewWriter(&lockedBuffer{err: fmt.Errorf("broken pipe")}, MLI2E, WithLogger(l),
+			WithWriteHook(func([]byte) { hooked++ }))
+		err := w.WriteMessage([]byte("x"))
+		if err == nil {
+			t.Errorf("Expected write error - got nil")
+		}
+		if hooked != 0 || len(l.lines) != 1 {
+			t.Errorf("Expected error to be logged and hook skipped, got %d hooks and %q", hooked, l.lines)
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		err := NewWriter(&bytes.Buffer{}, "3Q").WriteMessage([]byte("x"))
+		if err == nil {
+			t.Errorf("Expected error for invalid key - got nil")
+		}
+	})
+}
+
+func TestMessageConn(t *testing.T) {
+	a, b := net.Pipe()
+	client := NewMessageConn(a, MLI4I, WithReadTimeout(time.Second), WithWriteTimeout(time.Second))
+	server := NewMessageConn(b, MLI4I, WithReadTimeout(20*time.Millisecond))
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		_ = client.WriteMessage([]byte("0800"))
+	}()
+	msg, err := server.ReadMessage()
+	if err != nil || string(msg) != "0800" {
+		t.Errorf("Unexpected message, got %q, %v", msg, err)
+	}
+
+	go func() {
+		_ = client.WriteMessage([]byte("0810"))
+	}()
+	f, err := server.ReadFrame()
+	if err != nil || string(f.Body) != "0810" || f.Length != 4 {
+		t.Errorf("Unexpected frame, got %+v, %v", f, err)
+	}
+
+	_, err = server.ReadMessage()
+	var nerr net.Error
+	if !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Errorf("Expected read timeout, got %v", err)
+	}
+}
+
+func TestMLITypeStream(t *testing.T) {
+	t.Run("Reader and Writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Type4E.NewWriter(&buf).WriteMessage([]byte("0800"))
+		if err != nil {
+			t.Fatalf("Unexpected error writing - %s", err)
+		}
+		f, err := Type4E.NewReader(&buf).ReadFrame()
+		if err != nil || f.Key != MLI4E || string(f.Body) != "0800" {
+			t.Errorf("Unexpected frame, got %+v, %v", f, err)
+		}
+	})
+
+	t.Run("MessageConn", func(t *testing.T) {
+		a, b := net.Pipe()
+		client := Type2I.NewMessageConn(a)
+		server := NewMessageConn(b, MLI2I)
+		defer client.Close()
+		defer server.Close()
+
+		go func() {
+			_ = client.WriteMessage([]byte("0800"))
+		}()
+		msg, err := server.ReadMessage()
+		if err != nil || string(msg) != "0800" {
+			t.Errorf("Unexpected message, got %q, %v", msg, err)
+		}
+	})
+}
+
+func TestMessageConnDeadline(t *testing.T) {
+	a, b := net.Pipe()
+	conn := NewMessageConn(a, MLI2I)
+	defer conn.Close()
+	defer b.Close()
+
+	err := conn.SetDeadline(time.Now().Add(20 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unexpected error setting deadline - %s", err)
+	}
+
+	var nerr net.Error
+	_, err = conn.ReadMessage()
+	if !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Errorf("Expected read to time out, got %v", err)
+	}
+	err = conn.WriteMessage([]byte("0800"))
+	if !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Errorf("Expected write to time out, got %v", err)
+	}
+
+	err = conn.Close()
+	if err != nil {
+		t.Errorf("Unexpected error closing - %s", err)
+	}
+	_, err = conn.ReadMessage()
+	if err == nil {
+		t.Errorf("Expected error reading a closed conn - got nil")
+	}
+}
+
+func TestAsymmetricMessageConn(t *testing.T) {
+	a, b := net.Pipe()
+	conn := NewAsymmetricMessageConn(a, MLI2I, MLI2E)
+	peer := NewAsymmetricMessageConn(b, MLI2E, MLI2I)
+	defer conn.Close()
+	defer peer.Close()
+
+	go func() {
+		_ = peer.WriteMessage([]byte("0100"))
+	}()
+	raw := make([]byte, 2)
+	_, err := io.ReadFull(conn.Conn, raw)
+	if err != nil || !bytes.Equal(raw, []byte{0x00, 0x06}) {
+		t.Fatalf("Expected 2I MLI from peer, got %x, %v", raw, err)
+	}
+	_, _ = io.ReadFull(conn.Conn, make([]byte, 4))
+
+	go func() {
+		_ = conn.WriteMessage([]byte("0110"))
+	}()
+	msg, err := peer.ReadMessage()
+	if err != nil || string(msg) != "0110" {
+		t.Errorf("Unexpected message read with 2E, got %q, %v", msg, err)
+	}
+
+	go func() {
+		_ = peer.WriteMessage([]byte("0800"))
+	}()
+	msg, err = conn.ReadMessage()
+	if err != nil || string(msg) != "0800" {
+		t.Errorf("Unexpected message read with 2I, got %q, %v", msg, err)
+	}
+}
+
+func TestSetCodec(t *testing.T) {
+	c4e, _ := New(MLI4E)
+
+	t.Run("Reader", func(t *testing.T) {
+		stream := []byte{0x00, 0x05, 'a', 'b', 'c', 0x00, 0x00, 0x00, 0x02, 'd', 'e'}
+		r := NewReader(bytes.NewReader(stream), MLI2I)
+		msg, err := r.ReadMessage()
+		if err != nil || string(msg) != "abc" {
+			t.Fatalf("Unexpected message before switch, got %q, %v", msg, err)
+		}
+		r.SetCodec(c4e)
+		f, err := r.ReadFrame()
+		if err != nil || string(f.Body) != "de" || f.Key != MLI4E || len(f.MLI) != Size4E {
+			t.Errorf("Unexpected frame after switch, got %+v, %v", f, err)
+		}
+	})
+
+	t.Run("Writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, MLI2I)
+		_ = w.WriteMessage([]byte("abc"))
+		w.SetCodec(c4e)
+		_ = w.WriteMessage([]byte("de"))
+		expected := []byte{0x00, 0x05, 'a', 'b', 'c', 0x00, 0x00, 0x00, 0x02, 'd', 'e'}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Unexpected bytes written, got %x expected %x", buf.Bytes(), expected)
+		}
+	})
+
+	t.Run("Replaces Invalid Key", func(t *testing.T) {
+		r := NewReader(bytes.NewReader([]byte{0x00, 0x00, 0x00, 0x01, 'x'}), "3Q")
+		r.SetCodec(c4e)
+		msg, err := r.ReadMessage()
+		if err != nil || string(msg) != "x" {
+			t.Errorf("Unexpected message, got %q, %v", msg, err)
+		}
+	})
+
+	t.Run("MessageConn", func(t *testing.T) {
+		a, b := net.Pipe()
+		client := NewMessageConn(a, MLI2I)
+		server := NewMessageConn(b, MLI2I)
+		defer client.Close()
+		defer server.Close()
+
+		done := make(chan error, 1)
+		go func() {
+			err := client.WriteMessage([]byte("sign-on"))
+			if err == nil {
+				client.SetCodec(c4e)
+				err = client.WriteMessage([]byte("0100"))
+			}
+			done <- err
+		}()
+
+		msg, err := server.ReadMessage()
+		if err != nil || string(msg) != "sign-on" {
+			t.Fatalf("Unexpected sign-on message, got %q, %v", msg, err)
+		}
+		server.SetCodec(c4e)
+		f, err := server.ReadFrame()
+		if err != nil || string(f.Body) != "0100" || f.Key != MLI4E {
+			t.Errorf("Unexpected frame after switch, got %+v, %v", f, err)
+		}
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected error writing - %s", err)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		w := NewWriter(&lockedBuffer{}, MLI2I)
+		c2i, _ := New(MLI2I)
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < 100; i++ {
+				_ = w.WriteMessage([]byte("x"))
+			}
+			close(done)
+		}()
+		for i := 0; i < 100; i++ {
+			if i%2 == 0 {
+				w.SetCodec(c4e)
+			} else {
+				w.SetCodec(c2i)
+			}
+		}
+		<-done
+	})
+}
+
+func TestIdleTimeout(t *testing.T) {
+	t.Run("Close", func(t *testing.T) {
+		a, b := net.Pipe()
+		defer b.Close()
+		conn := NewMessageConn(a, MLI2I, WithIdleTimeout(20*time.Millisecond, nil))
+		defer conn.Close()
+
+		_, err := conn.ReadMessage()
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("Expected idle connection to be closed, got %v", err)
+		}
+		select {
+		case <-conn.closed:
+		default:
+			t.Errorf("Expected idle timeout to close the MessageConn, not only the underlying connection")
+		}
+	})
+
+	t.Run("After Close", func(t *testing.T) {
+		a, b := net.Pipe()
+		defer b.Close()
+		idle := make(chan net.Conn, 1)
+		conn := NewMessageConn(a, MLI2I, WithIdleTimeout(20*time.Millisecond, func(c net.Conn) { idle <- c }))
+		conn.Close()
+
+		conn.touch()
+		select {
+		case <-idle:
+			t.Errorf("Idle callback called after Close")
+		case <-time.After(60 * time.Millisecond):
+		}
+	})
+
+	t.Run("Traffic Resets", func(t *testing.T) {
+		a, b := net.Pipe()
+		idle := make(chan net.Conn, 1)
+		conn := NewMessageConn(a, MLI2I, WithIdleTimeout(50*time.Millisecond, func(c net.Conn) { idle <- c }))
+		peer := NewMessageConn(b, MLI2I)
+		defer conn.Close()
+		defer peer.Close()
+
+		go func() {
+			for i := 0; i < 4; i++ {
+				time.Sleep(20 * time.Millisecond)
+				_ = peer.WriteMessage([]byte("0800"))
+			}
+		}()
+		for i := 0; i < 4; i++ {
+			_, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("Unexpected error reading - %s", err)
+			}
+		}
+		select {
+		case <-idle:
+			t.Fatalf("Idle callback called while traffic was flowing")
+		default:
+		}
+
+		select {
+		case c := <-idle:
+			if c != a {
+				t.Errorf("Expected callback with the underlying connection")
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Expected idle callback after traffic stopped")
+		}
+	})
+}
+
+// shortWriter writes at most limit bytes per call, returning err when it cannot write everything
+type shortWriter struct {
+	bytes.Buffer
+	limit int
+	err   error
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > s.limit {
+		n, _ := s.Buffer.Write(p[:s.limit])
+		return n, s.err
+	}
+	return s.Buffer.Write(p)
+}
+
+func TestPartialWrite(t *testing.T) {
+	timeout := fmt.Errorf("i/o timeout")
+
+	t.Run("Resume", func(t *testing.T) {
+		sw := &shortWriter{limit: 3, err: timeout}
+		var hooked []string
+		w := NewWriter(sw, MLI2E, WithWriteHook(func(msg []byte) { hooked = append(hooked, string(msg)) }))
+
+		err := w.WriteMessage([]byte("abcdefg"))
+		var perr *PartialWriteError
+		if !errors.As(err, &perr) || perr.Written != 3 || perr.Total != 9 || !errors.Is(err, timeout) {
+			t.Fatalf("Expected PartialWriteError for 3 of 9 bytes, got %v", err)
+		}
+
+		err = w.WriteMessage([]byte("x"))
+		if !errors.As(err, &perr) {
+			t.Errorf("Expected writes to be refused while a frame is pending, got %v", err)
+		}
+
+		err = w.Resume()
+		if !errors.As(err, &perr) || perr.Written != 6 {
+			t.Errorf("Expected PartialWriteError for 6 of 9 bytes, got %v", err)
+		}
+		sw.limit = 64
+		err = w.Resume()
+		if err != nil {
+			t.Fatalf("Unexpected error resuming - %s", err)
+		}
+		err = w.WriteMessage([]byte("x"))
+		if err != nil {
+			t.Fatalf("Unexpected error writing after resume - %s", err)
+		}
+
+		expected := []byte{0x00, 0x07, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 0x00, 0x01, 'x'}
+		if !bytes.Equal(sw.Bytes(), expected) {
+			t.Errorf("Unexpected bytes written, got %x expected %x", sw.Bytes(), expected)
+		}
+		if len(hooked) != 2 || hooked[0] != "abcdefg" {
+			t.Errorf("Expected hook to be called once each message completed, got %q", hooked)
+		}
+		if err := w.Resume(); err != nil {
+			t.Errorf("Expected Resume without a pending frame to return nil, got %v", err)
+		}
+	})
+
+	t.Run("Nothing Written", func(t *testing.T) {
+		w := NewWriter(&shortWriter{limit: 0, err: timeout}, MLI2E)
+		err := w.WriteMessage([]byte("abc"))
+		var perr *PartialWriteError
+		if err == nil || errors.As(err, &perr) {
+			t.Errorf("Expected plain write error when nothing was sent, got %v", err)
+		}
+	})
+}
+
+func TestDefaultStream(t *testing.T) {
+	// Restore the unset default for other tests
+	defer defaultKey.Store("")
+	err := SetDefault(MLI2I)
+	if err != nil {
+		t.Fatalf("Unexpected error setting default - %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewWriter(&buf, "").WriteMessage([]byte("abc"))
+	if err != nil || !bytes.Equal(buf.Bytes(), []byte{0x00, 0x05, 'a', 'b', 'c'}) {
+		t.Errorf("Unexpected result from default Writer, got %x, %v", buf.Bytes(), err)
+	}
+	msg, err := NewReader(&buf, "").ReadMessage()
+	if err != nil || string(msg) != "abc" {
+		t.Errorf("Unexpected result from default Reader, got %q, %v", msg, err)
+	}
+}
+
+func TestFrameReset(t *testing.T) {
+	stream := []byte{0x00, 0x07, 'h', 'e', 'l', 'l', 'o', 0x00, 0x05, 'a', 'b', 'c'}
+	r := NewReader(bytes.NewReader(stream), MLI2I)
+
+	var f Frame
+	err := r.ReadFrameInto(&f)
+	if err != nil || string(f.Body) != "hello" {
+		t.Fatalf("Unexpected frame, got %+v, %v", f, err)
+	}
+	body := &f.Body[:1][0]
+
+	f.Reset()
+	if f.Key != "" || f.Length != 0 || len(f.Body) != 0 || cap(f.Body) < 5 {
+		t.Errorf("Unexpected frame after Reset, got %+v", f)
+	}
+
+	err = r.ReadFrameInto(&f)
+	if err != nil || string(f.Body) != "abc" || f.Length != 3 || f.Key != MLI2I {
+		t.Fatalf("Unexpected refilled frame, got %+v, %v", f, err)
+	}
+	if &f.Body[0] != body {
+		t.Errorf("Expected refilled frame to reuse the body buffer")
+	}
+}
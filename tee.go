@@ -0,0 +1,128 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"io"
+	"sync"
+)
+
+// defaultTeeQueue is the number of frames a Tee queues when a queue size is not provided
+const defaultTeeQueue = 1024
+
+// Tee mirrors frames to a secondary destination, such as a passive fraud monitoring consumer, without affecting the
+// primary path. Frames are queued and written by a background goroutine; when the queue is full or the destination
+// fails, frames are dropped rather than delaying the caller. A Tee may be shared by many Readers and Writers and is
+// safe for concurrent use.
+//
+//	tee := simplemli.NewTee(monitor, 0)
+//	defer tee.Close()
+//	conn := simplemli.NewMessageConn(c, simplemli.MLI2I, simplemli.WithTee(tee))
+type Tee struct {
+	w    io.Writer
+	ch   chan []byte
+	done chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+// NewTee starts a Tee mirroring frames to w, queueing at most queue frames. A queue of zero or less uses a default of
+// 1024 frames.
+func NewTee(w io.Writer, queue int) *Tee {
+	if queue <= 0 {
+		queue = defaultTeeQueue
+	}
+	t := &Tee{
+		w:    w,
+		ch:   make(chan []byte, queue),
+		done: make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// Write queues a copy of the frame p for mirroring and never blocks. Write always reports success, frames which cannot
+// be queued are counted by Dropped.
+func (t *Tee) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		t.dropped++
+		return len(p), nil
+	}
+
+	select {
+	case t.ch <- append([]byte(nil), p...):
+	default:
+		t.dropped++
+	}
+	return len(p), nil
+}
+
+// mirror queues the frame made up of mli and body
+func (t *Tee) mirror(mli, body []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		t.dropped++
+		return
+	}
+
+	b := make([]byte, len(mli)+len(body))
+	copy(b[copy(b, mli):], body)
+	select {
+	case t.ch <- b:
+	default:
+		t.dropped++
+	}
+}
+
+// loop writes queued frames until the Tee is closed
+func (t *Tee) loop() {
+	defer close(t.done)
+	for b := range t.ch {
+		_, err := t.w.Write(b)
+		if err != nil {
+			t.mu.Lock()
+			t.dropped++
+			t.mu.Unlock()
+		}
+	}
+}
+
+// Dropped returns the number of frames which were not mirrored, because the queue was full or the write failed.
+func (t *Tee) Dropped() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// Close stops accepting frames and waits for queued frames to be written.
+func (t *Tee) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.ch)
+	t.mu.Unlock()
+	<-t.done
+	return nil
+}
@@ -0,0 +1,87 @@
+//go:build !mlicore
+
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// blockingWriter blocks every write until release is closed
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return len(p), nil
+}
+
+func TestTee(t *testing.T) {
+	t.Run("Mirror", func(t *testing.T) {
+		var mirrored lockedBuffer
+		tee := NewTee(&mirrored, 0)
+
+		stream := []byte{0x00, 0x05, 'a', 'b', 'c'}
+		r := NewReader(bytes.NewReader(stream), MLI2I, WithTee(tee))
+		msg, err := r.ReadMessage()
+		if err != nil || string(msg) != "abc" {
+			t.Fatalf("Unexpected message, got %q, %v", msg, err)
+		}
+		msg[0] = 'x'
+
+		var primary bytes.Buffer
+		w := NewWriter(&primary, MLI2E, WithTee(tee))
+		_ = w.WriteMessage([]byte("de"))
+
+		_ = tee.Close()
+		expected := []byte{0x00, 0x05, 'a', 'b', 'c', 0x00, 0x02, 'd', 'e'}
+		if !bytes.Equal(mirrored.Bytes(), expected) {
+			t.Errorf("Unexpected mirrored frames, got %x expected %x", mirrored.Bytes(), expected)
+		}
+		if tee.Dropped() != 0 {
+			t.Errorf("Unexpected dropped frames, got %d", tee.Dropped())
+		}
+	})
+
+	t.Run("Full Queue", func(t *testing.T) {
+		bw := &blockingWriter{release: make(chan struct{})}
+		tee := NewTee(bw, 1)
+		w := NewWriter(&bytes.Buffer{}, MLI2E, WithTee(tee))
+		for i := 0; i < 10; i++ {
+			err := w.WriteMessage([]byte("x"))
+			if err != nil {
+				t.Fatalf("Unexpected error writing with a blocked tee - %s", err)
+			}
+		}
+		if tee.Dropped() < 8 {
+			t.Errorf("Expected frames to be dropped, got %d", tee.Dropped())
+		}
+		close(bw.release)
+		_ = tee.Close()
+		_, _ = tee.Write([]byte{0x00})
+	})
+
+	t.Run("Failed Mirror", func(t *testing.T) {
+		tee := NewTee(&lockedBuffer{err: fmt.Errorf("connection refused")}, 0)
+		_, _ = tee.Write([]byte{0x00, 0x00})
+		_ = tee.Close()
+		if tee.Dropped() != 1 {
+			t.Errorf("Expected failed write to be counted, got %d", tee.Dropped())
+		}
+	})
+}
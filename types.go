@@ -0,0 +1,195 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+
+package simplemli
+
+import (
+	"io"
+)
+
+// MLIType is an MLI type key. Passing the typed constants rather than bare strings lets the compiler catch a mistyped
+// key, and ParseType converts a key read from configuration, including the name of a registered codec.
+//
+//	b, err := simplemli.Type2I.Encode(len(msg))
+//
+// MLIType has a method for each of the package's entry points which take a key, such as New, ReadFrame, WriteFrame,
+// NewReader and NewMessageConn, so code holding an MLIType never needs a bare string. The MLI2I style string constants
+// remain the keys accepted by the package functions, string(t) converts an MLIType for those APIs.
+type MLIType string
+
+// Typed MLI types, see the matching MLI2I style constants for descriptions
+const (
+	Type2I     MLIType = MLI2I
+	Type2E     MLIType = MLI2E
+	Type4I     MLIType = MLI4I
+	Type4E     MLIType = MLI4E
+	Type2EE    MLIType = MLI2EE
+	Type2BCD2  MLIType = MLI2BCD2
+	TypeA4E    MLIType = MLIA4E
+	Type2IL    MLIType = MLI2IL
+	Type2EL    MLIType = MLI2EL
+	Type4IL    MLIType = MLI4IL
+	Type4EL    MLIType = MLI4EL
+	Type1I     MLIType = MLI1I
+	Type1E     MLIType = MLI1E
+	Type3I     MLIType = MLI3I
+	Type3E     MLIType = MLI3E
+	Type8I     MLIType = MLI8I
+	Type8E     MLIType = MLI8E
+	TypeA2E    MLIType = MLIA2E
+	TypeA6E    MLIType = MLIA6E
+	TypeA6I    MLIType = MLIA6I
+	TypeH4E    MLIType = MLIH4E
+	TypeE4E    MLIType = MLIE4E
+	TypeBCD2   MLIType = MLIBCD2
+	TypeBCD4   MLIType = MLIBCD4
+	TypeBCD4I  MLIType = MLIBCD4I
+	Type2BCD2I MLIType = MLI2BCD2I
+)
+
+// ParseType returns the MLIType for key, or ErrInvalidType if key is neither a built-in MLI type nor registered.
+func ParseType(key string) (MLIType, error) {
+	if _, err := Size(key); err != nil {
+		return "", err
+	}
+	return MLIType(key), nil
+}
+
+// String returns the MLI type key.
+func (t MLIType) String() string {
+	return string(t)
+}
+
+// Size returns the MLI size in bytes, or 0 for an invalid type.
+func (t MLIType) Size() int {
+	size, _ := Size(string(t))
+	return size
+}
+
+// Encode is Encode for the MLI type t.
+func (t MLIType) Encode(length int) ([]byte, error) {
+	return Encode(string(t), length)
+}
+
+// Decode is DecodeBytes for the MLI type t.
+func (t MLIType) Decode(b []byte, opts ...DecodeOption) (int, error) {
+	return DecodeBytes(string(t), b, opts...)
+}
+
+// Append is EncodeAppend for the MLI type t.
+func (t MLIType) Append(dst []byte, length int) ([]byte, error) {
+	return EncodeAppend(dst, string(t), length)
+}
+
+// New is New for the MLI type t.
+func (t MLIType) New(opts ...CodecOption) (Codec, error) {
+	return New(string(t), opts...)
+}
+
+// EncodeFrame is EncodeFrame for the MLI type t.
+func (t MLIType) EncodeFrame(msg []byte) ([]byte, error) {
+	return EncodeFrame(string(t), msg)
+}
+
+// ReadFrame is ReadFrame for the MLI type t.
+func (t MLIType) ReadFrame(r io.Reader, opts ...DecodeOption) ([]byte, error) {
+	return ReadFrame(r, string(t), opts...)
+}
+
+// WriteFrame is WriteFrame for the MLI type t.
+func (t MLIType) WriteFrame(w io.Writer, msg []byte) error {
+	return WriteFrame(w, string(t), msg)
+}
+
+// Deframe is Deframe for the MLI type t.
+func (t MLIType) Deframe(buf []byte, opts ...DecodeOption) (msg, rest []byte, err error) {
+	return Deframe(string(t), buf, opts...)
+}
+
+// TypeInfo describes a supported MLI type.
+type TypeInfo struct {
+	// Key is the MLI type passed to Encode and Decode
+	Key string
+
+	// Size is the MLI size in bytes
+	Size int
+
+	// MinLength and MaxLength are the smallest and largest message lengths the type can represent
+	MinLength int
+	MaxLength int
+
+	// Description is a short human-readable description of the encoding
+	Description string
+}
+
+// builtinTypes lists the built-in MLI types in documentation order
+var builtinTypes = []struct {
+	key         string
+	description string
+}{
+	{MLI2I, "2-byte network byte order with MLI included"},
+	{MLI2E, "2-byte network byte order with MLI excluded"},
+	{MLI4I, "4-byte network byte order with MLI included"},
+	{MLI4E, "4-byte network byte order with MLI excluded"},
+	{MLI2EE, "2-byte network byte order with MLI excluded, additional 2-byte header is included with message"},
+	{MLI2BCD2, "2-byte header with a 2-byte binary-coded decimal, the value counts the 4-byte MLI"},
+	{MLIA4E, "4-byte ASCII string with MLI excluded"},
+	{MLI2IL, "2-byte little-endian byte order with MLI included"},
+	{MLI2EL, "2-byte little-endian byte order with MLI excluded"},
+	{MLI4IL, "4-byte little-endian byte order with MLI included"},
+	{MLI4EL, "4-byte little-endian byte order with MLI excluded"},
+	{MLI1I, "1-byte length with MLI included"},
+	{MLI1E, "1-byte length with MLI excluded"},
+	{MLI3I, "3-byte network byte order with MLI included"},
+	{MLI3E, "3-byte network byte order with MLI excluded"},
+	{MLI8I, "8-byte network byte order with MLI included"},
+	{MLI8E, "8-byte network byte order with MLI excluded"},
+	{MLIA2E, "2-byte ASCII string with MLI excluded"},
+	{MLIA6E, "6-byte ASCII string with MLI excluded"},
+	{MLIA6I, "6-byte ASCII string with MLI included"},
+	{MLIH4E, "4-byte ASCII hexadecimal string with MLI excluded"},
+	{MLIE4E, "4-byte EBCDIC string with MLI excluded"},
+	{MLIBCD2, "2-byte binary-coded decimal without a header, with MLI excluded"},
+	{MLIBCD4, "4-byte binary-coded decimal with MLI excluded"},
+	{MLIBCD4I, "4-byte binary-coded decimal with MLI included"},
+	{MLI2BCD2I, "2-byte header with a 2-byte binary-coded decimal with MLI included"},
+}
+
+// Types returns metadata for every supported MLI type, allowing tools to enumerate capabilities at runtime. The
+// built-in types are listed in documentation order followed by registered codecs sorted by name. The length bounds of
+// a registered codec are not known, so MaxLength is the largest int.
+func Types() []TypeInfo {
+	keys := registeredKeys()
+	types := make([]TypeInfo, 0, len(builtinTypes)+len(keys))
+	for _, t := range builtinTypes {
+		types = append(types, typeInfo(t.key, t.description))
+	}
+	for _, key := range keys {
+		types = append(types, typeInfo(key, "registered codec"))
+	}
+	return types
+}
+
+// typeInfo returns the TypeInfo for a valid MLI type key
+func typeInfo(key, description string) TypeInfo {
+	size, _ := Size(key)
+	minLen, maxLen, _ := mliBounds(key)
+	return TypeInfo{
+		Key:         key,
+		Size:        size,
+		MinLength:   minLen,
+		MaxLength:   maxLen,
+		Description: description,
+	}
+}
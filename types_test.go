@@ -0,0 +1,164 @@
+/*
+ * Copyright 2020 American Express Travel Related Services Company, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ */
+package simplemli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTypes(t *testing.T) {
+	err := Register("listed5", ascii5{})
+	if err != nil {
+		t.Fatalf("Unexpected error registering codec - %s", err)
+	}
+
+	types := Types()
+	if len(types) < 27 {
+		t.Fatalf("Expected 26 built-in types and registered codecs, got %d", len(types))
+	}
+	for _, ti := range types[:26] {
+		t.Run(ti.Key, func(t *testing.T) {
+			if ti.Description == "" {
+				t.Errorf("Missing description")
+			}
+			b, err := Encode(ti.Key, ti.MaxLength)
+			if err != nil || len(b) != ti.Size {
+				t.Errorf("Unexpected result encoding max length, got %x, %v", b, err)
+			}
+			n, err := Decode(ti.Key, &b)
+			if err != nil || n != ti.MaxLength {
+				t.Errorf("Max length does not round trip, got %d, %v expected %d", n, err, ti.MaxLength)
+			}
+		})
+	}
+
+	t.Run("Registered", func(t *testing.T) {
+		for _, ti := range types[26:] {
+			if _, ok := builtin(ti.Key); ok {
+				t.Errorf("Built-in type %s listed after the registered codecs", ti.Key)
+			}
+			if ti.Key == "listed5" {
+				if ti.Size != 5 || ti.Description == "" {
+					t.Errorf("Unexpected registered type info, got %+v", ti)
+				}
+				return
+			}
+		}
+		t.Errorf("Registered codec missing from Types")
+	})
+}
+
+func TestMLIType(t *testing.T) {
+	for _, typ := range []MLIType{Type2I, Type2E, Type4I, Type4E, Type2EE, Type2BCD2, TypeA4E} {
+		t.Run(typ.String(), func(t *testing.T) {
+			parsed, err := ParseType(string(typ))
+			if err != nil || parsed != typ {
+				t.Errorf("Unexpected result from ParseType, got %q, %v", parsed, err)
+			}
+
+			size, _ := Size(string(typ))
+			if typ.Size() != size {
+				t.Errorf("Unexpected size, got %d expected %d", typ.Size(), size)
+			}
+
+			b, err := typ.Encode(1500)
+			if err != nil || len(b) != size {
+				t.Fatalf("Unexpected result from Encode, got %x, %v", b, err)
+			}
+			n, err := typ.Decode(b)
+			if err != nil || n != 1500 {
+				t.Errorf("Unexpected result from Decode, got %d, %v", n, err)
+			}
+		})
+	}
+
+	t.Run("Registered Type", func(t *testing.T) {
+		err := Register("typed5", ascii5{})
+		if err != nil {
+			t.Fatalf("Unexpected error registering codec - %s", err)
+		}
+		typ, err := ParseType("typed5")
+		if err != nil || typ != "typed5" {
+			t.Fatalf("Unexpected result from ParseType, got %q, %v", typ, err)
+		}
+		if typ.Size() != 5 {
+			t.Errorf("Unexpected size, got %d expected 5", typ.Size())
+		}
+		b, err := typ.Encode(1500)
+		if err != nil {
+			t.Fatalf("Unexpected error from Encode - %s", err)
+		}
+		n, err := typ.Decode(b)
+		if err != nil || n != 1500 {
+			t.Errorf("Unexpected result from Decode, got %d, %v", n, err)
+		}
+		_, err = typ.Decode(b, MaxLength(1000))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge from Decode with MaxLength, got %v", err)
+		}
+	})
+
+	t.Run("Frames", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Type2E.WriteFrame(&buf, []byte("0800"))
+		if err != nil {
+			t.Fatalf("Unexpected error from WriteFrame - %s", err)
+		}
+		frame, err := Type2E.EncodeFrame([]byte("0810"))
+		if err != nil {
+			t.Fatalf("Unexpected error from EncodeFrame - %s", err)
+		}
+		buf.Write(frame)
+
+		msg, err := Type2E.ReadFrame(&buf, MaxLength(4))
+		if err != nil || string(msg) != "0800" {
+			t.Errorf("Unexpected result from ReadFrame, got %q, %v", msg, err)
+		}
+		msg, rest, err := Type2E.Deframe(buf.Bytes())
+		if err != nil || string(msg) != "0810" || len(rest) != 0 {
+			t.Errorf("Unexpected result from Deframe, got %q %x, %v", msg, rest, err)
+		}
+
+		b, err := Type2E.Append([]byte{0xff}, 4)
+		if err != nil || !bytes.Equal(b, []byte{0xff, 0x00, 0x04}) {
+			t.Errorf("Unexpected result from Append, got %x, %v", b, err)
+		}
+		c, err := Type2E.New(Adjust(2))
+		if err != nil || c.Size() != Size2E {
+			t.Fatalf("Unexpected result from New, got %v, %v", c, err)
+		}
+	})
+
+	t.Run("Invalid Type", func(t *testing.T) {
+		_, err := ParseType("2i")
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType parsing an unknown key, got %v", err)
+		}
+
+		typ := MLIType("bad")
+		if typ.Size() != 0 {
+			t.Errorf("Expected 0 size for an invalid type, got %d", typ.Size())
+		}
+		_, err = typ.Encode(1)
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType encoding, got %v", err)
+		}
+		_, err = typ.Decode([]byte{0, 1})
+		if err != ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType decoding, got %v", err)
+		}
+	})
+}